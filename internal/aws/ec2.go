@@ -16,14 +16,18 @@ package aws
 import (
 	"context"
 	"fmt"
+	"slices"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 
 	"github.com/solomon-os/go-test/internal/logger"
 	"github.com/solomon-os/go-test/internal/models"
+	"github.com/solomon-os/go-test/internal/repository"
 	"github.com/solomon-os/go-test/internal/retry"
+	"github.com/solomon-os/go-test/internal/worker"
 )
 
 // EC2Client defines the interface for EC2 operations.
@@ -33,13 +37,32 @@ type EC2Client interface {
 		params *ec2.DescribeInstancesInput,
 		optFns ...func(*ec2.Options),
 	) (*ec2.DescribeInstancesOutput, error)
+	DescribeVolumes(
+		ctx context.Context,
+		params *ec2.DescribeVolumesInput,
+		optFns ...func(*ec2.Options),
+	) (*ec2.DescribeVolumesOutput, error)
 }
 
+const (
+	// instanceBatchSize is the most instance IDs sent in one
+	// DescribeInstances call.
+	instanceBatchSize = 200
+
+	// instanceBatchConcurrency is the most DescribeInstances batches run
+	// at once.
+	instanceBatchConcurrency = 4
+
+	// volumeBatchSize is the most volume IDs sent in one DescribeVolumes call.
+	volumeBatchSize = 200
+)
+
 // Client wraps the AWS EC2 client with helper methods.
 // It includes built-in retry logic for handling transient AWS API failures.
 type Client struct {
 	ec2Client   EC2Client
 	retryConfig retry.Config
+	rootVolumes bool
 }
 
 // NewClient creates a new AWS EC2 client with the specified region.
@@ -64,15 +87,24 @@ func NewClient(ctx context.Context, region string, opts ...ClientOption) (*Clien
 	return &Client{
 		ec2Client:   ec2.NewFromConfig(cfg),
 		retryConfig: options.retryConfig,
+		rootVolumes: options.rootVolumes,
 	}, nil
 }
 
 // NewClientWithEC2 creates a Client with a custom EC2 client implementation.
 // This is primarily used for testing with mock clients.
-func NewClientWithEC2(client EC2Client) *Client {
+func NewClientWithEC2(client EC2Client, opts ...ClientOption) *Client {
+	options := &clientOptions{
+		retryConfig: retry.AWSConfig.WithShouldRetry(IsRetryableError),
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	return &Client{
 		ec2Client:   client,
-		retryConfig: retry.AWSConfig.WithShouldRetry(IsRetryableError),
+		retryConfig: options.retryConfig,
+		rootVolumes: options.rootVolumes,
 	}
 }
 
@@ -89,7 +121,7 @@ func NewClientWithEC2AndRetry(client EC2Client, retryConfig retry.Config) *Clien
 func (c *Client) GetInstance(ctx context.Context, instanceID string) (*models.EC2Instance, error) {
 	logger.Debug("fetching EC2 instance", "instance_id", instanceID)
 
-	return retry.Do(ctx, c.retryConfig, func(ctx context.Context) (*models.EC2Instance, error) {
+	raw, err := retry.Do(ctx, c.retryConfig, func(ctx context.Context) (*types.Instance, error) {
 		input := &ec2.DescribeInstancesInput{
 			InstanceIds: []string{instanceID},
 		}
@@ -111,48 +143,263 @@ func (c *Client) GetInstance(ctx context.Context, instanceID string) (*models.EC
 		}
 
 		logger.Debug("successfully fetched EC2 instance", "instance_id", instanceID)
-		return convertEC2Instance(&output.Reservations[0].Instances[0]), nil
+		return &output.Reservations[0].Instances[0], nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := c.convertInstances(ctx, []types.Instance{*raw})
+	if err != nil {
+		return nil, err
+	}
+	return instances[0], nil
 }
 
 // GetInstances retrieves multiple EC2 instances by their IDs.
-// It includes automatic retry logic for transient AWS API failures.
+// The IDs are described in batches of at most instanceBatchSize, run
+// concurrently, each with automatic retry logic for transient AWS API
+// failures. IDs that do not exist are left out of the result rather than
+// failing the whole call.
 func (c *Client) GetInstances(
 	ctx context.Context,
 	instanceIDs []string,
 ) ([]*models.EC2Instance, error) {
 	logger.Debug("fetching multiple EC2 instances", "count", len(instanceIDs))
 
-	return retry.Do(ctx, c.retryConfig, func(ctx context.Context) ([]*models.EC2Instance, error) {
-		input := &ec2.DescribeInstancesInput{
-			InstanceIds: instanceIDs,
+	batches := slices.Collect(slices.Chunk(instanceIDs, instanceBatchSize))
+	pool := worker.NewPool(instanceBatchConcurrency)
+
+	var raw []types.Instance
+	for _, result := range worker.RunFunc(ctx, pool, batches, c.describeBatch) {
+		if result.Err != nil {
+			return nil, result.Err
 		}
+		raw = append(raw, result.Value...)
+	}
 
-		output, err := c.ec2Client.DescribeInstances(ctx, input)
+	logger.Info(
+		"fetched EC2 instances",
+		"requested",
+		len(instanceIDs),
+		"returned",
+		len(raw),
+	)
+	return c.convertInstances(ctx, raw)
+}
+
+// describeBatch describes the instances in ids. AWS rejects a whole request
+// if any of its IDs does not exist, so a batch failing that way is split in
+// halves and each described again, until the missing IDs are isolated and
+// left out of the result.
+func (c *Client) describeBatch(ctx context.Context, ids []string) ([]types.Instance, error) {
+	raw, err := c.describePages(ctx, &ec2.DescribeInstancesInput{InstanceIds: ids})
+	if err == nil || !IsNotFoundError(err) {
+		return raw, err
+	}
+
+	if len(ids) == 1 {
+		logger.Warn("instance not found", "instance_id", ids[0], "error", err)
+		return nil, nil
+	}
+
+	mid := len(ids) / 2
+	logger.Debug("bisecting instance batch", "count", len(ids))
+	left, err := c.describeBatch(ctx, ids[:mid])
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.describeBatch(ctx, ids[mid:])
+	if err != nil {
+		return nil, err
+	}
+	return append(left, right...), nil
+}
+
+// FindInstancesByTag retrieves the instances that have not been terminated
+// and whose tag key has one of values, following every page of results.
+func (c *Client) FindInstancesByTag(
+	ctx context.Context,
+	key string,
+	values []string,
+) ([]*models.EC2Instance, error) {
+	logger.Debug("finding EC2 instances by tag", "key", key, "values", len(values))
+
+	return c.describeAll(ctx, &ec2.DescribeInstancesInput{
+		Filters: []types.Filter{
+			{Name: aws.String("tag:" + key), Values: values},
+			{
+				Name:   aws.String(repository.FilterNotTerminated.Name),
+				Values: repository.FilterNotTerminated.Values,
+			},
+		},
+	})
+}
+
+// ListInstances retrieves the instances matching every filter, following
+// every page of results. With no filters, all instances in the region,
+// including recently terminated ones, are returned.
+func (c *Client) ListInstances(
+	ctx context.Context,
+	filters ...types.Filter,
+) ([]*models.EC2Instance, error) {
+	logger.Debug("listing EC2 instances", "filters", len(filters))
+
+	return c.describeAll(ctx, &ec2.DescribeInstancesInput{Filters: filters})
+}
+
+// describeAll runs DescribeInstances and converts the instances of every
+// page. Listings are not compared, so their root volumes are never
+// described.
+func (c *Client) describeAll(
+	ctx context.Context,
+	input *ec2.DescribeInstancesInput,
+) ([]*models.EC2Instance, error) {
+	raw, err := c.describePages(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("described EC2 instances", "count", len(raw))
+	instances := make([]*models.EC2Instance, 0, len(raw))
+	for i := range raw {
+		instances = append(instances, convertEC2Instance(&raw[i]))
+	}
+	return instances, nil
+}
+
+// describePages runs DescribeInstances and collects the instances of every
+// page, retrying each page on transient failures.
+func (c *Client) describePages(
+	ctx context.Context,
+	input *ec2.DescribeInstancesInput,
+) ([]types.Instance, error) {
+	paginator := ec2.NewDescribeInstancesPaginator(c.ec2Client, input)
+
+	var instances []types.Instance
+	for paginator.HasMorePages() {
+		output, err := retry.Do(ctx, c.retryConfig,
+			func(ctx context.Context) (*ec2.DescribeInstancesOutput, error) {
+				output, err := paginator.NextPage(ctx)
+				if err != nil {
+					logger.Warn("AWS API call failed, may retry",
+						"error", err,
+						"retryable", IsRetryableError(err))
+					return nil, NewAWSError("DescribeInstances", err)
+				}
+				return output, nil
+			})
 		if err != nil {
-			logger.Warn("AWS API call failed, may retry",
-				"count", len(instanceIDs),
-				"error", err,
-				"retryable", IsRetryableError(err))
-			return nil, NewAWSError("DescribeInstances", err)
+			return nil, err
 		}
-
-		var instances []*models.EC2Instance
 		for _, reservation := range output.Reservations {
-			for i := range reservation.Instances {
-				instances = append(instances, convertEC2Instance(&reservation.Instances[i]))
+			instances = append(instances, reservation.Instances...)
+		}
+	}
+	return instances, nil
+}
+
+// convertInstances converts raw instances to models, filling in their root
+// volume details from DescribeVolumes when the client is configured to.
+func (c *Client) convertInstances(
+	ctx context.Context,
+	raw []types.Instance,
+) ([]*models.EC2Instance, error) {
+	instances := make([]*models.EC2Instance, 0, len(raw))
+	byVolume := make(map[string]*models.EC2Instance)
+	for i := range raw {
+		inst := convertEC2Instance(&raw[i])
+		instances = append(instances, inst)
+		if id := rootVolumeID(&raw[i]); c.rootVolumes && id != "" {
+			byVolume[id] = inst
+		}
+	}
+
+	if len(byVolume) > 0 {
+		if err := c.fillRootVolumes(ctx, byVolume); err != nil {
+			return nil, err
+		}
+	}
+	return instances, nil
+}
+
+// fillRootVolumes describes the volumes keyed in byVolume, in batches of at
+// most volumeBatchSize IDs, and copies their details into the root block
+// device of the instance each is attached to.
+func (c *Client) fillRootVolumes(ctx context.Context, byVolume map[string]*models.EC2Instance) error {
+	ids := make([]string, 0, len(byVolume))
+	for id := range byVolume {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for batch := range slices.Chunk(ids, volumeBatchSize) {
+		volumes, err := c.describeVolumes(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for i := range volumes {
+			if inst, ok := byVolume[derefString(volumes[i].VolumeId)]; ok {
+				applyVolume(&inst.RootBlockDevice, &volumes[i])
 			}
 		}
+	}
 
-		logger.Info(
-			"fetched EC2 instances",
-			"requested",
-			len(instanceIDs),
-			"returned",
-			len(instances),
-		)
-		return instances, nil
-	})
+	logger.Debug("described root volumes", "count", len(ids))
+	return nil
+}
+
+// describeVolumes runs DescribeVolumes for ids, following NextToken and
+// retrying each page on transient failures.
+func (c *Client) describeVolumes(ctx context.Context, ids []string) ([]types.Volume, error) {
+	var volumes []types.Volume
+	var nextToken *string
+	for {
+		output, err := retry.Do(ctx, c.retryConfig,
+			func(ctx context.Context) (*ec2.DescribeVolumesOutput, error) {
+				output, err := c.ec2Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+					VolumeIds: ids,
+					NextToken: nextToken,
+				})
+				if err != nil {
+					logger.Warn("AWS API call failed, may retry",
+						"count", len(ids),
+						"error", err,
+						"retryable", IsRetryableError(err))
+					return nil, NewAWSError("DescribeVolumes", err)
+				}
+				return output, nil
+			})
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, output.Volumes...)
+		if derefString(output.NextToken) == "" {
+			return volumes, nil
+		}
+		nextToken = output.NextToken
+	}
+}
+
+// rootVolumeID returns the ID of the EBS volume attached as the root device
+// of instance, or "" if it has none.
+func rootVolumeID(instance *types.Instance) string {
+	for _, bdm := range instance.BlockDeviceMappings {
+		if derefString(bdm.DeviceName) == derefString(instance.RootDeviceName) && bdm.Ebs != nil {
+			return derefString(bdm.Ebs.VolumeId)
+		}
+	}
+	return ""
+}
+
+// applyVolume copies the details of volume into device, leaving
+// DeleteOnTermination, which belongs to the attachment, untouched.
+func applyVolume(device *models.BlockDevice, volume *types.Volume) {
+	device.VolumeSize = int(derefInt32(volume.Size))
+	device.VolumeType = string(volume.VolumeType)
+	device.Encrypted = derefBool(volume.Encrypted)
+	device.IOPS = int(derefInt32(volume.Iops))
+	device.Throughput = int(derefInt32(volume.Throughput))
 }
 
 func convertEC2Instance(instance *types.Instance) *models.EC2Instance {
@@ -170,6 +417,11 @@ func convertEC2Instance(instance *types.Instance) *models.EC2Instance {
 		SecurityGroups: make([]string, 0),
 	}
 
+	if instance.State != nil {
+		ec2Inst.State = string(instance.State.Name)
+	}
+	ec2Inst.LaunchTime = instance.LaunchTime
+
 	if instance.Placement != nil {
 		ec2Inst.AvailabilityZone = derefString(instance.Placement.AvailabilityZone)
 	}
@@ -221,3 +473,10 @@ func derefBool(b *bool) bool {
 	}
 	return *b
 }
+
+func derefInt32(i *int32) int32 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
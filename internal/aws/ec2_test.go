@@ -3,16 +3,24 @@ package aws
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/solomon-os/go-test/internal/retry"
 )
 
 // mockEC2Client implements EC2Client for testing.
 type mockEC2Client struct {
 	DescribeInstancesFunc func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	DescribeVolumesFunc   func(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error)
 }
 
 func (m *mockEC2Client) DescribeInstances(
@@ -23,6 +31,14 @@ func (m *mockEC2Client) DescribeInstances(
 	return m.DescribeInstancesFunc(ctx, params, optFns...)
 }
 
+func (m *mockEC2Client) DescribeVolumes(
+	ctx context.Context,
+	params *ec2.DescribeVolumesInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.DescribeVolumesOutput, error) {
+	return m.DescribeVolumesFunc(ctx, params, optFns...)
+}
+
 func TestNewClientWithEC2(t *testing.T) {
 	mock := &mockEC2Client{}
 	client := NewClientWithEC2(mock)
@@ -153,6 +169,8 @@ func TestClient_GetInstance_FullMapping(t *testing.T) {
 								PublicIpAddress:  aws.String("54.1.2.3"),
 								KeyName:          aws.String("full-key"),
 								EbsOptimized:     aws.Bool(true),
+								LaunchTime:       aws.Time(time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)),
+								State:            &types.InstanceState{Name: types.InstanceStateNameStopped},
 								Placement: &types.Placement{
 									AvailabilityZone: aws.String("us-west-2b"),
 								},
@@ -216,6 +234,8 @@ func TestClient_GetInstance_FullMapping(t *testing.T) {
 			"arn:aws:iam::123:instance-profile/test",
 		},
 		{"RootBlockDevice.DeleteOnTermination", instance.RootBlockDevice.DeleteOnTermination, true},
+		{"State", instance.State, "stopped"},
+		{"LaunchTime", instance.LaunchTime.Format(time.RFC3339), "2024-02-01T09:00:00Z"},
 	}
 
 	for _, tt := range tests {
@@ -313,6 +333,244 @@ func TestClient_GetInstances(t *testing.T) {
 	}
 }
 
+func TestClient_GetInstances_Batches(t *testing.T) {
+	ids := make([]string, 0, instanceBatchSize+50)
+	for i := range instanceBatchSize + 48 {
+		ids = append(ids, fmt.Sprintf("i-%04d", i))
+	}
+	ids = append(ids, "i-gone", "i-bad")
+
+	tests := []struct {
+		name      string
+		failWith  string
+		wantCount int
+		wantErr   bool
+	}{
+		{name: "not found IDs are skipped", failWith: "InvalidInstanceID.NotFound", wantCount: len(ids) - 2},
+		{name: "malformed IDs are skipped", failWith: "InvalidInstanceID.Malformed", wantCount: len(ids) - 2},
+		{name: "other errors fail", failWith: "UnauthorizedOperation", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var largest int
+			mock := &mockEC2Client{
+				DescribeInstancesFunc: func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+					mu.Lock()
+					largest = max(largest, len(params.InstanceIds))
+					mu.Unlock()
+
+					instances := make([]types.Instance, 0, len(params.InstanceIds))
+					for _, id := range params.InstanceIds {
+						if id == "i-gone" || id == "i-bad" {
+							return nil, &smithy.GenericAPIError{Code: tt.failWith, Message: "invalid ID " + id}
+						}
+						instances = append(instances, types.Instance{InstanceId: aws.String(id)})
+					}
+					return &ec2.DescribeInstancesOutput{
+						Reservations: []types.Reservation{{Instances: instances}},
+					}, nil
+				},
+			}
+
+			instances, err := NewClientWithEC2(mock).GetInstances(context.Background(), ids)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetInstances() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if largest > instanceBatchSize {
+				t.Errorf("DescribeInstances sent %d IDs, want at most %d", largest, instanceBatchSize)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(instances) != tt.wantCount {
+				t.Errorf("GetInstances() returned %d instances, want %d", len(instances), tt.wantCount)
+			}
+			for _, inst := range instances {
+				if inst.InstanceID == "i-gone" || inst.InstanceID == "i-bad" {
+					t.Errorf("GetInstances() returned missing instance %s", inst.InstanceID)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_FindInstancesByTag(t *testing.T) {
+	var calls []*ec2.DescribeInstancesInput
+	mock := &mockEC2Client{
+		DescribeInstancesFunc: func(
+			ctx context.Context,
+			params *ec2.DescribeInstancesInput,
+			optFns ...func(*ec2.Options),
+		) (*ec2.DescribeInstancesOutput, error) {
+			calls = append(calls, params)
+			if params.NextToken == nil {
+				return &ec2.DescribeInstancesOutput{
+					NextToken: aws.String("page-2"),
+					Reservations: []types.Reservation{
+						{Instances: []types.Instance{{InstanceId: aws.String("i-1")}}},
+					},
+				}, nil
+			}
+			return &ec2.DescribeInstancesOutput{
+				Reservations: []types.Reservation{
+					{Instances: []types.Instance{{InstanceId: aws.String("i-2")}}},
+				},
+			}, nil
+		},
+	}
+
+	instances, err := NewClientWithEC2(mock).FindInstancesByTag(context.Background(), "Name", []string{"web", "api"})
+	if err != nil {
+		t.Fatalf("FindInstancesByTag() error = %v", err)
+	}
+	if len(instances) != 2 || instances[0].InstanceID != "i-1" || instances[1].InstanceID != "i-2" {
+		t.Errorf("FindInstancesByTag() = %+v, want i-1 and i-2 from both pages", instances)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("DescribeInstances called %d times, want 2", len(calls))
+	}
+	filters := calls[0].Filters
+	if len(filters) != 2 || *filters[0].Name != "tag:Name" || len(filters[0].Values) != 2 {
+		t.Errorf("Filters = %+v, want a tag:Name filter with both values", filters)
+	}
+	if *filters[1].Name != "instance-state-name" {
+		t.Errorf("Filters = %+v, want terminated instances excluded", filters)
+	}
+}
+
+// instanceWithRootVolume returns an instance whose root device is volumeID.
+func instanceWithRootVolume(id, volumeID string) types.Instance {
+	return types.Instance{
+		InstanceId:     aws.String(id),
+		RootDeviceName: aws.String("/dev/xvda"),
+		BlockDeviceMappings: []types.InstanceBlockDeviceMapping{
+			{
+				DeviceName: aws.String("/dev/sdb"),
+				Ebs:        &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-data")},
+			},
+			{
+				DeviceName: aws.String("/dev/xvda"),
+				Ebs: &types.EbsInstanceBlockDevice{
+					VolumeId:            aws.String(volumeID),
+					DeleteOnTermination: aws.Bool(true),
+				},
+			},
+		},
+	}
+}
+
+func TestClient_GetInstances_RootVolumes(t *testing.T) {
+	count := volumeBatchSize + 1
+	raw := make([]types.Instance, 0, count)
+	ids := make([]string, 0, count)
+	for i := range count {
+		id := fmt.Sprintf("i-%04d", i)
+		raw = append(raw, instanceWithRootVolume(id, fmt.Sprintf("vol-%04d", i)))
+		ids = append(ids, id)
+	}
+
+	tests := []struct {
+		name        string
+		rootVolumes bool
+		wantCalls   int
+		wantSize    int
+	}{
+		{name: "enabled", rootVolumes: true, wantCalls: 3, wantSize: 30},
+		{name: "disabled", rootVolumes: false, wantCalls: 0, wantSize: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []*ec2.DescribeVolumesInput
+			mock := &mockEC2Client{
+				DescribeInstancesFunc: func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+					instances := make([]types.Instance, 0, len(params.InstanceIds))
+					for _, inst := range raw {
+						if slices.Contains(params.InstanceIds, *inst.InstanceId) {
+							instances = append(instances, inst)
+						}
+					}
+					return &ec2.DescribeInstancesOutput{
+						Reservations: []types.Reservation{{Instances: instances}},
+					}, nil
+				},
+				DescribeVolumesFunc: func(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+					calls = append(calls, params)
+					if len(calls) == 1 {
+						return nil, errors.New("connection reset by peer")
+					}
+					volumes := make([]types.Volume, 0, len(params.VolumeIds))
+					for _, id := range params.VolumeIds {
+						volumes = append(volumes, types.Volume{
+							VolumeId:   aws.String(id),
+							Size:       aws.Int32(30),
+							VolumeType: types.VolumeTypeGp3,
+							Encrypted:  aws.Bool(true),
+							Iops:       aws.Int32(3000),
+							Throughput: aws.Int32(125),
+						})
+					}
+					return &ec2.DescribeVolumesOutput{Volumes: volumes}, nil
+				},
+			}
+
+			retryConfig := retry.Config{MaxAttempts: 2, ShouldRetry: IsRetryableError}
+			client := NewClientWithEC2(mock,
+				WithRetryConfig(retryConfig), WithRootVolumes(tt.rootVolumes))
+			instances, err := client.GetInstances(context.Background(), ids)
+			if err != nil {
+				t.Fatalf("GetInstances() error = %v", err)
+			}
+
+			if len(calls) != tt.wantCalls {
+				t.Errorf("DescribeVolumes called %d times, want %d", len(calls), tt.wantCalls)
+			}
+			for _, call := range calls {
+				if len(call.VolumeIds) > volumeBatchSize {
+					t.Errorf("DescribeVolumes sent %d IDs, want at most %d", len(call.VolumeIds), volumeBatchSize)
+				}
+			}
+			for _, inst := range instances {
+				root := inst.RootBlockDevice
+				if root.VolumeSize != tt.wantSize || !root.DeleteOnTermination {
+					t.Fatalf("%s: RootBlockDevice = %+v, want size %d and delete on termination",
+						inst.InstanceID, root, tt.wantSize)
+				}
+				if tt.rootVolumes && (root.VolumeType != "gp3" || !root.Encrypted ||
+					root.IOPS != 3000 || root.Throughput != 125) {
+					t.Fatalf("%s: RootBlockDevice = %+v, want the volume details", inst.InstanceID, root)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_ListInstances_NoRootVolumes(t *testing.T) {
+	mock := &mockEC2Client{
+		DescribeInstancesFunc: func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+			return &ec2.DescribeInstancesOutput{
+				Reservations: []types.Reservation{{Instances: []types.Instance{
+					instanceWithRootVolume("i-0001", "vol-0001"),
+				}}},
+			}, nil
+		},
+		DescribeVolumesFunc: func(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+			t.Errorf("DescribeVolumes called for %v, want listings to skip root volumes", params.VolumeIds)
+			return &ec2.DescribeVolumesOutput{}, nil
+		},
+	}
+
+	client := NewClientWithEC2(mock, WithRootVolumes(true))
+	if _, err := client.ListInstances(context.Background()); err != nil {
+		t.Fatalf("ListInstances() error = %v", err)
+	}
+	if _, err := client.FindInstancesByTag(context.Background(), "Name", []string{"web"}); err != nil {
+		t.Fatalf("FindInstancesByTag() error = %v", err)
+	}
+}
+
 func TestConvertEC2Instance_NilFields(t *testing.T) {
 	// Test handling of nil fields
 	instance := types.Instance{
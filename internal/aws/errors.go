@@ -237,6 +237,7 @@ type ClientOption func(*clientOptions)
 
 type clientOptions struct {
 	retryConfig retry.Config
+	rootVolumes bool
 }
 
 // WithRetryConfig sets the retry configuration for the client.
@@ -245,3 +246,14 @@ func WithRetryConfig(cfg retry.Config) ClientOption {
 		o.retryConfig = cfg
 	}
 }
+
+// WithRootVolumes makes GetInstance and GetInstances describe the EBS volume
+// attached as each instance's root device, filling VolumeSize, VolumeType,
+// Encrypted, IOPS and Throughput of RootBlockDevice. It costs extra
+// DescribeVolumes calls, so enable it only when root_block_device
+// attributes are compared. Listings never describe root volumes.
+func WithRootVolumes(enabled bool) ClientOption {
+	return func(o *clientOptions) {
+		o.rootVolumes = enabled
+	}
+}
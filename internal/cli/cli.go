@@ -5,7 +5,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -13,10 +16,14 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/solomon-os/go-test/internal/aws"
+	"github.com/solomon-os/go-test/internal/correlation"
+	"github.com/solomon-os/go-test/internal/discovery"
 	"github.com/solomon-os/go-test/internal/drift"
 	"github.com/solomon-os/go-test/internal/logger"
 	"github.com/solomon-os/go-test/internal/models"
 	"github.com/solomon-os/go-test/internal/reporter"
+	"github.com/solomon-os/go-test/internal/repository"
+	awsrepo "github.com/solomon-os/go-test/internal/repository/aws"
 	"github.com/solomon-os/go-test/internal/terraform"
 )
 
@@ -24,6 +31,8 @@ import (
 type AWSClient interface {
 	GetInstance(ctx context.Context, instanceID string) (*models.EC2Instance, error)
 	GetInstances(ctx context.Context, instanceIDs []string) ([]*models.EC2Instance, error)
+	FindInstancesByTag(ctx context.Context, key string, values []string) ([]*models.EC2Instance, error)
+	List(ctx context.Context, filters ...repository.Filter) ([]*models.EC2Instance, error)
 }
 
 // App holds the CLI application dependencies.
@@ -37,13 +46,22 @@ type App struct {
 }
 
 var (
-	tfStatePath string
-	region      string
-	instanceIDs []string
-	attributes  []string
-	outputFmt   string
-	timeout     time.Duration
-	concurrency int
+	tfStatePaths []string
+	region       string
+	instanceIDs  []string
+	attributes   []string
+	outputFmt    string
+	timeout      time.Duration
+	concurrency  int
+	tfVars       []string
+	tfVarFiles   []string
+	planSource   string
+	refState     string
+	tfConfigs    []string
+	correlations []string
+	filters      []string
+	unmanaged    bool
+	ownerTags    []string
 )
 
 var (
@@ -78,7 +96,12 @@ func newDefaultApp() *App {
 	return &App{
 		Output: os.Stdout,
 		NewAWSClient: func(ctx context.Context, region string) (AWSClient, error) {
-			return aws.NewClient(ctx, region)
+			client, err := aws.NewClient(ctx, region,
+				aws.WithRootVolumes(drift.ComparesRootBlockDevice(attributes)))
+			if err != nil {
+				return nil, err
+			}
+			return regionalClient{Client: client, repo: awsrepo.NewEC2Repository(client)}, nil
 		},
 	}
 }
@@ -87,8 +110,9 @@ func setup() {
 	defaultApp = newDefaultApp()
 
 	rootCmd.Flags().
-		StringVarP(&tfStatePath, "tf-state", "t", "", "Path to Terraform state file (required)")
-	rootCmd.Flags().StringVarP(&region, "region", "r", "us-east-1", "AWS region")
+		StringArrayVarP(&tfStatePaths, "tf-state", "t", nil, "Path or glob of Terraform state, show -json output, .tf file or module directory (required, repeatable)")
+	rootCmd.Flags().
+		StringVarP(&region, "region", "r", "us-east-1", "AWS region for instances whose provider region is unknown")
 	rootCmd.Flags().
 		StringSliceVarP(&instanceIDs, "instances", "i", nil, "Instance IDs to check (comma-separated, or checks all in state)")
 	rootCmd.Flags().
@@ -98,14 +122,43 @@ func setup() {
 	rootCmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Timeout for AWS API calls")
 	rootCmd.Flags().
 		IntVar(&concurrency, "concurrency", drift.DefaultConcurrency, "Maximum concurrent drift checks")
+	rootCmd.Flags().
+		StringArrayVar(&tfVars, "var", nil, "Set a Terraform input variable (name=value, repeatable)")
+	rootCmd.Flags().
+		StringArrayVar(&tfVarFiles, "var-file", nil, "Load Terraform variables from a .tfvars file (repeatable)")
+	rootCmd.Flags().
+		StringVar(&planSource, "plan-source", string(terraform.PlanSourcePlanned), "Part of a saved plan (terraform show -json) to compare: planned, prior, drift")
+	rootCmd.Flags().
+		StringVar(&refState, "ref-state", "", "Terraform state used to resolve resource and data source references in HCL")
+	rootCmd.Flags().
+		StringArrayVar(&tfConfigs, "tf-config", nil, "HCL file or module directory to compare with the state and AWS (three-way drift, repeatable)")
+	rootCmd.Flags().
+		StringSliceVar(&correlations, "correlate", []string{"import"}, "How to find the instance IDs of HCL resources, in order: import, tag:<key>, mapping:<file>")
+	rootCmd.Flags().
+		StringArrayVar(&filters, "filter", nil, "Only check instances matching an EC2 filter, e.g. tag:Env=prod (name=value[,value...], repeatable)")
+	rootCmd.Flags().
+		BoolVar(&unmanaged, "unmanaged", false, "Also list instances in AWS that no Terraform state manages (filtered by --filter)")
+	rootCmd.Flags().
+		StringSliceVar(&ownerTags, "owner-tags", discovery.DefaultOwnerTags, "Tags reported for unmanaged instances to identify their owner")
 	must(rootCmd.MarkFlagRequired("tf-state"))
 
 	rootCmd.AddCommand(detectCmd)
 	detectCmd.Flags().
-		StringVarP(&tfStatePath, "tf-state", "t", "", "Path to Terraform state file (required)")
-	detectCmd.Flags().StringVarP(&region, "region", "r", "us-east-1", "AWS region")
+		StringArrayVarP(&tfStatePaths, "tf-state", "t", nil, "Path or glob of Terraform state, show -json output, .tf file or module directory (required, repeatable)")
+	detectCmd.Flags().
+		StringVarP(&region, "region", "r", "us-east-1", "AWS region if the provider region is unknown")
 	detectCmd.Flags().StringSliceVarP(&attributes, "attributes", "a", nil, "Attributes to check")
 	detectCmd.Flags().StringVarP(&outputFmt, "output", "o", "text", "Output format")
+	detectCmd.Flags().StringArrayVar(&tfVars, "var", nil, "Set a Terraform input variable")
+	detectCmd.Flags().StringArrayVar(&tfVarFiles, "var-file", nil, "Load Terraform variables from a file")
+	detectCmd.Flags().
+		StringVar(&planSource, "plan-source", string(terraform.PlanSourcePlanned), "Part of a saved plan to compare")
+	detectCmd.Flags().
+		StringVar(&refState, "ref-state", "", "Terraform state used to resolve references in HCL")
+	detectCmd.Flags().
+		StringArrayVar(&tfConfigs, "tf-config", nil, "HCL file or module directory for three-way drift")
+	detectCmd.Flags().
+		StringSliceVar(&correlations, "correlate", []string{"import"}, "How to find the instance IDs of HCL resources")
 	must(detectCmd.MarkFlagRequired("tf-state"))
 
 	rootCmd.AddCommand(listAttrsCmd)
@@ -124,49 +177,65 @@ func Run() error {
 }
 
 func runDetector(cmd *cobra.Command, args []string) error {
-	logger.Info("running drift detection", "tf_state", tfStatePath, "region", region)
+	logger.Info("running drift detection", "tf_state", tfStatePaths, "region", region)
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	parser := getParser()
-	tfInstances, err := parser.ParseFile(tfStatePath)
+	parser, err := getParser()
 	if err != nil {
-		logger.Error("failed to parse Terraform state", "path", tfStatePath, "error", err)
-		return fmt.Errorf("failed to parse Terraform state: %w", err)
+		return err
 	}
-
-	if len(tfInstances) == 0 {
-		logger.Error("no EC2 instances found in Terraform state", "path", tfStatePath)
-		return fmt.Errorf("no EC2 instances found in Terraform state")
+	tfInstances, conflicts, err := parseTerraformStates(parser)
+	if err != nil {
+		return err
 	}
-
-	targetIDs := instanceIDs
-	if len(targetIDs) == 0 {
-		for id := range tfInstances {
-			targetIDs = append(targetIDs, id)
-		}
+	tfInstances, err = correlateInstances(ctx, tfInstances)
+	if err != nil {
+		return err
 	}
-	logger.Debug("target instances", "count", len(targetIDs))
-
-	awsClient, err := getAWSClient(ctx, region)
+	unapplied, err := attachTerraformConfig(parser, tfInstances)
 	if err != nil {
-		logger.Error("failed to create AWS client", "region", region, "error", err)
-		return fmt.Errorf("failed to create AWS client: %w", err)
+		return err
 	}
 
-	awsInstances, err := awsClient.GetInstances(ctx, targetIDs)
-	if err != nil {
-		logger.Error("failed to fetch AWS instances", "error", err)
-		return fmt.Errorf("failed to fetch AWS instances: %w", err)
+	if len(tfInstances) == 0 {
+		logger.Error("no EC2 instances found in Terraform state", "path", tfStatePaths)
+		return fmt.Errorf("no EC2 instances found in Terraform state")
 	}
 
-	awsInstanceMap := make(map[string]*models.EC2Instance)
-	for _, inst := range awsInstances {
-		awsInstanceMap[inst.InstanceID] = inst
+	managed := tfInstances
+	var awsInstanceMap map[string]*models.EC2Instance
+	var listed map[string][]*models.EC2Instance
+	if len(filters) > 0 {
+		if listed, err = listAWSInstances(ctx, tfInstances); err != nil {
+			return err
+		}
+		awsInstanceMap, tfInstances = selectListed(listed, tfInstances)
+		if drift.ComparesRootBlockDevice(attributes) {
+			// Listings leave out root volume details, so the instances
+			// compared are fetched again with them.
+			ids := slices.Sorted(maps.Keys(tfInstances))
+			if awsInstanceMap, err = fetchAWSInstances(ctx, ids, tfInstances); err != nil {
+				return err
+			}
+		}
+	} else {
+		targetIDs := targetInstanceIDs(tfInstances)
+		tfInstances = selectInstances(tfInstances, targetIDs)
+		if awsInstanceMap, err = fetchAWSInstances(ctx, targetIDs, tfInstances); err != nil {
+			return err
+		}
 	}
 
 	detector := getDetector()
 	report := detector.DetectMultiple(ctx, awsInstanceMap, tfInstances)
+	report.Conflicts = conflicts
+	drift.AddUnapplied(report, unapplied)
+	if unmanaged {
+		if report.Unmanaged, err = findUnmanaged(ctx, managed, listed); err != nil {
+			return err
+		}
+	}
 
 	logger.Info(
 		"drift detection completed",
@@ -186,16 +255,25 @@ func runSingleDetect(cmd *cobra.Command, args []string) error {
 		"instance_id",
 		instanceID,
 		"tf_state",
-		tfStatePath,
+		tfStatePaths,
 	)
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	parser := getParser()
-	tfInstances, err := parser.ParseFile(tfStatePath)
+	parser, err := getParser()
+	if err != nil {
+		return err
+	}
+	tfInstances, _, err := parseTerraformStates(parser)
+	if err != nil {
+		return err
+	}
+	tfInstances, err = correlateInstances(ctx, tfInstances)
 	if err != nil {
-		logger.Error("failed to parse Terraform state", "path", tfStatePath, "error", err)
-		return fmt.Errorf("failed to parse Terraform state: %w", err)
+		return err
+	}
+	if _, err := attachTerraformConfig(parser, tfInstances); err != nil {
+		return err
 	}
 
 	tfInstance, err := parser.GetInstanceByID(tfInstances, instanceID)
@@ -210,9 +288,10 @@ func runSingleDetect(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	awsClient, err := getAWSClient(ctx, region)
+	instanceRegion := regionOf(tfInstance)
+	awsClient, err := getAWSClient(ctx, instanceRegion)
 	if err != nil {
-		logger.Error("failed to create AWS client", "region", region, "error", err)
+		logger.Error("failed to create AWS client", "region", instanceRegion, "error", err)
 		return fmt.Errorf("failed to create AWS client: %w", err)
 	}
 
@@ -236,6 +315,210 @@ func runSingleDetect(cmd *cobra.Command, args []string) error {
 	return rep.ReportSingle(result)
 }
 
+// targetInstanceIDs returns --instances, or the IDs of all tfInstances if
+// it is not set.
+func targetInstanceIDs(tfInstances map[string]*models.EC2Instance) []string {
+	targetIDs := instanceIDs
+	if len(targetIDs) == 0 {
+		for id := range tfInstances {
+			targetIDs = append(targetIDs, id)
+		}
+	}
+	logger.Debug("target instances", "count", len(targetIDs))
+	return targetIDs
+}
+
+// selectInstances returns the tfInstances with the given IDs, so that
+// Terraform instances that were not looked up in AWS are not reported
+// missing from it.
+func selectInstances(tfInstances map[string]*models.EC2Instance, ids []string) map[string]*models.EC2Instance {
+	selected := make(map[string]*models.EC2Instance, len(ids))
+	for _, id := range ids {
+		if inst, ok := tfInstances[id]; ok {
+			selected[id] = inst
+		}
+	}
+	return selected
+}
+
+// listAWSInstances lists the instances matching --filter that have not
+// been terminated, in every region of tfInstances, keyed by region.
+func listAWSInstances(
+	ctx context.Context,
+	tfInstances map[string]*models.EC2Instance,
+) (map[string][]*models.EC2Instance, error) {
+	parsed, err := parseFilterFlags(filters)
+	if err != nil {
+		return nil, err
+	}
+	parsed = repository.ExcludeTerminated(parsed)
+
+	listed := make(map[string][]*models.EC2Instance)
+	for _, r := range instanceRegions(tfInstances) {
+		awsClient, err := getAWSClient(ctx, r)
+		if err != nil {
+			logger.Error("failed to create AWS client", "region", r, "error", err)
+			return nil, fmt.Errorf("failed to create AWS client: %w", err)
+		}
+
+		awsInstances, err := awsClient.List(ctx, parsed...)
+		if err != nil {
+			logger.Error("failed to list AWS instances", "region", r, "error", err)
+			return nil, fmt.Errorf("failed to list AWS instances: %w", err)
+		}
+		listed[r] = awsInstances
+	}
+	return listed, nil
+}
+
+// selectListed returns the listed instances Terraform manages, limited to
+// --instances if set, together with tfInstances narrowed to them, so that
+// the report covers only the filtered slice of the fleet. Instances no
+// state manages are left to --unmanaged.
+func selectListed(
+	listed map[string][]*models.EC2Instance,
+	tfInstances map[string]*models.EC2Instance,
+) (map[string]*models.EC2Instance, map[string]*models.EC2Instance) {
+	matchedAWS := make(map[string]*models.EC2Instance)
+	matchedTF := make(map[string]*models.EC2Instance)
+	for _, awsInstances := range listed {
+		for _, awsInst := range awsInstances {
+			id := awsInst.InstanceID
+			tfInst, ok := tfInstances[id]
+			if !ok || (len(instanceIDs) > 0 && !slices.Contains(instanceIDs, id)) {
+				continue
+			}
+			matchedAWS[id] = awsInst
+			matchedTF[id] = tfInst
+		}
+	}
+	logger.Debug("listed filtered instances", "managed", len(matchedTF))
+	return matchedAWS, matchedTF
+}
+
+// parseFilterFlags parses --filter values of the form name=value[,value...].
+func parseFilterFlags(flags []string) ([]repository.Filter, error) {
+	parsed := make([]repository.Filter, 0, len(flags))
+	for _, flag := range flags {
+		f, err := repository.ParseFilter(flag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --filter: %w", err)
+		}
+		parsed = append(parsed, f)
+	}
+	return parsed, nil
+}
+
+// instanceRegions returns the regions of tfInstances, with --region
+// standing in for instances whose region is unknown.
+func instanceRegions(tfInstances map[string]*models.EC2Instance) []string {
+	var regions []string
+	for _, inst := range tfInstances {
+		if r := regionOf(inst); !slices.Contains(regions, r) {
+			regions = append(regions, r)
+		}
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// findUnmanaged returns the instances matching --filter in every region of
+// managed that no loaded Terraform state manages. Regions in listed, as
+// returned by listAWSInstances, are not listed again.
+func findUnmanaged(
+	ctx context.Context,
+	managed map[string]*models.EC2Instance,
+	listed map[string][]*models.EC2Instance,
+) ([]models.UnmanagedInstance, error) {
+	parsed, err := parseFilterFlags(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	var unmanaged []models.UnmanagedInstance
+	for _, r := range instanceRegions(managed) {
+		opts := []discovery.Option{discovery.WithOwnerTags(ownerTags...), discovery.WithRegion(r)}
+		if awsInstances, ok := listed[r]; ok {
+			unmanaged = append(unmanaged, discovery.Unmanaged(awsInstances, managed, opts...)...)
+			continue
+		}
+
+		awsClient, err := getAWSClient(ctx, r)
+		if err != nil {
+			logger.Error("failed to create AWS client", "region", r, "error", err)
+			return nil, fmt.Errorf("failed to create AWS client: %w", err)
+		}
+		found, err := discovery.FindUnmanaged(ctx, awsClient, managed,
+			append(opts, discovery.WithFilters(parsed...))...)
+		if err != nil {
+			logger.Error("failed to list AWS instances", "region", r, "error", err)
+			return nil, fmt.Errorf("failed to list AWS instances: %w", err)
+		}
+		unmanaged = append(unmanaged, found...)
+	}
+	return unmanaged, nil
+}
+
+// regionalClient is the AWSClient of a region. It lists instances through
+// an awsrepo.EC2Repository over the same client.
+type regionalClient struct {
+	*aws.Client
+	repo *awsrepo.EC2Repository
+}
+
+func (c regionalClient) List(ctx context.Context, filters ...repository.Filter) ([]*models.EC2Instance, error) {
+	return c.repo.List(ctx, filters...)
+}
+
+// fetchAWSInstances fetches the given instances from AWS, querying each
+// instance in the region of its Terraform provider configuration.
+// Instances without a known region are queried in --region.
+func fetchAWSInstances(
+	ctx context.Context,
+	targetIDs []string,
+	tfInstances map[string]*models.EC2Instance,
+) (map[string]*models.EC2Instance, error) {
+	byRegion := make(map[string][]string)
+	var regions []string
+	for _, id := range targetIDs {
+		r := regionOf(tfInstances[id])
+		if _, ok := byRegion[r]; !ok {
+			regions = append(regions, r)
+		}
+		byRegion[r] = append(byRegion[r], id)
+	}
+	sort.Strings(regions)
+
+	awsInstanceMap := make(map[string]*models.EC2Instance)
+	for _, r := range regions {
+		awsClient, err := getAWSClient(ctx, r)
+		if err != nil {
+			logger.Error("failed to create AWS client", "region", r, "error", err)
+			return nil, fmt.Errorf("failed to create AWS client: %w", err)
+		}
+
+		logger.Debug("fetching AWS instances", "region", r, "count", len(byRegion[r]))
+		awsInstances, err := awsClient.GetInstances(ctx, byRegion[r])
+		if err != nil {
+			logger.Error("failed to fetch AWS instances", "region", r, "error", err)
+			return nil, fmt.Errorf("failed to fetch AWS instances: %w", err)
+		}
+		for _, inst := range awsInstances {
+			awsInstanceMap[inst.InstanceID] = inst
+		}
+	}
+	return awsInstanceMap, nil
+}
+
+// regionOf returns the region of a Terraform instance, or --region if it is
+// not known.
+func regionOf(inst *models.EC2Instance) string {
+	if inst != nil && inst.Region != "" {
+		return inst.Region
+	}
+	return region
+}
+
 func runListAttributes(cmd *cobra.Command, args []string) {
 	out := defaultApp.Output
 	writef(out, "Available attributes for drift detection:\n")
@@ -253,11 +536,117 @@ func writef(w io.Writer, format string, args ...any) {
 	}
 }
 
-func getParser() terraform.StateParser {
+func getParser() (terraform.StateParser, error) {
 	if defaultApp.Parser != nil {
-		return defaultApp.Parser
+		return defaultApp.Parser, nil
+	}
+
+	vars, err := parseVarFlags(tfVars)
+	if err != nil {
+		return nil, err
+	}
+	source, err := terraform.ParsePlanSource(planSource)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --plan-source: %w", err)
+	}
+	return terraform.NewParser(
+		terraform.WithVariables(vars),
+		terraform.WithVarFiles(tfVarFiles...),
+		terraform.WithPlanSource(source),
+		terraform.WithReferenceState(refState),
+	), nil
+}
+
+// parseTerraformStates resolves the --tf-state paths, including globs and
+// workspace directories, and merges the instances of every state.
+func parseTerraformStates(
+	parser terraform.StateParser,
+) (map[string]*models.EC2Instance, []models.StateConflict, error) {
+	refs, err := terraform.ResolveStatePaths(tfStatePaths)
+	if err != nil {
+		logger.Error("failed to resolve Terraform state paths", "paths", tfStatePaths, "error", err)
+		return nil, nil, fmt.Errorf("failed to parse Terraform state: %w", err)
+	}
+
+	instances, conflicts, err := terraform.ParseStates(parser, refs)
+	if err != nil {
+		logger.Error("failed to parse Terraform state", "paths", tfStatePaths, "error", err)
+		return nil, nil, fmt.Errorf("failed to parse Terraform state: %w", err)
+	}
+	return instances, conflicts, nil
+}
+
+// correlateInstances finds the instance IDs of instances declared in HCL
+// with the --correlate strategies, and returns the instances keyed by ID.
+func correlateInstances(
+	ctx context.Context,
+	tfInstances map[string]*models.EC2Instance,
+) (map[string]*models.EC2Instance, error) {
+	strategies := make([]correlation.Strategy, 0, len(correlations))
+	for _, spec := range correlations {
+		s, err := correlation.Parse(spec, findInstancesByTag)
+		if err != nil {
+			return nil, err
+		}
+		strategies = append(strategies, s)
+	}
+	return correlation.Correlate(ctx, tfInstances, strategies...)
+}
+
+// findInstancesByTag looks up live instances by tag in region, or in
+// --region if the region is unknown.
+func findInstancesByTag(ctx context.Context, r, key string, values []string) ([]*models.EC2Instance, error) {
+	if r == "" {
+		r = region
+	}
+	awsClient, err := getAWSClient(ctx, r)
+	if err != nil {
+		logger.Error("failed to create AWS client", "region", r, "error", err)
+		return nil, fmt.Errorf("failed to create AWS client: %w", err)
+	}
+	return awsClient.FindInstancesByTag(ctx, key, values)
+}
+
+// attachTerraformConfig parses the --tf-config configurations, if any, and
+// pairs their instances with the state instances by address, so that drift
+// is classified three ways. It returns the configured instances missing
+// from the state.
+func attachTerraformConfig(
+	parser terraform.StateParser,
+	tfInstances map[string]*models.EC2Instance,
+) ([]*models.EC2Instance, error) {
+	if len(tfConfigs) == 0 {
+		return nil, nil
+	}
+
+	refs, err := terraform.ResolveConfigPaths(tfConfigs)
+	if err != nil {
+		logger.Error("failed to resolve Terraform configuration paths", "paths", tfConfigs, "error", err)
+		return nil, fmt.Errorf("failed to parse Terraform configuration: %w", err)
+	}
+	config, _, err := terraform.ParseStates(parser, refs)
+	if err != nil {
+		logger.Error("failed to parse Terraform configuration", "paths", tfConfigs, "error", err)
+		return nil, fmt.Errorf("failed to parse Terraform configuration: %w", err)
+	}
+
+	unapplied := terraform.AttachConfiguration(tfInstances, config)
+	logger.Debug("attached Terraform configuration",
+		"configured", len(config), "not_in_state", len(unapplied))
+	return unapplied, nil
+}
+
+// parseVarFlags splits --var values of the form name=value.
+func parseVarFlags(flags []string) (map[string]string, error) {
+	vars := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		name, value, ok := strings.Cut(flag, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid --var %q: expected name=value", flag)
+		}
+		vars[strings.TrimSpace(name)] = value
 	}
-	return terraform.NewParser()
+	return vars, nil
 }
 
 func getDetector() drift.Detector {
@@ -3,14 +3,19 @@ package cli
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/solomon-os/go-test/internal/drift"
 	"github.com/solomon-os/go-test/internal/models"
 	"github.com/solomon-os/go-test/internal/reporter"
+	"github.com/solomon-os/go-test/internal/repository"
 	"github.com/solomon-os/go-test/internal/terraform"
 )
 
@@ -18,6 +23,9 @@ type mockAWSClient struct {
 	instances   map[string]*models.EC2Instance
 	getErr      error
 	getMultiErr error
+	listFilters []repository.Filter
+	listCalls   int
+	fetched     [][]string
 }
 
 func (m *mockAWSClient) GetInstance(
@@ -38,6 +46,7 @@ func (m *mockAWSClient) GetInstances(
 	ctx context.Context,
 	instanceIDs []string,
 ) ([]*models.EC2Instance, error) {
+	m.fetched = append(m.fetched, instanceIDs)
 	if m.getMultiErr != nil {
 		return nil, m.getMultiErr
 	}
@@ -50,6 +59,44 @@ func (m *mockAWSClient) GetInstances(
 	return result, nil
 }
 
+func (m *mockAWSClient) FindInstancesByTag(
+	ctx context.Context,
+	key string,
+	values []string,
+) ([]*models.EC2Instance, error) {
+	var result []*models.EC2Instance
+	for _, inst := range m.instances {
+		for _, v := range values {
+			if inst.Tags[key] == v {
+				result = append(result, inst)
+			}
+		}
+	}
+	return result, nil
+}
+
+// List matches tag filters and ignores all others.
+func (m *mockAWSClient) List(
+	ctx context.Context,
+	filters ...repository.Filter,
+) ([]*models.EC2Instance, error) {
+	m.listFilters = filters
+	m.listCalls++
+	var result []*models.EC2Instance
+	for _, inst := range m.instances {
+		matches := true
+		for _, f := range filters {
+			if key, ok := strings.CutPrefix(f.Name, "tag:"); ok && !slices.Contains(f.Values, inst.Tags[key]) {
+				matches = false
+			}
+		}
+		if matches {
+			result = append(result, inst)
+		}
+	}
+	return result, nil
+}
+
 func TestNewDefaultApp(t *testing.T) {
 	app := newDefaultApp()
 	if app == nil {
@@ -68,7 +115,10 @@ func TestGetParser(t *testing.T) {
 
 	t.Run("returns default parser when not set", func(t *testing.T) {
 		defaultApp.Parser = nil
-		p := getParser()
+		p, err := getParser()
+		if err != nil {
+			t.Fatalf("getParser returned error: %v", err)
+		}
 		if p == nil {
 			t.Error("getParser returned nil")
 		}
@@ -77,12 +127,87 @@ func TestGetParser(t *testing.T) {
 	t.Run("returns custom parser when set", func(t *testing.T) {
 		customParser := terraform.NewParser()
 		defaultApp.Parser = customParser
-		p := getParser()
+		p, _ := getParser()
 		if p != customParser {
 			t.Error("getParser should return custom parser")
 		}
 		defaultApp.Parser = nil
 	})
+
+	t.Run("returns error for malformed --var", func(t *testing.T) {
+		defaultApp.Parser = nil
+		tfVars = []string{"missing-equals"}
+		defer func() { tfVars = nil }()
+
+		if _, err := getParser(); err == nil {
+			t.Error("getParser should return error for malformed --var")
+		}
+	})
+
+	t.Run("returns error for unknown --plan-source", func(t *testing.T) {
+		defaultApp.Parser = nil
+		planSource = "after"
+		defer func() { planSource = string(terraform.PlanSourcePlanned) }()
+
+		if _, err := getParser(); err == nil {
+			t.Error("getParser should return error for unknown --plan-source")
+		}
+	})
+}
+
+func TestParseVarFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "simple values",
+			flags: []string{"env=prod", "size=t2.micro"},
+			want:  map[string]string{"env": "prod", "size": "t2.micro"},
+		},
+		{
+			name:  "value containing equals and commas",
+			flags: []string{`tags={Name="a=b", Team="x"}`},
+			want:  map[string]string{"tags": `{Name="a=b", Team="x"}`},
+		},
+		{
+			name:  "empty value",
+			flags: []string{"env="},
+			want:  map[string]string{"env": ""},
+		},
+		{
+			name:    "missing equals",
+			flags:   []string{"env"},
+			wantErr: true,
+		},
+		{
+			name:    "missing name",
+			flags:   []string{"=prod"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVarFlags(tt.flags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVarFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseVarFlags() = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("parseVarFlags()[%s] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
 }
 
 func TestGetDetector(t *testing.T) {
@@ -171,7 +296,7 @@ func TestRunListAttributes(t *testing.T) {
 func TestRunDetector_ParseError(t *testing.T) {
 	setupOnce.Do(setup)
 
-	tfStatePath = "/nonexistent/path.tfstate"
+	tfStatePaths = []string{"/nonexistent/path.tfstate"}
 	err := runDetector(nil, nil)
 	if err == nil {
 		t.Error("runDetector should return error for nonexistent file")
@@ -188,7 +313,7 @@ func TestRunDetector_NoInstances(t *testing.T) {
 		t.Fatalf("Failed to create temp state file: %v", err)
 	}
 
-	tfStatePath = statePath
+	tfStatePaths = []string{statePath}
 	err := runDetector(nil, nil)
 	if err == nil {
 		t.Error("runDetector should return error for empty state")
@@ -216,7 +341,7 @@ func TestRunDetector_Success(t *testing.T) {
 		t.Fatalf("Failed to create temp state file: %v", err)
 	}
 
-	tfStatePath = statePath
+	tfStatePaths = []string{statePath}
 	region = "us-east-1"
 	instanceIDs = nil
 	attributes = []string{"instance_type"}
@@ -246,6 +371,514 @@ func TestRunDetector_Success(t *testing.T) {
 	defaultApp.Output = os.Stdout
 }
 
+func TestRunDetector_MultipleStates(t *testing.T) {
+	setupOnce.Do(setup)
+
+	tmpDir := t.TempDir()
+	states := map[string]string{
+		"terraform.tfstate":                          "i-123",
+		"terraform.tfstate.d/prod/terraform.tfstate": "i-456",
+		"legacy.tfstate":                             "i-123",
+	}
+	for name, id := range states {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		content := `{"version": 4, "resources": [{"type": "aws_instance", "name": "web",
+			"instances": [{"attributes": {"id": "` + id + `", "instance_type": "t2.micro"}}]}]}`
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create temp state file: %v", err)
+		}
+	}
+
+	tfStatePaths = []string{tmpDir, filepath.Join(tmpDir, "*.tfstate")}
+	instanceIDs = nil
+	attributes = []string{"instance_type"}
+	outputFmt = "json"
+
+	defaultApp.AWSClient = &mockAWSClient{
+		instances: map[string]*models.EC2Instance{
+			"i-123": {InstanceID: "i-123", InstanceType: "t2.micro"},
+			"i-456": {InstanceID: "i-456", InstanceType: "t2.micro"},
+		},
+	}
+	var buf bytes.Buffer
+	defaultApp.Output = &buf
+	defaultApp.Reporter = nil
+	defer func() {
+		defaultApp.AWSClient = nil
+		defaultApp.Output = os.Stdout
+	}()
+
+	if err := runDetector(nil, nil); err != nil {
+		t.Fatalf("runDetector returned error: %v", err)
+	}
+
+	var report models.DriftReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if report.TotalInstances != 2 {
+		t.Errorf("TotalInstances = %d, want 2", report.TotalInstances)
+	}
+	for _, r := range report.Results {
+		if r.InstanceID == "i-456" && r.Workspace != "prod" {
+			t.Errorf("i-456 workspace = %q, want prod", r.Workspace)
+		}
+	}
+	if len(report.Conflicts) != 1 || report.Conflicts[0].InstanceID != "i-123" {
+		t.Errorf("Conflicts = %+v, want i-123", report.Conflicts)
+	}
+}
+
+func TestRunDetector_Regions(t *testing.T) {
+	setupOnce.Do(setup)
+
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "test.tfstate")
+	stateContent := `{
+		"version": 4,
+		"resources": [
+			{
+				"type": "aws_instance",
+				"name": "east",
+				"instances": [{"attributes": {"id": "i-east", "instance_type": "t2.micro",
+					"arn": "arn:aws:ec2:us-east-1:123456789012:instance/i-east"}}]
+			},
+			{
+				"type": "aws_instance",
+				"name": "west",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"].west",
+				"instances": [{"attributes": {"id": "i-west", "instance_type": "t2.micro",
+					"availability_zone": "us-west-2a"}}]
+			},
+			{
+				"type": "aws_instance",
+				"name": "unknown",
+				"instances": [{"attributes": {"id": "i-unknown", "instance_type": "t2.micro"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(statePath, []byte(stateContent), 0o644); err != nil {
+		t.Fatalf("Failed to create temp state file: %v", err)
+	}
+
+	tfStatePaths = []string{statePath}
+	region = "eu-west-1"
+	instanceIDs = nil
+	attributes = []string{"instance_type"}
+	outputFmt = "json"
+
+	clients := map[string]*mockAWSClient{
+		"us-east-1": {instances: map[string]*models.EC2Instance{
+			"i-east": {InstanceID: "i-east", InstanceType: "t2.micro"},
+		}},
+		"us-west-2": {instances: map[string]*models.EC2Instance{
+			"i-west": {InstanceID: "i-west", InstanceType: "t2.micro"},
+		}},
+		"eu-west-1": {instances: map[string]*models.EC2Instance{
+			"i-unknown": {InstanceID: "i-unknown", InstanceType: "t2.micro"},
+		}},
+	}
+	newClient := defaultApp.NewAWSClient
+	defaultApp.AWSClient = nil
+	defaultApp.NewAWSClient = func(ctx context.Context, r string) (AWSClient, error) {
+		client, ok := clients[r]
+		if !ok {
+			return nil, errors.New("unexpected region " + r)
+		}
+		return client, nil
+	}
+	var buf bytes.Buffer
+	defaultApp.Output = &buf
+	defaultApp.Reporter = nil
+	defer func() {
+		defaultApp.NewAWSClient = newClient
+		defaultApp.Output = os.Stdout
+		region = "us-east-1"
+	}()
+
+	if err := runDetector(nil, nil); err != nil {
+		t.Fatalf("runDetector returned error: %v", err)
+	}
+
+	var report models.DriftReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if report.TotalInstances != 3 || report.DriftedInstances != 0 {
+		t.Errorf("report = %d total, %d drifted; want 3 total, 0 drifted",
+			report.TotalInstances, report.DriftedInstances)
+	}
+}
+
+func TestRunDetector_Correlation(t *testing.T) {
+	setupOnce.Do(setup)
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "main.tf")
+	configContent := `
+import {
+  to = aws_instance.web
+  id = "i-0aaa"
+}
+
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "api" {
+  instance_type = "t3.micro"
+  tags          = { Name = "api" }
+}
+
+resource "aws_instance" "db" {
+  instance_type = "t3.micro"
+}`
+	if err := os.WriteFile(configPath, []byte(configContent), 0o644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	tfStatePaths = []string{configPath}
+	correlations = []string{"import", "tag:Name"}
+	instanceIDs = nil
+	attributes = []string{"instance_type"}
+	outputFmt = "json"
+	defaultApp.AWSClient = &mockAWSClient{instances: map[string]*models.EC2Instance{
+		"i-0aaa": {InstanceID: "i-0aaa", InstanceType: "t3.micro"},
+		"i-0bbb": {InstanceID: "i-0bbb", InstanceType: "t3.large", Tags: map[string]string{"Name": "api"}},
+	}}
+	var buf bytes.Buffer
+	defaultApp.Output = &buf
+	defaultApp.Reporter = nil
+	defer func() {
+		correlations = []string{"import"}
+		defaultApp.AWSClient = nil
+		defaultApp.Output = os.Stdout
+	}()
+
+	if err := runDetector(nil, nil); err != nil {
+		t.Fatalf("runDetector returned error: %v", err)
+	}
+
+	var report models.DriftReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	results := make(map[string]models.DriftResult)
+	for _, r := range report.Results {
+		results[r.InstanceID] = r
+	}
+	if r, ok := results["i-0aaa"]; !ok || r.HasDrift || r.Address != "aws_instance.web" {
+		t.Errorf("i-0aaa result = %+v, want aws_instance.web without drift", r)
+	}
+	if r, ok := results["i-0bbb"]; !ok || !r.HasDrift || r.Address != "aws_instance.api" {
+		t.Errorf("i-0bbb result = %+v, want aws_instance.api with drift", r)
+	}
+}
+
+func TestRunDetector_Filter(t *testing.T) {
+	setupOnce.Do(setup)
+
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"resources": [
+			{
+				"type": "aws_instance",
+				"name": "web",
+				"instances": [
+					{"index_key": 0, "attributes": {"id": "i-prod", "instance_type": "t3.micro"}},
+					{"index_key": 1, "attributes": {"id": "i-dev", "instance_type": "t3.micro"}}
+				]
+			}
+		]
+	}`
+	if err := os.WriteFile(statePath, []byte(stateContent), 0o644); err != nil {
+		t.Fatalf("Failed to create temp state file: %v", err)
+	}
+
+	tfStatePaths = []string{statePath}
+	filters = []string{"tag:Env=prod"}
+	instanceIDs = nil
+	attributes = []string{"instance_type"}
+	outputFmt = "json"
+	mockClient := &mockAWSClient{instances: map[string]*models.EC2Instance{
+		"i-prod": {InstanceID: "i-prod", InstanceType: "t3.large", Tags: map[string]string{"Env": "prod"}},
+		"i-dev":  {InstanceID: "i-dev", InstanceType: "t3.large", Tags: map[string]string{"Env": "dev"}},
+	}}
+	defaultApp.AWSClient = mockClient
+	var buf bytes.Buffer
+	defaultApp.Output = &buf
+	defaultApp.Reporter = nil
+	defer func() {
+		filters = nil
+		defaultApp.AWSClient = nil
+		defaultApp.Output = os.Stdout
+	}()
+
+	if err := runDetector(nil, nil); err != nil {
+		t.Fatalf("runDetector returned error: %v", err)
+	}
+
+	var report models.DriftReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if report.TotalInstances != 1 || report.Results[0].InstanceID != "i-prod" {
+		t.Errorf("report = %+v, want only i-prod checked", report)
+	}
+	names := make([]string, 0, len(mockClient.listFilters))
+	for _, f := range mockClient.listFilters {
+		names = append(names, f.Name)
+	}
+	if want := []string{"tag:Env", "instance-state-name"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List filters = %v, want %v", names, want)
+	}
+	if len(mockClient.fetched) != 0 {
+		t.Errorf("GetInstances called with %v, want the listed instances compared", mockClient.fetched)
+	}
+
+	// Listings have no root volume details, so comparing them fetches the
+	// selected instances again.
+	attributes = []string{"instance_type", "root_block_device.volume_size"}
+	buf.Reset()
+	if err := runDetector(nil, nil); err != nil {
+		t.Fatalf("runDetector returned error: %v", err)
+	}
+	if want := [][]string{{"i-prod"}}; !reflect.DeepEqual(mockClient.fetched, want) {
+		t.Errorf("GetInstances called with %v, want %v", mockClient.fetched, want)
+	}
+
+	filters = []string{"tag:Env"}
+	if err := runDetector(nil, nil); err == nil {
+		t.Error("runDetector expected error for an invalid --filter")
+	}
+}
+
+func TestRunDetector_MissingInAWS(t *testing.T) {
+	setupOnce.Do(setup)
+
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"resources": [
+			{
+				"type": "aws_instance",
+				"name": "web",
+				"instances": [
+					{"index_key": 0, "attributes": {"id": "i-1", "instance_type": "t3.micro"}},
+					{"index_key": 1, "attributes": {"id": "i-2", "instance_type": "t3.micro"}},
+					{"index_key": 2, "attributes": {"id": "i-3", "instance_type": "t3.micro"}}
+				]
+			}
+		]
+	}`
+	if err := os.WriteFile(statePath, []byte(stateContent), 0o644); err != nil {
+		t.Fatalf("Failed to create temp state file: %v", err)
+	}
+
+	tfStatePaths = []string{statePath}
+	attributes = []string{"instance_type"}
+	outputFmt = "json"
+	defaultApp.AWSClient = &mockAWSClient{instances: map[string]*models.EC2Instance{
+		"i-1": {InstanceID: "i-1", InstanceType: "t3.micro"},
+	}}
+	defaultApp.Reporter = nil
+	defer func() {
+		instanceIDs = nil
+		defaultApp.AWSClient = nil
+		defaultApp.Output = os.Stdout
+	}()
+
+	tests := []struct {
+		name        string
+		instanceIDs []string
+		wantTotal   int
+		wantMissing int
+	}{
+		{name: "all instances", instanceIDs: nil, wantTotal: 3, wantMissing: 2},
+		{name: "selected instances", instanceIDs: []string{"i-1", "i-2"}, wantTotal: 2, wantMissing: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instanceIDs = tt.instanceIDs
+			var buf bytes.Buffer
+			defaultApp.Output = &buf
+
+			if err := runDetector(nil, nil); err != nil {
+				t.Fatalf("runDetector returned error: %v", err)
+			}
+
+			var report models.DriftReport
+			if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+				t.Fatalf("invalid JSON output: %v", err)
+			}
+			if report.TotalInstances != tt.wantTotal || report.MissingInstances != tt.wantMissing ||
+				report.DriftedInstances != tt.wantMissing {
+				t.Errorf("report = %d total, %d drifted, %d missing; want %d total, %d missing",
+					report.TotalInstances, report.DriftedInstances, report.MissingInstances,
+					tt.wantTotal, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestRunDetector_Unmanaged(t *testing.T) {
+	setupOnce.Do(setup)
+
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"resources": [
+			{
+				"type": "aws_instance",
+				"name": "web",
+				"instances": [{"attributes": {"id": "i-1", "instance_type": "t3.micro"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(statePath, []byte(stateContent), 0o644); err != nil {
+		t.Fatalf("Failed to create temp state file: %v", err)
+	}
+
+	tfStatePaths = []string{statePath}
+	instanceIDs = nil
+	attributes = []string{"instance_type"}
+	outputFmt = "json"
+	unmanaged = true
+	filters = []string{"tag:Env=prod"}
+	mockClient := &mockAWSClient{instances: map[string]*models.EC2Instance{
+		"i-1": {InstanceID: "i-1", InstanceType: "t3.micro", Tags: map[string]string{"Env": "prod"}},
+		"i-2": {
+			InstanceID:   "i-2",
+			InstanceType: "t3.large",
+			Tags:         map[string]string{"Env": "prod", "Owner": "alice"},
+		},
+		"i-3": {InstanceID: "i-3", InstanceType: "t3.large", Tags: map[string]string{"Env": "dev"}},
+	}}
+	defaultApp.AWSClient = mockClient
+	var buf bytes.Buffer
+	defaultApp.Output = &buf
+	defaultApp.Reporter = nil
+	defer func() {
+		unmanaged = false
+		filters = nil
+		defaultApp.AWSClient = nil
+		defaultApp.Output = os.Stdout
+	}()
+
+	if err := runDetector(nil, nil); err != nil {
+		t.Fatalf("runDetector returned error: %v", err)
+	}
+
+	var report models.DriftReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if report.TotalInstances != 1 || report.DriftedInstances != 0 {
+		t.Errorf("report = %d total, %d drifted; want i-1 checked without drift",
+			report.TotalInstances, report.DriftedInstances)
+	}
+	if mockClient.listCalls != 1 {
+		t.Errorf("List calls = %d, want the filtered listing reused", mockClient.listCalls)
+	}
+	want := []models.UnmanagedInstance{{
+		InstanceID:   "i-2",
+		Region:       "us-east-1",
+		InstanceType: "t3.large",
+		OwnerTags:    map[string]string{"Owner": "alice"},
+	}}
+	if !reflect.DeepEqual(report.Unmanaged, want) {
+		t.Errorf("Unmanaged = %+v, want %+v", report.Unmanaged, want)
+	}
+}
+
+func TestRunDetector_ThreeWay(t *testing.T) {
+	setupOnce.Do(setup)
+
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"resources": [
+			{
+				"type": "aws_instance",
+				"name": "web",
+				"instances": [{"attributes": {"id": "i-123", "instance_type": "t2.micro", "ami": "ami-old"}}]
+			}
+		]
+	}`
+	configPath := filepath.Join(tmpDir, "main.tf")
+	configContent := `
+resource "aws_instance" "web" {
+  instance_type = "t3.large"
+  ami           = "ami-old"
+}
+
+resource "aws_instance" "api" {
+  instance_type = "t3.small"
+}`
+	if err := os.WriteFile(statePath, []byte(stateContent), 0o644); err != nil {
+		t.Fatalf("Failed to create temp state file: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte(configContent), 0o644); err != nil {
+		t.Fatalf("Failed to create temp config file: %v", err)
+	}
+
+	tfStatePaths = []string{statePath}
+	tfConfigs = []string{configPath}
+	instanceIDs = nil
+	attributes = []string{"instance_type", "ami"}
+	outputFmt = "json"
+	defaultApp.AWSClient = &mockAWSClient{instances: map[string]*models.EC2Instance{
+		"i-123": {InstanceID: "i-123", InstanceType: "t2.micro", AMI: "ami-manual"},
+	}}
+	var buf bytes.Buffer
+	defaultApp.Output = &buf
+	defaultApp.Reporter = nil
+	defer func() {
+		tfConfigs = nil
+		defaultApp.AWSClient = nil
+		defaultApp.Output = os.Stdout
+	}()
+
+	if err := runDetector(nil, nil); err != nil {
+		t.Fatalf("runDetector returned error: %v", err)
+	}
+
+	var report models.DriftReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(report.Results) != 2 {
+		t.Fatalf("Results = %+v, want 2", report.Results)
+	}
+	if api := report.Results[1]; api.Address != "aws_instance.api" || api.Status != models.StatusUnapplied || !api.HasDrift {
+		t.Errorf("api result = %+v, want it reported as not applied", api)
+	}
+	if report.UnappliedInstances != 1 || report.DriftedInstances != 2 {
+		t.Errorf("report totals = %d drifted, %d unapplied, want 2, 1",
+			report.DriftedInstances, report.UnappliedInstances)
+	}
+	kinds := make(map[string]models.DriftKind)
+	for _, attr := range report.Results[0].DriftedAttrs {
+		kinds[attr.Path] = attr.Kind
+	}
+	want := map[string]models.DriftKind{
+		"instance_type": models.DriftUnapplied,
+		"ami":           models.DriftOutOfBand,
+	}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("drift kinds = %v, want %v", kinds, want)
+	}
+}
+
 func TestRunDetector_AWSClientError(t *testing.T) {
 	setupOnce.Do(setup)
 
@@ -265,7 +898,7 @@ func TestRunDetector_AWSClientError(t *testing.T) {
 		t.Fatalf("Failed to create temp state file: %v", err)
 	}
 
-	tfStatePath = statePath
+	tfStatePaths = []string{statePath}
 	mockClient := &mockAWSClient{
 		getMultiErr: errors.New("AWS API error"),
 	}
@@ -282,7 +915,7 @@ func TestRunDetector_AWSClientError(t *testing.T) {
 func TestRunSingleDetect_ParseError(t *testing.T) {
 	setupOnce.Do(setup)
 
-	tfStatePath = "/nonexistent/path.tfstate"
+	tfStatePaths = []string{"/nonexistent/path.tfstate"}
 	err := runSingleDetect(nil, []string{"i-123"})
 	if err == nil {
 		t.Error("runSingleDetect should return error for nonexistent file")
@@ -308,7 +941,7 @@ func TestRunSingleDetect_InstanceNotInTerraform(t *testing.T) {
 		t.Fatalf("Failed to create temp state file: %v", err)
 	}
 
-	tfStatePath = statePath
+	tfStatePaths = []string{statePath}
 	err := runSingleDetect(nil, []string{"i-nonexistent"})
 	if err == nil {
 		t.Error("runSingleDetect should return error for nonexistent instance")
@@ -334,7 +967,7 @@ func TestRunSingleDetect_AWSClientError(t *testing.T) {
 		t.Fatalf("Failed to create temp state file: %v", err)
 	}
 
-	tfStatePath = statePath
+	tfStatePaths = []string{statePath}
 	mockClient := &mockAWSClient{
 		getErr: errors.New("AWS API error"),
 	}
@@ -367,7 +1000,7 @@ func TestRunSingleDetect_Success(t *testing.T) {
 		t.Fatalf("Failed to create temp state file: %v", err)
 	}
 
-	tfStatePath = statePath
+	tfStatePaths = []string{statePath}
 	attributes = []string{"instance_type"}
 	outputFmt = "json"
 
@@ -0,0 +1,244 @@
+// Package correlation matches Terraform instances declared in HCL to live
+// EC2 instances.
+//
+// Instances parsed from HCL have no instance ID, so they are keyed by
+// resource address and never match an AWS instance. A Strategy finds their
+// IDs: from import blocks, from a tag such as Name, or from a mapping file.
+// Strategies are tried in order until every instance has an ID.
+//
+// Example usage:
+//
+//	mapping, _ := correlation.Mapping("instances.json")
+//	instances, err := correlation.Correlate(ctx, hclInstances,
+//	    correlation.Import(), correlation.Tag("Name", lookup), mapping)
+package correlation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/solomon-os/go-test/internal/logger"
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+// Strategy finds the instance IDs of Terraform instances that have none.
+type Strategy interface {
+	// Name identifies the strategy, e.g. "import" or "tag:Name".
+	Name() string
+
+	// Match returns the instance IDs found for instances, keyed by
+	// resource address. Instances it cannot match are left out.
+	Match(ctx context.Context, instances []*models.EC2Instance) (map[string]string, error)
+}
+
+// TagLookup returns the live instances in region whose tag key has one of
+// values.
+type TagLookup func(ctx context.Context, region, key string, values []string) ([]*models.EC2Instance, error)
+
+// Correlate sets the instance ID of each instance without one using the
+// first strategy that matches it, and returns the instances keyed by
+// instance ID. Instances no strategy matches keep their original key.
+func Correlate(
+	ctx context.Context,
+	instances map[string]*models.EC2Instance,
+	strategies ...Strategy,
+) (map[string]*models.EC2Instance, error) {
+	keys := make([]string, 0, len(instances))
+	for key := range instances {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pending []*models.EC2Instance
+	for _, key := range keys {
+		if !instances[key].IDKnown {
+			pending = append(pending, instances[key])
+		}
+	}
+
+	for _, s := range strategies {
+		if len(pending) == 0 {
+			break
+		}
+		ids, err := s.Match(ctx, pending)
+		if err != nil {
+			return nil, fmt.Errorf("correlation by %s failed: %w", s.Name(), err)
+		}
+
+		remaining := pending[:0]
+		for _, inst := range pending {
+			id, ok := ids[inst.Address]
+			if !ok {
+				remaining = append(remaining, inst)
+				continue
+			}
+			logger.Debug("correlated instance",
+				"address", inst.Address, "instance_id", id, "strategy", s.Name())
+			inst.InstanceID = id
+			inst.IDKnown = true
+			inst.MatchedBy = s.Name()
+		}
+		pending = remaining
+	}
+	for _, inst := range pending {
+		logger.Warn("instance not correlated with AWS", "address", inst.Address)
+	}
+	return keyByInstanceID(keys, instances), nil
+}
+
+// keyByInstanceID rekeys instances by instance ID, in the order of keys.
+// An ID matched by more than one resource is kept for the first.
+func keyByInstanceID(keys []string, instances map[string]*models.EC2Instance) map[string]*models.EC2Instance {
+	correlated := make(map[string]*models.EC2Instance, len(instances))
+	for _, key := range keys {
+		inst := instances[key]
+		if inst.IDKnown {
+			key = inst.InstanceID
+		}
+		if other, ok := correlated[key]; ok {
+			logger.Warn("instance matched by more than one resource",
+				"instance_id", key, "address", inst.Address, "kept", other.Address)
+			continue
+		}
+		correlated[key] = inst
+	}
+	return correlated
+}
+
+// Parse returns the strategy named by spec: "import", "tag:<key>" or
+// "mapping:<file>". lookup is used by tag strategies.
+func Parse(spec string, lookup TagLookup) (Strategy, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "import":
+		return Import(), nil
+	case "tag":
+		if arg == "" {
+			return nil, fmt.Errorf("invalid correlation %q: expected tag:<key>", spec)
+		}
+		return Tag(arg, lookup), nil
+	case "mapping":
+		if arg == "" {
+			return nil, fmt.Errorf("invalid correlation %q: expected mapping:<file>", spec)
+		}
+		return Mapping(arg)
+	}
+	return nil, fmt.Errorf("unknown correlation %q: expected import, tag:<key> or mapping:<file>", spec)
+}
+
+// importStrategy matches instances by the id of the import block
+// targeting them.
+type importStrategy struct{}
+
+// Import matches instances by the id of the import block targeting their
+// address.
+func Import() Strategy {
+	return importStrategy{}
+}
+
+func (importStrategy) Name() string { return models.MatchedByImport }
+
+func (importStrategy) Match(_ context.Context, instances []*models.EC2Instance) (map[string]string, error) {
+	ids := make(map[string]string)
+	for _, inst := range instances {
+		if inst.ImportID != "" {
+			ids[inst.Address] = inst.ImportID
+		}
+	}
+	return ids, nil
+}
+
+// tagStrategy matches instances by the value of one tag.
+type tagStrategy struct {
+	key    string
+	lookup TagLookup
+}
+
+// Tag matches instances to the live instance in their region that has the
+// same value for tag key, e.g. Name. Values shared by more than one live
+// instance are ambiguous and left unmatched.
+func Tag(key string, lookup TagLookup) Strategy {
+	return &tagStrategy{key: key, lookup: lookup}
+}
+
+func (s *tagStrategy) Name() string { return "tag:" + s.key }
+
+func (s *tagStrategy) Match(ctx context.Context, instances []*models.EC2Instance) (map[string]string, error) {
+	byRegion := make(map[string][]*models.EC2Instance)
+	for _, inst := range instances {
+		if inst.Tags[s.key] != "" {
+			byRegion[inst.Region] = append(byRegion[inst.Region], inst)
+		}
+	}
+
+	ids := make(map[string]string)
+	for region, group := range byRegion {
+		values := make([]string, 0, len(group))
+		for _, inst := range group {
+			values = append(values, inst.Tags[s.key])
+		}
+		live, err := s.lookup(ctx, region, s.key, values)
+		if err != nil {
+			return nil, err
+		}
+
+		byValue := make(map[string][]string)
+		for _, inst := range live {
+			value := inst.Tags[s.key]
+			byValue[value] = append(byValue[value], inst.InstanceID)
+		}
+		for _, inst := range group {
+			matches := byValue[inst.Tags[s.key]]
+			switch len(matches) {
+			case 0:
+			case 1:
+				ids[inst.Address] = matches[0]
+			default:
+				logger.Warn("tag matches more than one instance",
+					"address", inst.Address, "tag", s.key, "instances", matches)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// mappingStrategy matches instances with an explicit address to instance
+// ID mapping.
+type mappingStrategy struct {
+	ids map[string]string
+}
+
+// Mapping matches instances with the mapping file at path, a JSON object of
+// resource addresses to instance IDs, e.g.
+// {"aws_instance.web": "i-0123456789abcdef0"}. Addresses an instance was
+// moved from by moved blocks also match it.
+func Mapping(path string) (Strategy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping file: %w", err)
+	}
+	var ids map[string]string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping file %s: %w", path, err)
+	}
+	return &mappingStrategy{ids: ids}, nil
+}
+
+func (s *mappingStrategy) Name() string { return models.MatchedByMapping }
+
+func (s *mappingStrategy) Match(_ context.Context, instances []*models.EC2Instance) (map[string]string, error) {
+	ids := make(map[string]string)
+	for _, inst := range instances {
+		for _, addr := range append([]string{inst.Address}, inst.PreviousAddresses...) {
+			if id, ok := s.ids[addr]; ok {
+				ids[inst.Address] = id
+				break
+			}
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,170 @@
+package correlation
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+func hclInstances() map[string]*models.EC2Instance {
+	return map[string]*models.EC2Instance{
+		"aws_instance.web": {
+			InstanceID: "aws_instance.web",
+			Address:    "aws_instance.web",
+			ImportID:   "i-0aaa",
+		},
+		"aws_instance.api": {
+			InstanceID: "aws_instance.api",
+			Address:    "aws_instance.api",
+			Region:     "us-west-2",
+			Tags:       map[string]string{"Name": "api"},
+		},
+		"aws_instance.worker[0]": {
+			InstanceID: "aws_instance.worker[0]",
+			Address:    "aws_instance.worker[0]",
+			Tags:       map[string]string{"Name": "worker"},
+		},
+		"aws_instance.db": {
+			InstanceID: "aws_instance.db",
+			Address:    "aws_instance.db",
+		},
+	}
+}
+
+func TestCorrelate(t *testing.T) {
+	var regions []string
+	lookup := func(ctx context.Context, region, key string, values []string) ([]*models.EC2Instance, error) {
+		regions = append(regions, region)
+		return []*models.EC2Instance{
+			{InstanceID: "i-0bbb", Tags: map[string]string{"Name": "api"}},
+			{InstanceID: "i-0ccc", Tags: map[string]string{"Name": "worker"}},
+			{InstanceID: "i-0ddd", Tags: map[string]string{"Name": "worker"}},
+		}, nil
+	}
+
+	mappingPath := filepath.Join(t.TempDir(), "mapping.json")
+	mappingContent := `{"aws_instance.db": "i-0eee", "aws_instance.web": "i-0fff"}`
+	if err := os.WriteFile(mappingPath, []byte(mappingContent), 0o644); err != nil {
+		t.Fatalf("failed to write mapping: %v", err)
+	}
+	mapping, err := Mapping(mappingPath)
+	if err != nil {
+		t.Fatalf("Mapping() error = %v", err)
+	}
+
+	got, err := Correlate(context.Background(), hclInstances(), Import(), Tag("Name", lookup), mapping)
+	if err != nil {
+		t.Fatalf("Correlate() error = %v", err)
+	}
+
+	want := map[string]string{
+		"i-0aaa":                 "aws_instance.web",
+		"i-0bbb":                 "aws_instance.api",
+		"i-0eee":                 "aws_instance.db",
+		"aws_instance.worker[0]": "aws_instance.worker[0]",
+	}
+	if len(got) != len(want) {
+		t.Errorf("Correlate() returned %d instances, want %d", len(got), len(want))
+	}
+	for key, addr := range want {
+		inst, ok := got[key]
+		if !ok || inst.Address != addr {
+			t.Errorf("instance %q = %+v, want %s", key, inst, addr)
+		}
+	}
+	matchedBy := map[string]string{"i-0aaa": "import", "i-0bbb": "tag:Name", "i-0eee": "mapping"}
+	for id, by := range matchedBy {
+		if got[id] != nil && got[id].MatchedBy != by {
+			t.Errorf("%s: MatchedBy = %q, want %q", id, got[id].MatchedBy, by)
+		}
+		if got[id] != nil && !got[id].IDKnown {
+			t.Errorf("%s: IDKnown = false, want true", id)
+		}
+	}
+	if len(regions) != 2 {
+		t.Errorf("lookup called for regions %v, want one call per region", regions)
+	}
+}
+
+func TestMapping_MovedAddresses(t *testing.T) {
+	mappingPath := filepath.Join(t.TempDir(), "mapping.json")
+	if err := os.WriteFile(mappingPath, []byte(`{"aws_instance.server": "i-0aaa"}`), 0o644); err != nil {
+		t.Fatalf("failed to write mapping: %v", err)
+	}
+	mapping, err := Mapping(mappingPath)
+	if err != nil {
+		t.Fatalf("Mapping() error = %v", err)
+	}
+
+	inst := &models.EC2Instance{
+		InstanceID:        "app",
+		Address:           "aws_instance.app",
+		PreviousAddresses: []string{"aws_instance.server"},
+	}
+	ids, err := mapping.Match(context.Background(), []*models.EC2Instance{inst})
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	if ids["aws_instance.app"] != "i-0aaa" {
+		t.Errorf("Match() = %v, want aws_instance.app matched through its moved address", ids)
+	}
+}
+
+func TestCorrelate_KeepsInstanceIDs(t *testing.T) {
+	instances := map[string]*models.EC2Instance{
+		"i-0123": {InstanceID: "i-0123", IDKnown: true, Address: "aws_instance.web", ImportID: "i-0999"},
+	}
+	got, err := Correlate(context.Background(), instances, Import())
+	if err != nil {
+		t.Fatalf("Correlate() error = %v", err)
+	}
+	if got["i-0123"] == nil || got["i-0123"].InstanceID != "i-0123" {
+		t.Errorf("Correlate() = %+v, want the state instance ID kept", got)
+	}
+}
+
+func TestCorrelate_LookupError(t *testing.T) {
+	lookup := func(ctx context.Context, region, key string, values []string) ([]*models.EC2Instance, error) {
+		return nil, errors.New("access denied")
+	}
+	if _, err := Correlate(context.Background(), hclInstances(), Tag("Name", lookup)); err == nil {
+		t.Error("Correlate() expected error from tag lookup")
+	}
+}
+
+func TestParse(t *testing.T) {
+	mappingPath := filepath.Join(t.TempDir(), "mapping.json")
+	if err := os.WriteFile(mappingPath, []byte(`{}`), 0o644); err != nil {
+		t.Fatalf("failed to write mapping: %v", err)
+	}
+
+	tests := []struct {
+		spec     string
+		wantName string
+		wantErr  bool
+	}{
+		{spec: "import", wantName: "import"},
+		{spec: "tag:Name", wantName: "tag:Name"},
+		{spec: "mapping:" + mappingPath, wantName: "mapping"},
+		{spec: "tag:", wantErr: true},
+		{spec: "mapping:", wantErr: true},
+		{spec: "mapping:/nonexistent.json", wantErr: true},
+		{spec: "address", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && s.Name() != tt.wantName {
+				t.Errorf("Name() = %q, want %q", s.Name(), tt.wantName)
+			}
+		})
+	}
+}
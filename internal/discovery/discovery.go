@@ -0,0 +1,166 @@
+// Package discovery finds EC2 instances that no Terraform state manages.
+//
+// Instances launched by hand or by other tooling never show up as drift,
+// because drift detection starts from the instances Terraform knows about.
+// This package starts from AWS instead: it lists the instances of a region
+// and reports the ones missing from every loaded state, with the tags and
+// launch time needed to track down who created them.
+//
+// Example usage:
+//
+//	repo := awsrepo.NewEC2Repository(client)
+//	unmanaged, err := discovery.FindUnmanaged(ctx, repo, tfInstances,
+//	    discovery.WithFilters(repository.VPCFilter("vpc-0123")))
+package discovery
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/solomon-os/go-test/internal/logger"
+	"github.com/solomon-os/go-test/internal/models"
+	"github.com/solomon-os/go-test/internal/repository"
+)
+
+// DefaultOwnerTags are the tags reported for an unmanaged instance to help
+// find its owner.
+var DefaultOwnerTags = []string{"Name", "Owner", "CreatedBy", "Team", "Environment"}
+
+// Lister lists the instances matching filters, as
+// repository.EC2Repository does.
+type Lister interface {
+	List(ctx context.Context, filters ...repository.Filter) ([]*models.EC2Instance, error)
+}
+
+// Option is a functional option for FindUnmanaged.
+type Option func(*options)
+
+type options struct {
+	filters   []repository.Filter
+	ownerTags []string
+	region    string
+}
+
+// WithFilters limits the search to instances matching every filter, such
+// as repository.TagFilter or repository.VPCFilter.
+func WithFilters(filters ...repository.Filter) Option {
+	return func(o *options) {
+		o.filters = append(o.filters, filters...)
+	}
+}
+
+// WithOwnerTags sets the tags reported for each unmanaged instance.
+// DefaultOwnerTags is used if it is not set.
+func WithOwnerTags(keys ...string) Option {
+	return func(o *options) {
+		o.ownerTags = keys
+	}
+}
+
+// WithRegion records the region being searched on each unmanaged instance.
+func WithRegion(region string) Option {
+	return func(o *options) {
+		o.region = region
+	}
+}
+
+// FindUnmanaged lists the instances of lister that have not been terminated,
+// and returns those absent from managed, the instances of every loaded
+// Terraform state, oldest first. An instance is managed if managed has it
+// under its ID as key or as InstanceID.
+func FindUnmanaged(
+	ctx context.Context,
+	lister Lister,
+	managed map[string]*models.EC2Instance,
+	opts ...Option,
+) ([]models.UnmanagedInstance, error) {
+	o := newOptions(opts)
+	instances, err := lister.List(ctx, repository.ExcludeTerminated(o.filters)...)
+	if err != nil {
+		return nil, err
+	}
+	return unmanagedOf(instances, managed, o), nil
+}
+
+// Unmanaged is FindUnmanaged for instances already listed, e.g. with the
+// same filters for drift detection. WithFilters has no effect.
+func Unmanaged(
+	instances []*models.EC2Instance,
+	managed map[string]*models.EC2Instance,
+	opts ...Option,
+) []models.UnmanagedInstance {
+	return unmanagedOf(instances, managed, newOptions(opts))
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{ownerTags: DefaultOwnerTags}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// unmanagedOf returns the instances absent from managed, oldest first.
+func unmanagedOf(
+	instances []*models.EC2Instance,
+	managed map[string]*models.EC2Instance,
+	o *options,
+) []models.UnmanagedInstance {
+	known := make(map[string]bool, len(managed))
+	for id, inst := range managed {
+		known[id] = true
+		known[inst.InstanceID] = true
+	}
+
+	var unmanaged []models.UnmanagedInstance
+	for _, inst := range instances {
+		if known[inst.InstanceID] {
+			continue
+		}
+		unmanaged = append(unmanaged, models.UnmanagedInstance{
+			InstanceID:   inst.InstanceID,
+			Region:       o.region,
+			InstanceType: inst.InstanceType,
+			State:        inst.State,
+			VpcID:        inst.VpcID,
+			LaunchTime:   inst.LaunchTime,
+			OwnerTags:    ownerTags(inst.Tags, o.ownerTags),
+		})
+	}
+	slices.SortFunc(unmanaged, compareLaunch)
+
+	logger.Info("found unmanaged instances",
+		"region", o.region, "listed", len(instances), "unmanaged", len(unmanaged))
+	return unmanaged
+}
+
+// ownerTags returns the tags among keys, or nil if there are none.
+func ownerTags(tags map[string]string, keys []string) map[string]string {
+	var owner map[string]string
+	for _, key := range keys {
+		value, ok := tags[key]
+		if !ok {
+			continue
+		}
+		if owner == nil {
+			owner = make(map[string]string)
+		}
+		owner[key] = value
+	}
+	return owner
+}
+
+// compareLaunch orders instances by launch time, those without one last,
+// then by ID.
+func compareLaunch(a, b models.UnmanagedInstance) int {
+	switch {
+	case a.LaunchTime == nil && b.LaunchTime != nil:
+		return 1
+	case a.LaunchTime != nil && b.LaunchTime == nil:
+		return -1
+	case a.LaunchTime != nil && !a.LaunchTime.Equal(*b.LaunchTime):
+		return a.LaunchTime.Compare(*b.LaunchTime)
+	}
+	return cmp.Compare(a.InstanceID, b.InstanceID)
+}
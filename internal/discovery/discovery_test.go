@@ -0,0 +1,168 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/solomon-os/go-test/internal/aws"
+	"github.com/solomon-os/go-test/internal/models"
+	"github.com/solomon-os/go-test/internal/repository"
+	awsrepo "github.com/solomon-os/go-test/internal/repository/aws"
+)
+
+// fakeEC2Client implements aws.EC2Client, returning its instances one per
+// page.
+type fakeEC2Client struct {
+	instances []types.Instance
+	err       error
+	filters   []types.Filter
+}
+
+func (f *fakeEC2Client) DescribeInstances(
+	ctx context.Context,
+	params *ec2.DescribeInstancesInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.DescribeInstancesOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.filters = params.Filters
+
+	page := 0
+	if params.NextToken != nil {
+		page, _ = strconv.Atoi(*params.NextToken)
+	}
+	output := &ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{{Instances: f.instances[page : page+1]}},
+	}
+	if page+1 < len(f.instances) {
+		output.NextToken = awssdk.String(strconv.Itoa(page + 1))
+	}
+	return output, nil
+}
+
+func (f *fakeEC2Client) DescribeVolumes(
+	ctx context.Context,
+	params *ec2.DescribeVolumesInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.DescribeVolumesOutput, error) {
+	return &ec2.DescribeVolumesOutput{}, nil
+}
+
+func instance(id string, launched time.Time, tags map[string]string) types.Instance {
+	inst := types.Instance{
+		InstanceId:   awssdk.String(id),
+		InstanceType: types.InstanceTypeT2Micro,
+		VpcId:        awssdk.String("vpc-0123"),
+		LaunchTime:   awssdk.Time(launched),
+		State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+	}
+	for k, v := range tags {
+		inst.Tags = append(inst.Tags, types.Tag{Key: awssdk.String(k), Value: awssdk.String(v)})
+	}
+	return inst
+}
+
+func TestFindUnmanaged(t *testing.T) {
+	older := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+	client := &fakeEC2Client{instances: []types.Instance{
+		instance("i-0new", newer, map[string]string{"Team": "data"}),
+		instance("i-0managed", older, nil),
+		instance("i-0old", older, map[string]string{"Owner": "alice", "CostCenter": "42"}),
+		instance("i-0hcl", older, nil),
+	}}
+	repo := awsrepo.NewEC2Repository(aws.NewClientWithEC2(client))
+	managed := map[string]*models.EC2Instance{
+		"i-0managed": {InstanceID: "i-0managed"},
+		"web":        {InstanceID: "i-0hcl", Address: "aws_instance.web"},
+	}
+
+	got, err := FindUnmanaged(context.Background(), repo, managed,
+		WithFilters(repository.VPCFilter("vpc-0123")), WithRegion("eu-west-1"))
+	if err != nil {
+		t.Fatalf("FindUnmanaged() error = %v", err)
+	}
+
+	want := []models.UnmanagedInstance{
+		{
+			InstanceID:   "i-0old",
+			Region:       "eu-west-1",
+			InstanceType: "t2.micro",
+			State:        "running",
+			VpcID:        "vpc-0123",
+			LaunchTime:   &older,
+			OwnerTags:    map[string]string{"Owner": "alice"},
+		},
+		{
+			InstanceID:   "i-0new",
+			Region:       "eu-west-1",
+			InstanceType: "t2.micro",
+			State:        "running",
+			VpcID:        "vpc-0123",
+			LaunchTime:   &newer,
+			OwnerTags:    map[string]string{"Team": "data"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindUnmanaged() = %+v, want %+v", got, want)
+	}
+
+	if len(client.filters) != 2 || *client.filters[0].Name != "vpc-id" ||
+		*client.filters[1].Name != "instance-state-name" {
+		t.Errorf("DescribeInstances filters = %+v, want vpc-id and terminated instances excluded", client.filters)
+	}
+}
+
+func TestFindUnmanaged_OwnerTags(t *testing.T) {
+	launched := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+	client := &fakeEC2Client{instances: []types.Instance{
+		instance("i-0aaa", launched, map[string]string{"Name": "scratch", "Contact": "bob"}),
+	}}
+	repo := awsrepo.NewEC2Repository(aws.NewClientWithEC2(client))
+
+	got, err := FindUnmanaged(context.Background(), repo, nil, WithOwnerTags("Contact"))
+	if err != nil {
+		t.Fatalf("FindUnmanaged() error = %v", err)
+	}
+	if len(got) != 1 || !reflect.DeepEqual(got[0].OwnerTags, map[string]string{"Contact": "bob"}) {
+		t.Errorf("FindUnmanaged() = %+v, want only the Contact tag", got)
+	}
+}
+
+func TestFindUnmanaged_ListError(t *testing.T) {
+	client := &fakeEC2Client{err: errors.New("access denied")}
+	repo := awsrepo.NewEC2Repository(aws.NewClientWithEC2(client))
+
+	if _, err := FindUnmanaged(context.Background(), repo, nil); err == nil {
+		t.Error("FindUnmanaged() expected error from List")
+	}
+}
+
+func TestUnmanaged(t *testing.T) {
+	launched := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+	listed := []*models.EC2Instance{
+		{InstanceID: "i-0managed"},
+		{InstanceID: "i-0aaa", LaunchTime: &launched, Tags: map[string]string{"Owner": "alice"}},
+	}
+	managed := map[string]*models.EC2Instance{"i-0managed": {InstanceID: "i-0managed"}}
+
+	got := Unmanaged(listed, managed, WithRegion("us-east-1"))
+	want := []models.UnmanagedInstance{{
+		InstanceID: "i-0aaa",
+		Region:     "us-east-1",
+		LaunchTime: &launched,
+		OwnerTags:  map[string]string{"Owner": "alice"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmanaged() = %+v, want %+v", got, want)
+	}
+}
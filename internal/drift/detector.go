@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
 
@@ -44,6 +45,18 @@ var DefaultAttributes = []string{
 	"root_block_device.encrypted",
 }
 
+// ComparesRootBlockDevice reports whether attributes, or DefaultAttributes
+// if attributes is empty, include root_block_device or one of its fields.
+// Only then do the AWS side's root volume details need to be fetched.
+func ComparesRootBlockDevice(attributes []string) bool {
+	if len(attributes) == 0 {
+		attributes = DefaultAttributes
+	}
+	return slices.ContainsFunc(attributes, func(attr string) bool {
+		return attr == "root_block_device" || strings.HasPrefix(attr, "root_block_device.")
+	})
+}
+
 // Detector defines the interface for drift detection operations.
 type Detector interface {
 	Detect(awsInstance, tfInstance *models.EC2Instance) *models.DriftResult
@@ -127,10 +140,20 @@ func (d *DefaultDetector) Detect(awsInstance, tfInstance *models.EC2Instance) *m
 	)
 	result := &models.DriftResult{
 		InstanceID:   awsInstance.InstanceID,
+		Address:      tfInstance.Address,
+		StateFile:    tfInstance.StateFile,
+		Workspace:    tfInstance.Workspace,
+		MatchedBy:    tfInstance.MatchedBy,
+		MovedFrom:    tfInstance.MovedFrom,
 		HasDrift:     false,
 		DriftedAttrs: make([]models.DriftedAttr, 0),
 	}
 
+	compare := d.compare
+	if tfInstance.Configuration != nil {
+		compare = d.compareThreeWay
+	}
+
 	for _, attr := range d.attributes {
 		awsValue, tfValue, err := d.getAttributeValues(awsInstance, tfInstance, attr)
 		if err != nil {
@@ -146,14 +169,11 @@ func (d *DefaultDetector) Detect(awsInstance, tfInstance *models.EC2Instance) *m
 			continue
 		}
 
-		if !d.valuesEqual(awsValue, tfValue) {
-			logger.Debug("drift detected", "instance_id", awsInstance.InstanceID, "attribute", attr)
-			result.HasDrift = true
-			result.DriftedAttrs = append(result.DriftedAttrs, models.DriftedAttr{
-				Path:           attr,
-				AWSValue:       awsValue,
-				TerraformValue: tfValue,
-			})
+		for _, drifted := range compare(tfInstance, attr, awsValue, tfValue) {
+			if !drifted.Ignored && !drifted.Unknown {
+				result.HasDrift = true
+			}
+			result.DriftedAttrs = append(result.DriftedAttrs, drifted)
 		}
 	}
 
@@ -172,9 +192,69 @@ func (d *DefaultDetector) Detect(awsInstance, tfInstance *models.EC2Instance) *m
 	return result
 }
 
+// compare compares attr between the AWS and Terraform values of an
+// instance and returns the differences: none if they are equal, the
+// attribute marked ignored if its drift is covered by lifecycle
+// ignore_changes, and the parts whose Terraform value is unknown until
+// apply marked unknown instead of compared.
+func (d *DefaultDetector) compare(
+	tfInstance *models.EC2Instance,
+	attr string,
+	awsValue, tfValue any,
+) []models.DriftedAttr {
+	id := tfInstance.InstanceID
+	all, keys := unknownKeys(tfInstance.Unknown, attr)
+	if all {
+		logger.Debug("terraform value unknown", "instance_id", id, "attribute", attr)
+		return []models.DriftedAttr{unknownAttr(tfInstance, attr, awsValue)}
+	}
+
+	var drifted []models.DriftedAttr
+	if len(keys) > 0 {
+		awsMap, awsOK := awsValue.(map[string]string)
+		tfMap, tfOK := tfValue.(map[string]string)
+		if awsOK && tfOK {
+			for _, key := range keys {
+				drifted = append(drifted, unknownAttr(tfInstance, attr+"."+key, awsMap[key]))
+			}
+			awsValue, tfValue = withoutKeys(awsMap, keys), withoutKeys(tfMap, keys)
+		}
+	}
+
+	if d.valuesEqual(awsValue, tfValue) {
+		return drifted
+	}
+
+	ignored := d.isIgnored(tfInstance.IgnoreChanges, attr, awsValue, tfValue)
+	if ignored {
+		logger.Debug("ignored drift", "instance_id", id, "attribute", attr)
+	} else {
+		logger.Debug("drift detected", "instance_id", id, "attribute", attr)
+	}
+	return append(drifted, models.DriftedAttr{
+		Path:           attr,
+		AWSValue:       awsValue,
+		TerraformValue: tfValue,
+		Ignored:        ignored,
+		Source:         sourceOf(tfInstance, attr),
+	})
+}
+
+// sourceOf returns where the Terraform value of attr was declared, or nil.
+func sourceOf(tfInstance *models.EC2Instance, attr string) *models.Source {
+	src, ok := tfInstance.SourceOf(attr)
+	if !ok {
+		return nil
+	}
+	return &src
+}
+
 // DetectMultiple performs drift detection across multiple instances using a bounded worker pool.
 // This method uses the configured concurrency limit to prevent resource exhaustion
-// when processing large numbers of instances.
+// when processing large numbers of instances. Terraform instances absent from
+// awsInstances are reported as models.StatusMissingInAWS, so tfInstances should
+// hold only the instances that were looked up in AWS. Those without an
+// instance ID are reported as models.StatusUncorrelated instead.
 func (d *DefaultDetector) DetectMultiple(
 	ctx context.Context,
 	awsInstances, tfInstances map[string]*models.EC2Instance,
@@ -186,14 +266,16 @@ func (d *DefaultDetector) DetectMultiple(
 		"concurrency", d.concurrency,
 	)
 
+	missing, uncorrelated := missingInAWS(awsInstances, tfInstances)
 	report := &models.DriftReport{
-		TotalInstances: len(awsInstances),
-		Results:        make([]models.DriftResult, 0, len(awsInstances)),
-	}
-
-	if len(awsInstances) == 0 {
-		return report
+		TotalInstances:        len(awsInstances) + len(missing),
+		DriftedInstances:      len(missing),
+		MissingInstances:      len(missing),
+		UncorrelatedInstances: len(uncorrelated),
 	}
+	report.Results = make([]models.DriftResult, 0, len(awsInstances)+len(missing)+len(uncorrelated))
+	report.Results = append(report.Results, missing...)
+	report.Results = append(report.Results, uncorrelated...)
 
 	// Create input for worker pool
 	type detectInput struct {
@@ -252,11 +334,44 @@ func (d *DefaultDetector) DetectMultiple(
 		"drift detection complete",
 		"total", report.TotalInstances,
 		"drifted", report.DriftedInstances,
+		"missing", report.MissingInstances,
+		"uncorrelated", report.UncorrelatedInstances,
 	)
 
 	return report
 }
 
+// missingInAWS returns a result for each Terraform instance absent from
+// awsInstances: StatusMissingInAWS if it has an instance ID, or
+// StatusUncorrelated if no state or correlation strategy supplied its ID and
+// so it was never looked up.
+func missingInAWS(awsInstances, tfInstances map[string]*models.EC2Instance) (missing, uncorrelated []models.DriftResult) {
+	for id, tfInst := range tfInstances {
+		if _, ok := awsInstances[id]; ok {
+			continue
+		}
+		result := models.DriftResult{
+			InstanceID: tfInst.InstanceID,
+			Address:    tfInst.Address,
+			StateFile:  tfInst.StateFile,
+			Workspace:  tfInst.Workspace,
+			MatchedBy:  tfInst.MatchedBy,
+			MovedFrom:  tfInst.MovedFrom,
+		}
+		if !tfInst.IDKnown {
+			logger.Warn("instance has no instance ID", "key", id, "address", tfInst.Address)
+			result.Status = models.StatusUncorrelated
+			uncorrelated = append(uncorrelated, result)
+			continue
+		}
+		logger.Warn("instance not found in AWS", "instance_id", id, "address", tfInst.Address)
+		result.Status = models.StatusMissingInAWS
+		result.HasDrift = true
+		missing = append(missing, result)
+	}
+	return missing, uncorrelated
+}
+
 func (d *DefaultDetector) getAttributeValues(
 	aws, tf *models.EC2Instance,
 	attr string,
@@ -2,9 +2,12 @@ package drift
 
 import (
 	"context"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/solomon-os/go-test/internal/models"
+	"github.com/solomon-os/go-test/internal/terraform"
 )
 
 func TestNewDetector(t *testing.T) {
@@ -168,6 +171,9 @@ func TestDetector_Detect(t *testing.T) {
 			},
 			tf: &models.EC2Instance{
 				InstanceID:   "i-123",
+				Address:      "module.web.aws_instance.this[0]",
+				StateFile:    "terraform.tfstate.d/prod/terraform.tfstate",
+				Workspace:    "prod",
 				EBSOptimized: false,
 			},
 			attributes: []string{"ebs_optimized"},
@@ -192,10 +198,300 @@ func TestDetector_Detect(t *testing.T) {
 			if result.InstanceID != tt.aws.InstanceID {
 				t.Errorf("InstanceID = %s, want %s", result.InstanceID, tt.aws.InstanceID)
 			}
+
+			if result.Address != tt.tf.Address {
+				t.Errorf("Address = %s, want %s", result.Address, tt.tf.Address)
+			}
+
+			if result.StateFile != tt.tf.StateFile || result.Workspace != tt.tf.Workspace {
+				t.Errorf("state = %s (%s), want %s (%s)",
+					result.StateFile, result.Workspace, tt.tf.StateFile, tt.tf.Workspace)
+			}
+		})
+	}
+}
+
+func TestDetector_Detect_IgnoreChanges(t *testing.T) {
+	aws := &models.EC2Instance{
+		InstanceID:      "i-123",
+		InstanceType:    "t3.large",
+		AMI:             "ami-new",
+		Tags:            map[string]string{"Name": "web-renamed", "Env": "prod"},
+		RootBlockDevice: models.BlockDevice{VolumeSize: 100, VolumeType: "gp3"},
+	}
+	tf := &models.EC2Instance{
+		InstanceID:      "i-123",
+		InstanceType:    "t3.micro",
+		AMI:             "ami-old",
+		Tags:            map[string]string{"Name": "web", "Env": "prod"},
+		RootBlockDevice: models.BlockDevice{VolumeSize: 20, VolumeType: "gp3"},
+	}
+	attrs := []string{"instance_type", "ami", "tags", "root_block_device.volume_size", "root_block_device.volume_type"}
+
+	tests := []struct {
+		name        string
+		ignore      []string
+		tags        map[string]string
+		wantDrift   bool
+		wantIgnored []string
+	}{
+		{
+			name:      "nothing ignored",
+			wantDrift: true,
+		},
+		{
+			name:        "attributes ignored",
+			ignore:      []string{"ami", "root_block_device.volume_size"},
+			wantDrift:   true,
+			wantIgnored: []string{"ami", "root_block_device.volume_size"},
+		},
+		{
+			name:        "parent block ignored",
+			ignore:      []string{"instance_type", "ami", "tags", "root_block_device"},
+			wantIgnored: []string{"instance_type", "ami", "tags", "root_block_device.volume_size"},
+		},
+		{
+			name:        "all ignored",
+			ignore:      []string{models.IgnoreAllChanges},
+			wantIgnored: []string{"instance_type", "ami", "tags", "root_block_device.volume_size"},
+		},
+		{
+			name:        "only ignored tag differs",
+			ignore:      []string{"instance_type", "ami", "root_block_device", "tags.Name"},
+			wantIgnored: []string{"instance_type", "ami", "tags", "root_block_device.volume_size"},
+		},
+		{
+			name:        "other tags differ too",
+			ignore:      []string{"instance_type", "ami", "root_block_device", "tags.Name"},
+			tags:        map[string]string{"Name": "web", "Env": "staging"},
+			wantDrift:   true,
+			wantIgnored: []string{"instance_type", "ami", "root_block_device.volume_size"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfInst := *tf
+			tfInst.IgnoreChanges = tt.ignore
+			if tt.tags != nil {
+				tfInst.Tags = tt.tags
+			}
+
+			result := NewDetector(attrs).Detect(aws, &tfInst)
+			if result.HasDrift != tt.wantDrift {
+				t.Errorf("HasDrift = %v, want %v", result.HasDrift, tt.wantDrift)
+			}
+			if len(result.DriftedAttrs) != 4 {
+				t.Errorf("DriftedAttrs = %d, want 4 including ignored ones", len(result.DriftedAttrs))
+			}
+
+			var ignored []string
+			for _, a := range result.DriftedAttrs {
+				if a.Ignored {
+					ignored = append(ignored, a.Path)
+				}
+			}
+			if strings.Join(ignored, ",") != strings.Join(tt.wantIgnored, ",") {
+				t.Errorf("ignored = %v, want %v", ignored, tt.wantIgnored)
+			}
 		})
 	}
 }
 
+func TestDetector_Detect_Sources(t *testing.T) {
+	aws := &models.EC2Instance{InstanceID: "i-123", InstanceType: "t3.large", AMI: "ami-new"}
+	tf := &models.EC2Instance{
+		InstanceID:   "i-123",
+		Address:      "aws_instance.web",
+		StateFile:    "main.tf",
+		InstanceType: "t3.micro",
+		AMI:          "ami-old",
+		Sources: map[string]models.Source{
+			"instance_type": {File: "main.tf", Line: 27, Column: 3},
+		},
+	}
+
+	result := NewDetector([]string{"instance_type", "ami"}).Detect(aws, tf)
+	if len(result.DriftedAttrs) != 2 {
+		t.Fatalf("DriftedAttrs = %+v, want 2", result.DriftedAttrs)
+	}
+
+	want := map[string]string{
+		"instance_type": "main.tf:27",
+		"ami":           "aws_instance.web (main.tf)",
+	}
+	for _, attr := range result.DriftedAttrs {
+		if attr.Source == nil {
+			t.Errorf("%s: Source is nil", attr.Path)
+			continue
+		}
+		if attr.Source.String() != want[attr.Path] {
+			t.Errorf("%s: Source = %s, want %s", attr.Path, attr.Source, want[attr.Path])
+		}
+	}
+}
+
+func TestDetector_Detect_Unknown(t *testing.T) {
+	aws := &models.EC2Instance{
+		InstanceID: "i-123",
+		KeyName:    "deploy",
+		Tags:       map[string]string{"Name": "web", "CreatedAt": "2024-01-01T00:00:00Z"},
+	}
+	tf := &models.EC2Instance{
+		InstanceID: "i-123",
+		Tags:       map[string]string{"Name": "web"},
+		Unknown:    []string{"key_name", "tags.CreatedAt"},
+	}
+
+	t.Run("unknown values are not drift", func(t *testing.T) {
+		result := NewDetector([]string{"key_name", "tags"}).Detect(aws, tf)
+		if result.HasDrift {
+			t.Errorf("HasDrift = true, want false: %+v", result.DriftedAttrs)
+		}
+		got := make(map[string]models.DriftedAttr)
+		for _, attr := range result.DriftedAttrs {
+			got[attr.Path] = attr
+		}
+		for _, path := range []string{"key_name", "tags.CreatedAt"} {
+			attr, ok := got[path]
+			if !ok || !attr.Unknown || attr.TerraformValue != models.UnknownValue {
+				t.Errorf("%s = %+v, want an unknown attribute", path, attr)
+			}
+		}
+		if len(result.DriftedAttrs) != 2 {
+			t.Errorf("DriftedAttrs = %+v, want 2", result.DriftedAttrs)
+		}
+	})
+
+	t.Run("known map keys are still compared", func(t *testing.T) {
+		renamed := *aws
+		renamed.Tags = map[string]string{"Name": "web-renamed", "CreatedAt": "2024-01-01T00:00:00Z"}
+		result := NewDetector([]string{"tags"}).Detect(&renamed, tf)
+		if !result.HasDrift {
+			t.Fatal("HasDrift = false, want drift on tags.Name")
+		}
+		last := result.DriftedAttrs[len(result.DriftedAttrs)-1]
+		if last.Path != "tags" || last.Unknown {
+			t.Errorf("last drifted attribute = %+v, want drift on tags", last)
+		}
+		if _, ok := last.AWSValue.(map[string]string)["CreatedAt"]; ok {
+			t.Error("unknown key should be excluded from the compared tags")
+		}
+	})
+}
+
+func TestDetector_Detect_ThreeWay(t *testing.T) {
+	config := &models.EC2Instance{
+		InstanceID:    "web",
+		InstanceType:  "t3.large",
+		AMI:           "ami-new",
+		KeyName:       "deploy",
+		Tags:          map[string]string{"Name": "web", "CreatedAt": "unknown"},
+		Unknown:       []string{"tags.CreatedAt"},
+		IgnoreChanges: []string{"key_name"},
+		Sources: map[string]models.Source{
+			"instance_type": {File: "main.tf", Line: 3},
+		},
+	}
+	state := &models.EC2Instance{
+		InstanceID:    "i-123",
+		InstanceType:  "t3.micro",
+		AMI:           "ami-old",
+		KeyName:       "deploy",
+		Tags:          map[string]string{"Name": "web", "CreatedAt": "2024-01-01"},
+		Configuration: config,
+	}
+	aws := &models.EC2Instance{
+		InstanceID:   "i-123",
+		InstanceType: "t3.micro",
+		AMI:          "ami-manual",
+		KeyName:      "rotated",
+		Tags:         map[string]string{"Name": "web", "CreatedAt": "2024-01-01"},
+	}
+
+	result := NewDetector([]string{"instance_type", "ami", "key_name", "tags"}).Detect(aws, state)
+	if !result.HasDrift {
+		t.Fatal("HasDrift = false, want true")
+	}
+
+	got := make(map[string]models.DriftedAttr)
+	for _, attr := range result.DriftedAttrs {
+		got[attr.Path] = attr
+	}
+	tests := []struct {
+		path    string
+		kind    models.DriftKind
+		ignored bool
+	}{
+		{path: "instance_type", kind: models.DriftUnapplied},
+		{path: "ami", kind: models.DriftBoth},
+		{path: "key_name", kind: models.DriftOutOfBand, ignored: true},
+	}
+	for _, tt := range tests {
+		attr, ok := got[tt.path]
+		if !ok {
+			t.Errorf("%s not reported", tt.path)
+			continue
+		}
+		if attr.Kind != tt.kind || attr.Ignored != tt.ignored {
+			t.Errorf("%s: Kind = %q, Ignored = %v, want %q, %v",
+				tt.path, attr.Kind, attr.Ignored, tt.kind, tt.ignored)
+		}
+	}
+	if _, ok := got["tags"]; ok {
+		t.Error("tags reported, want the unknown CreatedAt taken from state")
+	}
+
+	ami := got["ami"]
+	if ami.TerraformValue != "ami-new" || ami.StateValue != "ami-old" || ami.AWSValue != "ami-manual" {
+		t.Errorf("ami values = %v, %v, %v, want configuration, state and AWS values",
+			ami.TerraformValue, ami.StateValue, ami.AWSValue)
+	}
+	if src := got["instance_type"].Source; src == nil || src.Line != 3 {
+		t.Errorf("instance_type Source = %v, want main.tf:3", src)
+	}
+}
+
+func TestDetector_compareThreeWay_NoConfigValue(t *testing.T) {
+	state := &models.EC2Instance{
+		InstanceID:    "i-123",
+		Configuration: &models.EC2Instance{InstanceID: "web"},
+	}
+
+	drifted := NewDetector(nil).compareThreeWay(state, "placement_group", "pg-aws", "pg-state")
+	if len(drifted) != 1 {
+		t.Fatalf("compareThreeWay() = %v, want the state-vs-AWS drift", drifted)
+	}
+	if got := drifted[0]; got.Kind != "" || got.AWSValue != "pg-aws" || got.TerraformValue != "pg-state" {
+		t.Errorf("compareThreeWay() = %+v, want a two-way difference", got)
+	}
+}
+
+func TestAddUnapplied(t *testing.T) {
+	report := &models.DriftReport{
+		TotalInstances: 1,
+		Results:        []models.DriftResult{{InstanceID: "i-123"}},
+	}
+	AddUnapplied(report, []*models.EC2Instance{
+		{InstanceID: "aws_instance.web[2]", Address: "aws_instance.web[2]", StateFile: "main.tf"},
+	})
+
+	if report.TotalInstances != 2 || report.DriftedInstances != 1 || report.UnappliedInstances != 1 {
+		t.Errorf("report totals = %d/%d/%d, want 2 total, 1 drifted, 1 unapplied",
+			report.TotalInstances, report.DriftedInstances, report.UnappliedInstances)
+	}
+	want := models.DriftResult{
+		InstanceID: "aws_instance.web[2]",
+		Address:    "aws_instance.web[2]",
+		StateFile:  "main.tf",
+		Status:     models.StatusUnapplied,
+		HasDrift:   true,
+	}
+	if len(report.Results) != 2 || !reflect.DeepEqual(report.Results[1], want) {
+		t.Errorf("Results = %+v, want the unapplied instance appended", report.Results)
+	}
+}
+
 func TestDetector_DetectMultiple(t *testing.T) {
 	awsInstances := map[string]*models.EC2Instance{
 		"i-123": {
@@ -241,6 +537,110 @@ func TestDetector_DetectMultiple(t *testing.T) {
 	}
 }
 
+func TestDetector_DetectMultiple_MissingInAWS(t *testing.T) {
+	awsInstances := map[string]*models.EC2Instance{
+		"i-123": {InstanceID: "i-123", InstanceType: "t2.micro"},
+	}
+	tfInstances := map[string]*models.EC2Instance{
+		"i-123": {InstanceID: "i-123", InstanceType: "t2.micro"},
+		"i-456": {
+			InstanceID: "i-456",
+			IDKnown:    true,
+			Address:    "aws_instance.db",
+			StateFile:  "prod.tfstate",
+			MatchedBy:  models.MatchedByImport,
+		},
+	}
+
+	report := NewDetector([]string{"instance_type"}).DetectMultiple(context.Background(), awsInstances, tfInstances)
+
+	if report.TotalInstances != 2 || report.DriftedInstances != 1 || report.MissingInstances != 1 {
+		t.Errorf("report totals = %d/%d/%d, want 2 total, 1 drifted, 1 missing",
+			report.TotalInstances, report.DriftedInstances, report.MissingInstances)
+	}
+	if len(report.Results) != 2 {
+		t.Fatalf("Results count = %d, want 2", len(report.Results))
+	}
+	missing := report.Results[1]
+	want := models.DriftResult{
+		InstanceID: "i-456",
+		Address:    "aws_instance.db",
+		StateFile:  "prod.tfstate",
+		MatchedBy:  models.MatchedByImport,
+		Status:     models.StatusMissingInAWS,
+		HasDrift:   true,
+	}
+	if !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing result = %+v, want %+v", missing, want)
+	}
+	if report.Results[0].Status != "" {
+		t.Errorf("i-123 Status = %q, want none", report.Results[0].Status)
+	}
+}
+
+func TestDetector_DetectMultiple_Uncorrelated(t *testing.T) {
+	awsInstances := map[string]*models.EC2Instance{
+		"i-123": {InstanceID: "i-123", InstanceType: "t2.micro"},
+	}
+	tfInstances := map[string]*models.EC2Instance{
+		"i-123": {InstanceID: "i-123", InstanceType: "t2.micro"},
+		// An HCL resource no correlation strategy matched is keyed by its
+		// file and address.
+		"main.tf:aws_instance.web": {InstanceID: "aws_instance.web", Address: "aws_instance.web", StateFile: "main.tf"},
+		// An instance a plan will create is keyed by its address.
+		"aws_instance.new": {InstanceID: "aws_instance.new", Address: "aws_instance.new"},
+	}
+
+	report := NewDetector([]string{"instance_type"}).DetectMultiple(context.Background(), awsInstances, tfInstances)
+
+	if report.TotalInstances != 1 || report.DriftedInstances != 0 || report.MissingInstances != 0 {
+		t.Errorf("report totals = %d/%d/%d, want 1 total, 0 drifted, 0 missing",
+			report.TotalInstances, report.DriftedInstances, report.MissingInstances)
+	}
+	if report.UncorrelatedInstances != 2 {
+		t.Errorf("UncorrelatedInstances = %d, want 2", report.UncorrelatedInstances)
+	}
+	if len(report.Results) != 3 {
+		t.Fatalf("Results count = %d, want 3", len(report.Results))
+	}
+	want := models.DriftResult{
+		InstanceID: "aws_instance.web",
+		Address:    "aws_instance.web",
+		StateFile:  "main.tf",
+		Status:     models.StatusUncorrelated,
+	}
+	if !reflect.DeepEqual(report.Results[1], want) {
+		t.Errorf("web result = %+v, want %+v", report.Results[1], want)
+	}
+	if got := report.Results[0].Status; got != models.StatusUncorrelated {
+		t.Errorf("aws_instance.new Status = %q, want %q", got, models.StatusUncorrelated)
+	}
+}
+
+func TestDetector_DetectMultiple_StateInstanceIDs(t *testing.T) {
+	// The fixture's database server has an ID that is not hexadecimal; it
+	// still comes from state, so it is missing in AWS, not uncorrelated.
+	tfInstances, err := terraform.NewParser().ParseStateFile("../../testdata/terraform.tfstate")
+	if err != nil {
+		t.Fatalf("ParseStateFile() error = %v", err)
+	}
+	awsInstances := map[string]*models.EC2Instance{
+		"i-0abc123def456789a": tfInstances["i-0abc123def456789a"],
+		"i-0def456789abc123b": tfInstances["i-0def456789abc123b"],
+	}
+
+	report := NewDetector([]string{"instance_type"}).DetectMultiple(context.Background(), awsInstances, tfInstances)
+
+	if report.MissingInstances != 1 || report.UncorrelatedInstances != 0 {
+		t.Errorf("missing/uncorrelated = %d/%d, want 1/0", report.MissingInstances, report.UncorrelatedInstances)
+	}
+	for _, r := range report.Results {
+		if r.InstanceID == "i-0ghi789012jkl345c" && r.Status != models.StatusMissingInAWS {
+			t.Errorf("i-0ghi789012jkl345c Status = %q, want %q", r.Status, models.StatusMissingInAWS)
+		}
+	}
+}
+
 func TestDetector_DetectMultiple_ContextCancelled(t *testing.T) {
 	awsInstances := map[string]*models.EC2Instance{
 		"i-123": {InstanceID: "i-123", InstanceType: "t2.micro"},
@@ -347,3 +747,25 @@ func TestDetector_GetAttributes(t *testing.T) {
 		t.Errorf("GetAttributes() returned %d attributes, want %d", len(got), len(attrs))
 	}
 }
+
+func TestComparesRootBlockDevice(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes []string
+		want       bool
+	}{
+		{name: "defaults", attributes: nil, want: true},
+		{name: "field", attributes: []string{"instance_type", "root_block_device.volume_size"}, want: true},
+		{name: "whole block", attributes: []string{"root_block_device"}, want: true},
+		{name: "none", attributes: []string{"instance_type", "tags"}, want: false},
+		{name: "similar name", attributes: []string{"root_block_device_name"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComparesRootBlockDevice(tt.attributes); got != tt.want {
+				t.Errorf("ComparesRootBlockDevice(%v) = %v, want %v", tt.attributes, got, tt.want)
+			}
+		})
+	}
+}
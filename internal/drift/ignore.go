@@ -0,0 +1,49 @@
+package drift
+
+import (
+	"strings"
+
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+// isIgnored reports whether drift on attr is covered by a resource's
+// lifecycle ignore_changes paths: when all changes are ignored, when attr or
+// one of its parents is listed (e.g. "root_block_device" covers
+// "root_block_device.volume_size"), or when the only differing keys of a
+// map attribute are listed individually (e.g. "tags.Name").
+func (d *DefaultDetector) isIgnored(ignore []string, attr string, awsValue, tfValue any) bool {
+	var keys []string
+	for _, path := range ignore {
+		switch {
+		case path == models.IgnoreAllChanges, path == attr, strings.HasPrefix(attr, path+"."):
+			return true
+		case strings.HasPrefix(path, attr+"."):
+			keys = append(keys, strings.TrimPrefix(path, attr+"."))
+		}
+	}
+	if len(keys) == 0 {
+		return false
+	}
+
+	awsMap, ok := awsValue.(map[string]string)
+	if !ok {
+		return false
+	}
+	tfMap, ok := tfValue.(map[string]string)
+	if !ok {
+		return false
+	}
+	return d.valuesEqual(withoutKeys(awsMap, keys), withoutKeys(tfMap, keys))
+}
+
+// withoutKeys returns a copy of m without keys.
+func withoutKeys(m map[string]string, keys []string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	for _, k := range keys {
+		delete(out, k)
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package drift
+
+import (
+	"strings"
+
+	"github.com/solomon-os/go-test/internal/logger"
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+// compareThreeWay compares attr across the HCL configuration, state and
+// AWS values of a state instance with a Configuration, and classifies the
+// difference: a configuration change not yet applied, a change made
+// outside Terraform, or both. Configuration values not known until apply
+// are taken from the state, as Terraform records them there once computed.
+// If the configuration value cannot be read, the state and AWS values are
+// compared two ways rather than dropped.
+func (d *DefaultDetector) compareThreeWay(
+	stateInstance *models.EC2Instance,
+	attr string,
+	awsValue, stateValue any,
+) []models.DriftedAttr {
+	config := stateInstance.Configuration
+	configValue, err := d.extractValue(config, strings.Split(attr, "."))
+	if err != nil {
+		logger.Debug("configuration value unavailable, comparing state with AWS",
+			"instance_id", stateInstance.InstanceID, "attribute", attr, "error", err)
+		return d.compare(stateInstance, attr, awsValue, stateValue)
+	}
+	configValue = resolveUnknown(config.Unknown, attr, configValue, stateValue)
+
+	applied := d.valuesEqual(configValue, stateValue)
+	inSync := d.valuesEqual(stateValue, awsValue)
+	var kind models.DriftKind
+	switch {
+	case applied && inSync:
+		return nil
+	case applied:
+		kind = models.DriftOutOfBand
+	case inSync:
+		kind = models.DriftUnapplied
+	default:
+		kind = models.DriftBoth
+	}
+
+	ignore := config.IgnoreChanges
+	ignored := (applied || d.isIgnored(ignore, attr, stateValue, configValue)) &&
+		(inSync || d.isIgnored(ignore, attr, awsValue, stateValue))
+	logger.Debug("drift detected", "instance_id", stateInstance.InstanceID,
+		"attribute", attr, "kind", kind, "ignored", ignored)
+
+	return []models.DriftedAttr{{
+		Path:           attr,
+		AWSValue:       awsValue,
+		TerraformValue: configValue,
+		StateValue:     stateValue,
+		Kind:           kind,
+		Ignored:        ignored,
+		Source:         sourceOf(config, attr),
+	}}
+}
+
+// resolveUnknown replaces the parts of a configuration value that are not
+// known until apply with the state value: all of it, or the unknown keys
+// of a map attribute.
+func resolveUnknown(unknown []string, attr string, configValue, stateValue any) any {
+	all, keys := unknownKeys(unknown, attr)
+	if all {
+		return stateValue
+	}
+	if len(keys) == 0 {
+		return configValue
+	}
+
+	configMap, ok := configValue.(map[string]string)
+	if !ok {
+		return configValue
+	}
+	stateMap, _ := stateValue.(map[string]string)
+	resolved := withoutKeys(configMap, keys)
+	for _, key := range keys {
+		if v, ok := stateMap[key]; ok {
+			resolved[key] = v
+		}
+	}
+	return resolved
+}
+
+// AddUnapplied adds a models.StatusUnapplied result to report for each
+// instance declared in the configuration but missing from the state, as
+// returned by terraform.AttachConfiguration.
+func AddUnapplied(report *models.DriftReport, unapplied []*models.EC2Instance) {
+	for _, cfg := range unapplied {
+		report.Results = append(report.Results, models.DriftResult{
+			InstanceID: cfg.InstanceID,
+			Address:    cfg.Address,
+			StateFile:  cfg.StateFile,
+			Workspace:  cfg.Workspace,
+			Status:     models.StatusUnapplied,
+			HasDrift:   true,
+		})
+	}
+	report.TotalInstances += len(unapplied)
+	report.DriftedInstances += len(unapplied)
+	report.UnappliedInstances += len(unapplied)
+}
@@ -0,0 +1,33 @@
+package drift
+
+import (
+	"strings"
+
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+// unknownKeys reports which part of attr has a Terraform value that is not
+// known until apply: all of it, when attr or one of its parents is unknown,
+// or only the listed keys of a map attribute (e.g. "CreatedAt" of "tags").
+func unknownKeys(unknown []string, attr string) (all bool, keys []string) {
+	for _, path := range unknown {
+		switch {
+		case path == attr, strings.HasPrefix(attr, path+"."):
+			return true, nil
+		case strings.HasPrefix(path, attr+"."):
+			keys = append(keys, strings.TrimPrefix(path, attr+"."))
+		}
+	}
+	return false, keys
+}
+
+// unknownAttr reports attr of tfInstance as unknown in Terraform.
+func unknownAttr(tfInstance *models.EC2Instance, attr string, awsValue any) models.DriftedAttr {
+	return models.DriftedAttr{
+		Path:           attr,
+		AWSValue:       awsValue,
+		TerraformValue: models.UnknownValue,
+		Unknown:        true,
+		Source:         sourceOf(tfInstance, attr),
+	}
+}
@@ -80,7 +80,11 @@ func (b *Builder) Build(ctx context.Context) (*Container, error) {
 	if r, ok := b.overrides["tfrepo"].(repository.TerraformRepository); ok {
 		c.TFRepository = r
 	} else {
-		c.TFRepository = b.factory.CreateTerraformRepository()
+		repo, err := b.factory.CreateTerraformRepository(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.TFRepository = repo
 	}
 
 	// Detector
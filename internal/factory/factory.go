@@ -21,6 +21,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
+	"sync"
 
 	"github.com/solomon-os/go-test/internal/aws"
 	"github.com/solomon-os/go-test/internal/drift"
@@ -39,9 +41,41 @@ type Config struct {
 	// AWSRegion is the AWS region to use for EC2 API calls.
 	AWSRegion string
 
-	// TerraformPath is the path to the Terraform state file.
+	// TerraformPath is the path to the Terraform state file. With a remote
+	// backend it may instead point at the configuration declaring the
+	// backend block, whose settings fill in those not set explicitly.
 	TerraformPath string
 
+	// Backend selects where Terraform state is read from: BackendLocal
+	// (the default), BackendS3, BackendHTTP or BackendCloud.
+	Backend string
+
+	// S3 locates the state object when Backend is BackendS3.
+	S3 tfrepo.S3Config
+
+	// HTTP locates the state when Backend is BackendHTTP.
+	HTTP tfrepo.HTTPConfig
+
+	// Cloud selects the Terraform Cloud or Enterprise workspaces when
+	// Backend is BackendCloud.
+	Cloud tfrepo.CloudConfig
+
+	// Variables sets Terraform input variables used when evaluating HCL,
+	// equivalent to -var name=value.
+	Variables map[string]string
+
+	// VarFiles lists additional .tfvars files, equivalent to -var-file.
+	VarFiles []string
+
+	// PlanSource selects the part of a saved plan, given as
+	// `terraform show -json` output, used as the desired state.
+	PlanSource terraform.PlanSource
+
+	// ReferenceState is a local Terraform state used to resolve references
+	// to other resources and data sources when evaluating HCL. Remote
+	// backends are not supported.
+	ReferenceState string
+
 	// Attributes is the list of attributes to check for drift.
 	// If empty, default attributes are used.
 	Attributes []string
@@ -56,6 +90,14 @@ type Config struct {
 	RetryConfig retry.Config
 }
 
+// Supported values of Config.Backend.
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+	BackendHTTP  = "http"
+	BackendCloud = "cloud"
+)
+
 // DefaultConfig returns configuration with sensible defaults.
 func DefaultConfig() Config {
 	return Config{
@@ -72,9 +114,10 @@ type Factory struct {
 	config Config
 
 	// Cached components for reuse
-	awsClient  *aws.Client
-	parser     terraform.StateParser
-	formatters *formatter.Registry
+	awsClient     *aws.Client
+	regionClients map[string]*aws.Client
+	parser        terraform.StateParser
+	formatters    *formatter.Registry
 }
 
 // New creates a new Factory with the given configuration.
@@ -98,7 +141,8 @@ func (f *Factory) CreateAWSClient(ctx context.Context) (*aws.Client, error) {
 	}
 
 	client, err := aws.NewClient(ctx, f.config.AWSRegion,
-		aws.WithRetryConfig(f.config.RetryConfig))
+		aws.WithRetryConfig(f.config.RetryConfig),
+		aws.WithRootVolumes(drift.ComparesRootBlockDevice(f.config.Attributes)))
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +151,32 @@ func (f *Factory) CreateAWSClient(ctx context.Context) (*aws.Client, error) {
 	return client, nil
 }
 
+// CreateRegionalEC2Repository creates an EC2 repository for region, or for
+// the configured AWSRegion if region is empty. Clients are cached per region.
+func (f *Factory) CreateRegionalEC2Repository(
+	ctx context.Context,
+	region string,
+) (repository.EC2Repository, error) {
+	if region == "" || region == f.config.AWSRegion {
+		return f.CreateEC2Repository(ctx)
+	}
+	if client, ok := f.regionClients[region]; ok {
+		return awsrepo.NewEC2Repository(client), nil
+	}
+
+	client, err := aws.NewClient(ctx, region,
+		aws.WithRetryConfig(f.config.RetryConfig),
+		aws.WithRootVolumes(drift.ComparesRootBlockDevice(f.config.Attributes)))
+	if err != nil {
+		return nil, err
+	}
+	if f.regionClients == nil {
+		f.regionClients = make(map[string]*aws.Client)
+	}
+	f.regionClients[region] = client
+	return awsrepo.NewEC2Repository(client), nil
+}
+
 // CreateParser creates a Terraform parser.
 // The parser is cached and reused for subsequent calls.
 func (f *Factory) CreateParser() terraform.StateParser {
@@ -114,7 +184,12 @@ func (f *Factory) CreateParser() terraform.StateParser {
 		return f.parser
 	}
 
-	f.parser = terraform.NewParser()
+	f.parser = terraform.NewParser(
+		terraform.WithVariables(f.config.Variables),
+		terraform.WithVarFiles(f.config.VarFiles...),
+		terraform.WithPlanSource(f.config.PlanSource),
+		terraform.WithReferenceState(f.config.ReferenceState),
+	)
 	return f.parser
 }
 
@@ -127,10 +202,91 @@ func (f *Factory) CreateEC2Repository(ctx context.Context) (repository.EC2Reposi
 	return awsrepo.NewEC2Repository(client), nil
 }
 
-// CreateTerraformRepository creates a Terraform repository.
-func (f *Factory) CreateTerraformRepository() repository.TerraformRepository {
+// CreateTerraformRepository creates a Terraform repository for the
+// configured backend.
+func (f *Factory) CreateTerraformRepository(ctx context.Context) (repository.TerraformRepository, error) {
 	parser := f.CreateParser()
-	return tfrepo.NewRepository(parser, f.config.TerraformPath)
+
+	switch f.config.Backend {
+	case "", BackendLocal:
+		return tfrepo.NewRepository(parser, f.config.TerraformPath), nil
+	case BackendS3:
+		cfg, err := f.s3Config()
+		if err != nil {
+			return nil, err
+		}
+		client, err := tfrepo.NewS3Client(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return tfrepo.NewS3Repository(client, parser, cfg), nil
+	case BackendHTTP:
+		cfg, err := f.httpConfig()
+		if err != nil {
+			return nil, err
+		}
+		return tfrepo.NewHTTPRepository(nil, parser, cfg), nil
+	case BackendCloud:
+		if err := f.config.Cloud.Validate(); err != nil {
+			return nil, err
+		}
+		return tfrepo.NewCloudRepository(nil, parser, f.config.Cloud), nil
+	default:
+		return nil, fmt.Errorf("unsupported state backend %q", f.config.Backend)
+	}
+}
+
+// s3Config returns the configured S3 location, completed from the
+// backend "s3" block of the configuration at TerraformPath if there is one.
+func (f *Factory) s3Config() (tfrepo.S3Config, error) {
+	cfg := f.config.S3
+	backend, err := f.declaredBackend(BackendS3)
+	if err != nil {
+		return tfrepo.S3Config{}, err
+	}
+	if backend.Type != "" {
+		declared, err := tfrepo.S3ConfigFromBackend(backend)
+		if err != nil {
+			return tfrepo.S3Config{}, err
+		}
+		cfg = cfg.Merge(declared)
+	}
+	if cfg.Region == "" {
+		cfg.Region = f.config.AWSRegion
+	}
+	return cfg, cfg.Validate()
+}
+
+// httpConfig returns the configured HTTP state location, completed from the
+// backend "http" block of the configuration at TerraformPath if there is one.
+func (f *Factory) httpConfig() (tfrepo.HTTPConfig, error) {
+	cfg := f.config.HTTP
+	backend, err := f.declaredBackend(BackendHTTP)
+	if err != nil {
+		return tfrepo.HTTPConfig{}, err
+	}
+	if backend.Type != "" {
+		declared, err := tfrepo.HTTPConfigFromBackend(backend)
+		if err != nil {
+			return tfrepo.HTTPConfig{}, err
+		}
+		cfg = cfg.Merge(declared)
+	}
+	return cfg, cfg.Validate()
+}
+
+// declaredBackend returns the backend block of the configuration at
+// TerraformPath if it declares a backend of the given type. Otherwise the
+// returned Type is empty.
+func (f *Factory) declaredBackend(backendType string) (terraform.BackendConfig, error) {
+	if f.config.TerraformPath == "" {
+		return terraform.BackendConfig{}, nil
+	}
+	backend, err := terraform.ReadBackendConfig(f.config.TerraformPath)
+	if err != nil || backend.Type != backendType {
+		return terraform.BackendConfig{}, err
+	}
+	return backend, nil
 }
 
 // CreateDetector creates a configured drift detector.
@@ -160,6 +316,28 @@ type DriftService struct {
 	awsRepo  repository.EC2Repository
 	tfRepo   repository.TerraformRepository
 	detector drift.Detector
+
+	// regionRepo creates the EC2 repository of a region; nil sends every
+	// region to awsRepo.
+	regionRepo  RegionalEC2RepositoryFunc
+	regionMu    sync.Mutex
+	regionRepos map[string]repository.EC2Repository
+}
+
+// RegionalEC2RepositoryFunc creates the EC2 repository used for instances
+// in region.
+type RegionalEC2RepositoryFunc func(ctx context.Context, region string) (repository.EC2Repository, error)
+
+// DriftServiceOption is a functional option for configuring a DriftService.
+type DriftServiceOption func(*DriftService)
+
+// WithRegionalRepositories queries the instances of each region with the
+// repository created by fn. Instances without a known region use the
+// service's EC2 repository.
+func WithRegionalRepositories(fn RegionalEC2RepositoryFunc) DriftServiceOption {
+	return func(s *DriftService) {
+		s.regionRepo = fn
+	}
 }
 
 // NewDriftService creates a new DriftService with the given dependencies.
@@ -167,12 +345,18 @@ func NewDriftService(
 	awsRepo repository.EC2Repository,
 	tfRepo repository.TerraformRepository,
 	detector drift.Detector,
+	opts ...DriftServiceOption,
 ) *DriftService {
-	return &DriftService{
-		awsRepo:  awsRepo,
-		tfRepo:   tfRepo,
-		detector: detector,
+	s := &DriftService{
+		awsRepo:     awsRepo,
+		tfRepo:      tfRepo,
+		detector:    detector,
+		regionRepos: make(map[string]repository.EC2Repository),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // CreateDriftService creates a fully configured drift service.
@@ -182,10 +366,14 @@ func (f *Factory) CreateDriftService(ctx context.Context) (*DriftService, error)
 		return nil, err
 	}
 
-	tfRepo := f.CreateTerraformRepository()
+	tfRepo, err := f.CreateTerraformRepository(ctx)
+	if err != nil {
+		return nil, err
+	}
 	detector := f.CreateDetector()
 
-	return NewDriftService(awsRepo, tfRepo, detector), nil
+	return NewDriftService(awsRepo, tfRepo, detector,
+		WithRegionalRepositories(f.CreateRegionalEC2Repository)), nil
 }
 
 // DetectDrift performs drift detection for the specified instances.
@@ -208,20 +396,82 @@ func (s *DriftService) DetectDrift(
 		}
 	}
 
-	// Fetch AWS instances
-	awsInstances, err := s.awsRepo.GetByIDs(ctx, instanceIDs)
+	// Fetch AWS instances, querying each region with its own client
+	awsMap := make(map[string]*models.EC2Instance)
+	byRegion := groupByRegion(instanceIDs, tfInstances)
+	for _, region := range sortedRegions(byRegion) {
+		repo, err := s.repositoryFor(ctx, region)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create EC2 repository for region %s: %w", region, err)
+		}
+		awsInstances, err := repo.GetByIDs(ctx, byRegion[region])
+		if err != nil {
+			if region == "" {
+				return nil, fmt.Errorf("failed to fetch AWS instances: %w", err)
+			}
+			return nil, fmt.Errorf("failed to fetch AWS instances in %s: %w", region, err)
+		}
+		for _, inst := range awsInstances {
+			awsMap[inst.InstanceID] = inst
+		}
+	}
+
+	// Perform drift detection on the instances looked up, so that the
+	// rest of the state is not reported missing in AWS
+	checked := make(map[string]*models.EC2Instance, len(instanceIDs))
+	for _, id := range instanceIDs {
+		if inst, ok := tfInstances[id]; ok {
+			checked[id] = inst
+		}
+	}
+	return s.detector.DetectMultiple(ctx, awsMap, checked), nil
+}
+
+// repositoryFor returns the EC2 repository for region, creating and caching
+// it on first use.
+func (s *DriftService) repositoryFor(ctx context.Context, region string) (repository.EC2Repository, error) {
+	if region == "" || s.regionRepo == nil {
+		return s.awsRepo, nil
+	}
+
+	s.regionMu.Lock()
+	defer s.regionMu.Unlock()
+	if repo, ok := s.regionRepos[region]; ok {
+		return repo, nil
+	}
+	repo, err := s.regionRepo(ctx, region)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch AWS instances: %w", err)
+		return nil, err
 	}
+	s.regionRepos[region] = repo
+	return repo, nil
+}
 
-	// Convert to map for detector
-	awsMap := make(map[string]*models.EC2Instance)
-	for _, inst := range awsInstances {
-		awsMap[inst.InstanceID] = inst
+// groupByRegion groups instance IDs by the region of their Terraform
+// instance. IDs with no known region are grouped under "".
+func groupByRegion(
+	instanceIDs []string,
+	tfInstances map[string]*models.EC2Instance,
+) map[string][]string {
+	groups := make(map[string][]string)
+	for _, id := range instanceIDs {
+		var region string
+		if inst, ok := tfInstances[id]; ok {
+			region = inst.Region
+		}
+		groups[region] = append(groups[region], id)
 	}
+	return groups
+}
 
-	// Perform drift detection
-	return s.detector.DetectMultiple(ctx, awsMap, tfInstances), nil
+// sortedRegions returns the regions of groups in a stable order.
+func sortedRegions(groups map[string][]string) []string {
+	regions := make([]string, 0, len(groups))
+	for region := range groups {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
 }
 
 // AWSDrifter returns the EC2 repository.
@@ -2,6 +2,9 @@ package factory
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -137,7 +140,10 @@ func TestFactory_CreateTerraformRepository(t *testing.T) {
 	f := New(cfg)
 
 	t.Run("creates terraform repository", func(t *testing.T) {
-		repo := f.CreateTerraformRepository()
+		repo, err := f.CreateTerraformRepository(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 		if repo == nil {
 			t.Error("expected non-nil repository")
 		}
@@ -146,6 +152,85 @@ func TestFactory_CreateTerraformRepository(t *testing.T) {
 			t.Errorf("expected file path '/path/to/state.tfstate', got %s", repo.FilePath())
 		}
 	})
+
+	t.Run("rejects unsupported backend", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Backend = "gcs"
+		if _, err := New(cfg).CreateTerraformRepository(context.Background()); err == nil {
+			t.Error("expected error for unsupported backend")
+		}
+	})
+
+	t.Run("creates http repository", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Backend = BackendHTTP
+		cfg.HTTP.Address = "https://state.example.com/prod"
+		repo, err := New(cfg).CreateTerraformRepository(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if repo.FilePath() != "https://state.example.com/prod" {
+			t.Errorf("expected state address as file path, got %s", repo.FilePath())
+		}
+	})
+
+	t.Run("creates terraform cloud repository", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Backend = BackendCloud
+		cfg.Cloud.Organization = "acme"
+		cfg.Cloud.Workspace = "prod"
+		cfg.Cloud.Token = "secret"
+		repo, err := New(cfg).CreateTerraformRepository(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if repo.FilePath() != "https://app.terraform.io/app/acme/workspaces/prod" {
+			t.Errorf("expected workspace URL as file path, got %s", repo.FilePath())
+		}
+	})
+
+	t.Run("requires s3 bucket and key", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Backend = BackendS3
+		if _, err := New(cfg).CreateTerraformRepository(context.Background()); err == nil {
+			t.Error("expected error for incomplete s3 config")
+		}
+	})
+}
+
+func TestFactory_S3Config(t *testing.T) {
+	dir := t.TempDir()
+	backend := `
+terraform {
+  backend "s3" {
+    bucket = "states"
+    key    = "prod/terraform.tfstate"
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "backend.tf"), []byte(backend), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Backend = BackendS3
+	cfg.TerraformPath = dir
+	cfg.AWSRegion = "eu-west-1"
+	cfg.S3.Key = "override.tfstate"
+
+	got, err := New(cfg).s3Config()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Bucket != "states" {
+		t.Errorf("expected bucket from backend block, got %s", got.Bucket)
+	}
+	if got.Key != "override.tfstate" {
+		t.Errorf("expected explicit key to win, got %s", got.Key)
+	}
+	if got.Region != "eu-west-1" {
+		t.Errorf("expected region to default to AWSRegion, got %s", got.Region)
+	}
 }
 
 func TestFactory_FormattersRegistry(t *testing.T) {
@@ -212,6 +297,50 @@ func TestNewDriftService(t *testing.T) {
 	})
 }
 
+func TestDriftService_DetectDrift_Regions(t *testing.T) {
+	tfRepo := &regionTerraformRepository{instances: map[string]*models.EC2Instance{
+		"i-east":    {InstanceID: "i-east", InstanceType: "t3.micro", Region: "us-east-1"},
+		"i-west":    {InstanceID: "i-west", InstanceType: "t3.micro", Region: "us-west-2"},
+		"i-unknown": {InstanceID: "i-unknown", InstanceType: "t3.micro"},
+	}}
+	repos := map[string]*regionEC2Repository{
+		"":          {instances: []string{"i-unknown"}},
+		"us-east-1": {instances: []string{"i-east"}},
+		"us-west-2": {instances: []string{"i-west"}},
+	}
+	created := 0
+
+	service := NewDriftService(repos[""], tfRepo, drift.NewDetector([]string{"instance_type"}),
+		WithRegionalRepositories(func(ctx context.Context, region string) (repository.EC2Repository, error) {
+			created++
+			repo, ok := repos[region]
+			if !ok {
+				return nil, fmt.Errorf("unexpected region %q", region)
+			}
+			return repo, nil
+		}))
+
+	for i := 0; i < 2; i++ {
+		report, err := service.DetectDrift(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("DetectDrift() error = %v", err)
+		}
+		if report.TotalInstances != 3 || report.DriftedInstances != 0 {
+			t.Errorf("report = %d total, %d drifted; want 3 total, 0 drifted",
+				report.TotalInstances, report.DriftedInstances)
+		}
+	}
+
+	for region, repo := range repos {
+		if len(repo.requested) != 2 || len(repo.requested[0]) != 1 {
+			t.Errorf("region %q requests = %v, want one instance per run", region, repo.requested)
+		}
+	}
+	if created != 2 {
+		t.Errorf("created %d regional repositories, want 2", created)
+	}
+}
+
 func TestConfig_WithRetryConfig(t *testing.T) {
 	cfg := DefaultConfig()
 	customRetry := retry.Config{
@@ -268,3 +397,38 @@ func (m *mockTerraformRepository) GetAll(
 
 func (m *mockTerraformRepository) Refresh(ctx context.Context) error { return nil }
 func (m *mockTerraformRepository) FilePath() string                  { return "/mock/path" }
+
+// regionEC2Repository serves the instances of one region and records the
+// IDs requested from it.
+type regionEC2Repository struct {
+	mockEC2Repository
+	instances []string
+	requested [][]string
+}
+
+func (m *regionEC2Repository) GetByIDs(
+	ctx context.Context,
+	ids []string,
+) ([]*models.EC2Instance, error) {
+	m.requested = append(m.requested, ids)
+	var result []*models.EC2Instance
+	for _, id := range ids {
+		for _, own := range m.instances {
+			if id == own {
+				result = append(result, &models.EC2Instance{InstanceID: id, InstanceType: "t3.micro"})
+			}
+		}
+	}
+	return result, nil
+}
+
+type regionTerraformRepository struct {
+	mockTerraformRepository
+	instances map[string]*models.EC2Instance
+}
+
+func (m *regionTerraformRepository) GetAll(
+	ctx context.Context,
+) (map[string]*models.EC2Instance, error) {
+	return m.instances, nil
+}
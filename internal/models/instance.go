@@ -10,6 +10,11 @@
 //   - BlockDevice: Represents EBS block device configuration
 //   - DriftResult: Contains comparison results for a single instance
 //   - DriftReport: Aggregates results for multiple instances
+//   - StateConflict: An instance found in more than one Terraform state
+//   - Source: Where a Terraform value was declared
+//   - DriftKind: How configuration, state and AWS differ for an attribute
+//   - ResultStatus: Why an instance could not be compared, e.g. missing in AWS
+//   - UnmanagedInstance: An instance in AWS that no Terraform state manages
 //
 // Example usage:
 //
@@ -20,6 +25,65 @@
 //	}
 package models
 
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IgnoreAllChanges is the EC2Instance.IgnoreChanges path recorded for
+// lifecycle { ignore_changes = all }.
+const IgnoreAllChanges = "*"
+
+// UnknownValue is the DriftedAttr.TerraformValue of an attribute whose
+// Terraform value is not known until apply, e.g. timestamp().
+const UnknownValue = "unknown"
+
+// Values of EC2Instance.MatchedBy other than "tag:<key>".
+const (
+	MatchedByImport  = "import"
+	MatchedByMoved   = "moved"
+	MatchedByMapping = "mapping"
+)
+
+// DriftKind classifies a difference found by comparing the HCL
+// configuration, the Terraform state and AWS.
+type DriftKind string
+
+const (
+	// DriftUnapplied is a configuration change not yet applied: the
+	// configuration differs from the state, which matches AWS.
+	DriftUnapplied DriftKind = "unapplied"
+
+	// DriftOutOfBand is a change made outside Terraform: AWS differs from
+	// the state, which matches the configuration.
+	DriftOutOfBand DriftKind = "out_of_band"
+
+	// DriftBoth is an unapplied configuration change on an attribute that
+	// was also changed outside Terraform.
+	DriftBoth DriftKind = "both"
+)
+
+// ResultStatus classifies a DriftResult that could not be compared
+// attribute by attribute.
+type ResultStatus string
+
+const (
+	// StatusMissingInAWS marks a Terraform-managed instance that no longer
+	// exists in AWS, e.g. because it was terminated outside Terraform.
+	StatusMissingInAWS ResultStatus = "missing_in_aws"
+
+	// StatusUncorrelated marks a Terraform instance without an instance ID,
+	// such as an HCL resource no correlation strategy matched or one a plan
+	// has yet to create. It cannot be looked up in AWS, so it has no drift.
+	StatusUncorrelated ResultStatus = "uncorrelated"
+
+	// StatusUnapplied marks an instance declared in the HCL configuration
+	// that is missing from the state, i.e. a configuration change not yet
+	// applied. It has drift.
+	StatusUnapplied ResultStatus = "unapplied"
+)
+
 // EC2Instance represents a normalized EC2 instance configuration
 // that can be compared between AWS and Terraform sources.
 //
@@ -33,6 +97,49 @@ type EC2Instance struct {
 	// InstanceID is the unique EC2 instance identifier (e.g., "i-1234567890abcdef0").
 	InstanceID string `json:"instance_id"`
 
+	// IDKnown reports whether InstanceID is an EC2 instance ID, read from a
+	// state or found by a correlation strategy. Otherwise InstanceID is the
+	// key the HCL parser, or a plan for an instance yet to be created, uses
+	// in its place. Always false for AWS-side instances.
+	IDKnown bool `json:"id_known,omitempty"`
+
+	// Address is the Terraform resource address that declares the instance
+	// (e.g., "module.web.aws_instance.this[0]"). Empty for AWS-side instances.
+	Address string `json:"address,omitempty"`
+
+	// StateFile is the Terraform state or configuration the instance was
+	// read from. Empty for AWS-side instances.
+	StateFile string `json:"state_file,omitempty"`
+
+	// Workspace is the Terraform workspace of StateFile, if known.
+	Workspace string `json:"workspace,omitempty"`
+
+	// Provider is the provider configuration managing the instance, e.g.
+	// "aws" or "aws.west". Empty for AWS-side instances.
+	Provider string `json:"provider,omitempty"`
+
+	// Region is the AWS region the instance is managed in, from its
+	// provider configuration or, failing that, its ARN or availability
+	// zone. Empty if unknown.
+	Region string `json:"region,omitempty"`
+
+	// ImportID is the instance ID of an import block targeting the
+	// instance's address in HCL. Empty otherwise.
+	ImportID string `json:"import_id,omitempty"`
+
+	// PreviousAddresses are the addresses moved blocks record the
+	// instance's resource or module was moved from, most recent first.
+	PreviousAddresses []string `json:"previous_addresses,omitempty"`
+
+	// MatchedBy is how the instance was matched when its address or ID
+	// alone did not: MatchedByImport, MatchedByMoved, MatchedByMapping or
+	// "tag:<key>".
+	MatchedBy string `json:"matched_by,omitempty"`
+
+	// MovedFrom is the address the instance was matched under when
+	// MatchedBy is MatchedByMoved.
+	MovedFrom string `json:"moved_from,omitempty"`
+
 	// InstanceType is the EC2 instance type (e.g., "t2.micro", "m5.large").
 	InstanceType string `json:"instance_type"`
 
@@ -74,6 +181,87 @@ type EC2Instance struct {
 
 	// IAMInstanceProfile is the ARN of the IAM instance profile attached.
 	IAMInstanceProfile string `json:"iam_instance_profile"`
+
+	// State is the instance state (e.g., "running", "stopped"). Empty for
+	// Terraform instances.
+	State string `json:"state,omitempty"`
+
+	// LaunchTime is when the instance was launched. Nil for Terraform
+	// instances.
+	LaunchTime *time.Time `json:"launch_time,omitempty"`
+
+	// IgnoreChanges lists the attribute paths of the resource's lifecycle
+	// ignore_changes (e.g., "tags", "root_block_device.volume_size"), or
+	// IgnoreAllChanges for ignore_changes = all. Drift on them is reported
+	// as ignored.
+	IgnoreChanges []string `json:"ignore_changes,omitempty"`
+
+	// Unknown lists the attribute paths whose Terraform value is not known
+	// until apply (e.g., "tags.CreatedAt" for tags = { CreatedAt = timestamp() }).
+	// They are reported as unknown rather than compared.
+	Unknown []string `json:"unknown,omitempty"`
+
+	// Sources records where each attribute set in HCL was declared, keyed
+	// by attribute path (e.g., "instance_type", "root_block_device.volume_size").
+	// Empty for instances read from state or AWS.
+	Sources map[string]Source `json:"sources,omitempty"`
+
+	// Configuration is the instance as declared in HCL, for a state
+	// instance compared three ways: configuration, state and AWS. Nil for
+	// a plain two-way comparison.
+	Configuration *EC2Instance `json:"-"`
+}
+
+// SourceOf returns where the Terraform value of the attribute at path was
+// declared: the HCL argument setting it or an enclosing one (e.g., "tags"
+// for "tags.Name"), otherwise the resource address and the state or
+// configuration the instance was read from. It returns false if neither is
+// known.
+func (i *EC2Instance) SourceOf(path string) (Source, bool) {
+	for p := path; p != ""; {
+		if src, ok := i.Sources[p]; ok {
+			return src, true
+		}
+		dot := strings.LastIndex(p, ".")
+		if dot < 0 {
+			break
+		}
+		p = p[:dot]
+	}
+	if i.Address == "" {
+		return Source{}, false
+	}
+	return Source{File: i.StateFile, Address: i.Address}, true
+}
+
+// Source locates the Terraform declaration of a value: a position in a
+// configuration file, or a resource in a state.
+type Source struct {
+	// File is the configuration or state file.
+	File string `json:"file,omitempty"`
+
+	// Line and Column are the 1-based position of the HCL argument, or zero
+	// for values read from state.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+
+	// Address is the Terraform resource address, for values read from
+	// state.
+	Address string `json:"address,omitempty"`
+}
+
+// String returns the source as "main.tf:27", or as the resource address
+// and state file, e.g. "module.web.aws_instance.this[0] (terraform.tfstate)".
+func (s Source) String() string {
+	switch {
+	case s.Line > 0:
+		return s.File + ":" + strconv.Itoa(s.Line)
+	case s.Address != "" && s.File != "":
+		return s.Address + " (" + s.File + ")"
+	case s.Address != "":
+		return s.Address
+	}
+	return s.File
 }
 
 // BlockDevice represents an EBS block device configuration.
@@ -106,6 +294,26 @@ type DriftResult struct {
 	// InstanceID is the EC2 instance ID that was checked.
 	InstanceID string `json:"instance_id"`
 
+	// Address is the Terraform resource address of the instance, if known.
+	Address string `json:"address,omitempty"`
+
+	// StateFile is the Terraform state or configuration the instance was
+	// read from.
+	StateFile string `json:"state_file,omitempty"`
+
+	// Workspace is the Terraform workspace of StateFile, if known.
+	Workspace string `json:"workspace,omitempty"`
+
+	// MatchedBy and MovedFrom record how the Terraform instance was
+	// matched, as in EC2Instance.
+	MatchedBy string `json:"matched_by,omitempty"`
+	MovedFrom string `json:"moved_from,omitempty"`
+
+	// Status is set when the instance could not be compared attribute by
+	// attribute, e.g. StatusMissingInAWS. Missing and unapplied instances
+	// have drift; uncorrelated ones do not.
+	Status ResultStatus `json:"status,omitempty"`
+
 	// HasDrift indicates whether any configuration drift was detected.
 	HasDrift bool `json:"has_drift"`
 
@@ -118,6 +326,23 @@ type DriftResult struct {
 	Error string `json:"error,omitempty"`
 }
 
+// MatchNote describes how the Terraform instance was matched, e.g.
+// "import block" or "moved from aws_instance.old", or returns an empty
+// string if it was matched by address or ID alone.
+func (r *DriftResult) MatchNote() string {
+	switch r.MatchedBy {
+	case "":
+		return ""
+	case MatchedByImport:
+		return "import block"
+	case MatchedByMoved:
+		return "moved from " + r.MovedFrom
+	case MatchedByMapping:
+		return "mapping file"
+	}
+	return strings.Replace(r.MatchedBy, ":", " ", 1)
+}
+
 // DriftedAttr represents a single attribute that has drifted.
 // It captures the attribute path and both the AWS and Terraform values
 // for easy comparison and reporting.
@@ -130,6 +355,28 @@ type DriftedAttr struct {
 
 	// TerraformValue is the expected value from Terraform configuration.
 	TerraformValue any `json:"terraform_value"`
+
+	// StateValue is the value recorded in the Terraform state, in a
+	// three-way comparison where TerraformValue comes from HCL. Nil
+	// otherwise.
+	StateValue any `json:"state_value,omitempty"`
+
+	// Kind classifies the difference in a three-way comparison. Empty
+	// otherwise.
+	Kind DriftKind `json:"kind,omitempty"`
+
+	// Ignored indicates the attribute is listed in the resource's lifecycle
+	// ignore_changes, so Terraform will not reconcile it. Ignored attributes
+	// do not count towards HasDrift.
+	Ignored bool `json:"ignored,omitempty"`
+
+	// Unknown indicates the Terraform value is not known until apply, so
+	// TerraformValue is UnknownValue. Unknown attributes do not count
+	// towards HasDrift.
+	Unknown bool `json:"unknown,omitempty"`
+
+	// Source is where the Terraform value was declared, if known.
+	Source *Source `json:"source,omitempty"`
 }
 
 // DriftReport contains the complete drift detection report for multiple instances.
@@ -138,9 +385,77 @@ type DriftReport struct {
 	// TotalInstances is the total number of instances checked.
 	TotalInstances int `json:"total_instances"`
 
-	// DriftedInstances is the count of instances with detected drift.
+	// DriftedInstances is the count of instances with detected drift,
+	// including those missing in AWS and those not yet applied.
 	DriftedInstances int `json:"drifted_instances"`
 
+	// MissingInstances is the count of Terraform instances not found in AWS.
+	MissingInstances int `json:"missing_instances"`
+
+	// UncorrelatedInstances is the count of Terraform instances without an
+	// instance ID. They are listed in Results but not counted as checked.
+	UncorrelatedInstances int `json:"uncorrelated_instances"`
+
+	// UnappliedInstances is the count of instances declared in the HCL
+	// configuration but missing from the state.
+	UnappliedInstances int `json:"unapplied_instances"`
+
 	// Results contains the detailed drift result for each instance.
 	Results []DriftResult `json:"results"`
+
+	// Conflicts lists instances found in more than one Terraform state.
+	// Each was checked against the first state it was found in.
+	Conflicts []StateConflict `json:"conflicts,omitempty"`
+
+	// Unmanaged lists instances found in AWS that no loaded Terraform
+	// state manages, when they were looked for.
+	Unmanaged []UnmanagedInstance `json:"unmanaged,omitempty"`
+}
+
+// UnmanagedInstance is an instance found in AWS that no loaded Terraform
+// state manages, e.g. one launched by hand. Its owner tags and launch time
+// help find out who created it.
+type UnmanagedInstance struct {
+	// InstanceID is the EC2 instance ID.
+	InstanceID string `json:"instance_id"`
+
+	// Region is the AWS region the instance was found in, if known.
+	Region string `json:"region,omitempty"`
+
+	// InstanceType is the EC2 instance type.
+	InstanceType string `json:"instance_type,omitempty"`
+
+	// State is the instance state (e.g., "running", "stopped").
+	State string `json:"state,omitempty"`
+
+	// VpcID is the VPC ID where the instance resides.
+	VpcID string `json:"vpc_id,omitempty"`
+
+	// LaunchTime is when the instance was launched.
+	LaunchTime *time.Time `json:"launch_time,omitempty"`
+
+	// OwnerTags are the instance's tags that identify its owner, such as
+	// Name, Owner or Team.
+	OwnerTags map[string]string `json:"owner_tags,omitempty"`
+}
+
+// StateRef identifies a Terraform state and the workspace it belongs to.
+type StateRef struct {
+	// StateFile is the path of the state or configuration.
+	StateFile string `json:"state_file"`
+
+	// Workspace is the Terraform workspace, if known.
+	Workspace string `json:"workspace,omitempty"`
+}
+
+// StateConflict records an instance ID declared by more than one Terraform
+// state, which usually means a resource was imported into several
+// workspaces.
+type StateConflict struct {
+	// InstanceID is the conflicting EC2 instance ID.
+	InstanceID string `json:"instance_id"`
+
+	// States lists every state declaring the instance, in the order they
+	// were read.
+	States []StateRef `json:"states"`
 }
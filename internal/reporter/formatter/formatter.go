@@ -17,10 +17,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
 	"text/tabwriter"
+	"time"
 
+	"github.com/solomon-os/go-test/internal/logger"
 	"github.com/solomon-os/go-test/internal/models"
 )
 
@@ -114,8 +118,8 @@ func (f *TableFormatter) Description() string { return "Tabular output format" }
 func (f *TableFormatter) Format(w io.Writer, report *models.DriftReport) error {
 	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
 
-	writef(tw, "INSTANCE ID\tDRIFT DETECTED\tDRIFTED ATTRIBUTES\n")
-	writef(tw, "-----------\t--------------\t------------------\n")
+	writef(tw, "INSTANCE ID\tADDRESS\tDRIFT DETECTED\tDRIFTED ATTRIBUTES\n")
+	writef(tw, "-----------\t-------\t--------------\t------------------\n")
 
 	for _, result := range report.Results {
 		driftStatus := "No"
@@ -127,7 +131,7 @@ func (f *TableFormatter) Format(w io.Writer, report *models.DriftReport) error {
 		if len(result.DriftedAttrs) > 0 {
 			attrNames := make([]string, len(result.DriftedAttrs))
 			for i, a := range result.DriftedAttrs {
-				attrNames[i] = a.Path
+				attrNames[i] = attrLabel(a)
 			}
 			attrs = strings.Join(attrNames, ", ")
 		}
@@ -135,13 +139,31 @@ func (f *TableFormatter) Format(w io.Writer, report *models.DriftReport) error {
 		if result.Error != "" {
 			attrs = fmt.Sprintf("ERROR: %s", result.Error)
 		}
+		switch result.Status {
+		case models.StatusMissingInAWS:
+			attrs = "MISSING IN AWS"
+		case models.StatusUncorrelated:
+			attrs = "NOT CORRELATED"
+		case models.StatusUnapplied:
+			attrs = "NOT APPLIED"
+		}
+
+		address := result.Address
+		if address == "" {
+			address = "-"
+		}
+		if note := result.MatchNote(); note != "" {
+			address += " (" + note + ")"
+		}
 
-		writef(tw, "%s\t%s\t%s\n", result.InstanceID, driftStatus, attrs)
+		writef(tw, "%s\t%s\t%s\t%s\n", result.InstanceID, address, driftStatus, attrs)
 	}
 
 	writef(tw, "\n")
-	writef(tw, "Summary: %d/%d instances with drift\n",
-		report.DriftedInstances, report.TotalInstances)
+	writef(tw, "Summary: %d/%d instances with drift%s\n",
+		report.DriftedInstances, report.TotalInstances, missingSuffix(report))
+	writeConflicts(tw, report.Conflicts)
+	writeUnmanaged(tw, report.Unmanaged)
 
 	return tw.Flush()
 }
@@ -158,6 +180,30 @@ func (f *TextFormatter) Format(w io.Writer, report *models.DriftReport) error {
 
 	for _, result := range report.Results {
 		writef(w, "Instance: %s\n", result.InstanceID)
+		if result.Address != "" {
+			writef(w, "  Address: %s\n", result.Address)
+		}
+		if note := result.MatchNote(); note != "" {
+			writef(w, "  Matched: %s\n", note)
+		}
+		if result.StateFile != "" {
+			writef(w, "  State: %s\n", formatStateRef(models.StateRef{
+				StateFile: result.StateFile,
+				Workspace: result.Workspace,
+			}))
+		}
+
+		switch result.Status {
+		case models.StatusMissingInAWS:
+			writef(w, "  Status: MISSING IN AWS\n\n")
+			continue
+		case models.StatusUncorrelated:
+			writef(w, "  Status: NOT CORRELATED (no instance ID)\n\n")
+			continue
+		case models.StatusUnapplied:
+			writef(w, "  Status: NOT APPLIED (in configuration, not in state)\n\n")
+			continue
+		}
 
 		if result.Error != "" {
 			writef(w, "  Error: %s\n\n", result.Error)
@@ -165,18 +211,16 @@ func (f *TextFormatter) Format(w io.Writer, report *models.DriftReport) error {
 		}
 
 		if !result.HasDrift {
-			writef(w, "  Status: No drift detected\n\n")
+			writef(w, "  Status: No drift detected\n")
+			writeAttrGroup(w, "Ignored Changes", result.DriftedAttrs, isIgnored)
+			writeAttrGroup(w, "Unknown Values", result.DriftedAttrs, isUnknown)
+			writef(w, "\n")
 			continue
 		}
 
 		writef(w, "  Status: DRIFT DETECTED\n")
 		writef(w, "  Drifted Attributes:\n")
-
-		for _, attr := range result.DriftedAttrs {
-			writef(w, "    - %s:\n", attr.Path)
-			writef(w, "        AWS:       %v\n", formatValue(attr.AWSValue))
-			writef(w, "        Terraform: %v\n", formatValue(attr.TerraformValue))
-		}
+		writeDriftedAttrs(w, result.DriftedAttrs)
 		writef(w, "\n")
 	}
 
@@ -184,8 +228,19 @@ func (f *TextFormatter) Format(w io.Writer, report *models.DriftReport) error {
 	writef(w, "-------\n")
 	writef(w, "Total instances checked: %d\n", report.TotalInstances)
 	writef(w, "Instances with drift:    %d\n", report.DriftedInstances)
+	if report.MissingInstances > 0 {
+		writef(w, "Missing in AWS:          %d\n", report.MissingInstances)
+	}
+	if report.UnappliedInstances > 0 {
+		writef(w, "Not applied:             %d\n", report.UnappliedInstances)
+	}
+	if report.UncorrelatedInstances > 0 {
+		writef(w, "Not correlated:          %d\n", report.UncorrelatedInstances)
+	}
 	writef(w, "Instances without drift: %d\n",
 		report.TotalInstances-report.DriftedInstances)
+	writeConflicts(w, report.Conflicts)
+	writeUnmanaged(w, report.Unmanaged)
 
 	return nil
 }
@@ -197,11 +252,16 @@ func (f *CompactFormatter) Name() string        { return "compact" }
 func (f *CompactFormatter) Description() string { return "Compact single-line summary" }
 
 func (f *CompactFormatter) Format(w io.Writer, report *models.DriftReport) error {
+	unmanaged := ""
+	if len(report.Unmanaged) > 0 {
+		unmanaged = fmt.Sprintf("; %d unmanaged instances", len(report.Unmanaged))
+	}
 	if report.DriftedInstances == 0 {
-		writef(w, "OK: No drift detected in %d instances\n", report.TotalInstances)
+		writef(w, "OK: No drift detected in %d instances%s%s\n",
+			report.TotalInstances, missingSuffix(report), unmanaged)
 	} else {
-		writef(w, "DRIFT: %d/%d instances have drift\n",
-			report.DriftedInstances, report.TotalInstances)
+		writef(w, "DRIFT: %d/%d instances have drift%s%s\n",
+			report.DriftedInstances, report.TotalInstances, missingSuffix(report), unmanaged)
 	}
 	return nil
 }
@@ -209,7 +269,143 @@ func (f *CompactFormatter) Format(w io.Writer, report *models.DriftReport) error
 // Helper functions
 
 func writef(w io.Writer, format string, args ...any) {
-	_, _ = fmt.Fprintf(w, format, args...)
+	if _, err := fmt.Fprintf(w, format, args...); err != nil {
+		logger.Warn("failed to write output", "error", err)
+	}
+}
+
+// writeDriftedAttrs lists drifted attributes with their AWS and Terraform
+// values and where the Terraform value was declared. Three-way differences
+// show the configuration and state values.
+func writeDriftedAttrs(w io.Writer, attrs []models.DriftedAttr) {
+	for _, attr := range attrs {
+		writef(w, "    - %s:\n", attrLabel(attr))
+		writef(w, "        AWS:       %v\n", formatValue(attr.AWSValue))
+		if attr.Kind != "" {
+			writef(w, "        Config:    %v\n", formatValue(attr.TerraformValue))
+			writef(w, "        State:     %v\n", formatValue(attr.StateValue))
+		} else {
+			writef(w, "        Terraform: %v\n", formatValue(attr.TerraformValue))
+		}
+		if attr.Source != nil {
+			writef(w, "        Source:    %s\n", attr.Source)
+		}
+	}
+}
+
+func isIgnored(attr models.DriftedAttr) bool { return attr.Ignored }
+func isUnknown(attr models.DriftedAttr) bool { return attr.Unknown }
+
+// writeAttrGroup lists the attributes matching keep under heading, if any.
+func writeAttrGroup(
+	w io.Writer,
+	heading string,
+	attrs []models.DriftedAttr,
+	keep func(models.DriftedAttr) bool,
+) {
+	var group []models.DriftedAttr
+	for _, attr := range attrs {
+		if keep(attr) {
+			group = append(group, attr)
+		}
+	}
+	if len(group) == 0 {
+		return
+	}
+	writef(w, "  %s:\n", heading)
+	writeDriftedAttrs(w, group)
+}
+
+// attrLabel returns the attribute path, marked if its drift is ignored
+// through lifecycle ignore_changes or its Terraform value is unknown, or
+// with the kind of a three-way difference.
+func attrLabel(attr models.DriftedAttr) string {
+	switch {
+	case attr.Ignored:
+		return attr.Path + " (ignored)"
+	case attr.Unknown:
+		return attr.Path + " (unknown)"
+	case attr.Kind == models.DriftUnapplied:
+		return attr.Path + " (not applied)"
+	case attr.Kind == models.DriftOutOfBand:
+		return attr.Path + " (out-of-band)"
+	case attr.Kind == models.DriftBoth:
+		return attr.Path + " (not applied, out-of-band)"
+	}
+	return attr.Path
+}
+
+// missingSuffix returns ", N missing in AWS, M not applied, K not
+// correlated" for a summary line, leaving out zero counts.
+func missingSuffix(report *models.DriftReport) string {
+	var suffix string
+	if report.MissingInstances > 0 {
+		suffix += fmt.Sprintf(", %d missing in AWS", report.MissingInstances)
+	}
+	if report.UnappliedInstances > 0 {
+		suffix += fmt.Sprintf(", %d not applied", report.UnappliedInstances)
+	}
+	if report.UncorrelatedInstances > 0 {
+		suffix += fmt.Sprintf(", %d not correlated", report.UncorrelatedInstances)
+	}
+	return suffix
+}
+
+// writeConflicts lists instances found in more than one Terraform state.
+func writeConflicts(w io.Writer, conflicts []models.StateConflict) {
+	if len(conflicts) == 0 {
+		return
+	}
+	writef(w, "\nConflicts\n")
+	writef(w, "---------\n")
+	for _, c := range conflicts {
+		writef(w, "%s is declared in %d states:\n", c.InstanceID, len(c.States))
+		for _, ref := range c.States {
+			writef(w, "  - %s\n", formatStateRef(ref))
+		}
+	}
+}
+
+// writeUnmanaged lists instances in AWS that no Terraform state manages,
+// with their owner tags.
+func writeUnmanaged(w io.Writer, unmanaged []models.UnmanagedInstance) {
+	if len(unmanaged) == 0 {
+		return
+	}
+	writef(w, "\nUnmanaged Instances\n")
+	writef(w, "-------------------\n")
+	for _, u := range unmanaged {
+		details := make([]string, 0, 4)
+		for _, d := range []string{u.Region, u.InstanceType, u.State} {
+			if d != "" {
+				details = append(details, d)
+			}
+		}
+		if u.LaunchTime != nil {
+			details = append(details, "launched "+u.LaunchTime.UTC().Format(time.RFC3339))
+		}
+		writef(w, "%s (%s)\n", u.InstanceID, strings.Join(details, ", "))
+		if len(u.OwnerTags) > 0 {
+			writef(w, "  Owner tags: %s\n", formatTags(u.OwnerTags))
+		}
+	}
+}
+
+// formatTags formats tags as key=value pairs sorted by key.
+func formatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		pairs = append(pairs, k+"="+tags[k])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// formatStateRef renders a state path with its workspace, if known.
+func formatStateRef(ref models.StateRef) string {
+	if ref.Workspace == "" {
+		return ref.StateFile
+	}
+	return fmt.Sprintf("%s (workspace %s)", ref.StateFile, ref.Workspace)
 }
 
 func formatValue(v any) string {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/solomon-os/go-test/internal/models"
 )
@@ -71,6 +72,15 @@ func TestJSONFormatter(t *testing.T) {
 				{InstanceID: "i-123", HasDrift: true},
 				{InstanceID: "i-456", HasDrift: false},
 			},
+			Conflicts: []models.StateConflict{
+				{
+					InstanceID: "i-123",
+					States: []models.StateRef{
+						{StateFile: "envs/prod.tfstate"},
+						{StateFile: "terraform.tfstate.d/prod/terraform.tfstate", Workspace: "prod"},
+					},
+				},
+			},
 		}
 
 		var buf bytes.Buffer
@@ -88,6 +98,9 @@ func TestJSONFormatter(t *testing.T) {
 		if decoded.TotalInstances != 2 {
 			t.Errorf("expected TotalInstances 2, got %d", decoded.TotalInstances)
 		}
+		if len(decoded.Conflicts) != 1 || decoded.Conflicts[0].States[1].Workspace != "prod" {
+			t.Errorf("expected conflict to round-trip, got %+v", decoded.Conflicts)
+		}
 	})
 
 	t.Run("Format respects custom indent", func(t *testing.T) {
@@ -178,6 +191,46 @@ func TestTableFormatter(t *testing.T) {
 			t.Error("expected error message in output")
 		}
 	})
+
+	t.Run("Format notes how instances were matched", func(t *testing.T) {
+		report := &models.DriftReport{
+			TotalInstances: 1,
+			Results: []models.DriftResult{
+				{InstanceID: "i-123", Address: "aws_instance.web", MatchedBy: "tag:Name"},
+			},
+		}
+
+		var buf bytes.Buffer
+		_ = f.Format(&buf, report)
+
+		if !strings.Contains(buf.String(), "aws_instance.web (tag Name)") {
+			t.Errorf("expected match note in output:\n%s", buf.String())
+		}
+	})
+
+	t.Run("Format labels three-way drift", func(t *testing.T) {
+		report := &models.DriftReport{
+			TotalInstances:   1,
+			DriftedInstances: 1,
+			Results: []models.DriftResult{
+				{
+					InstanceID: "i-123",
+					HasDrift:   true,
+					DriftedAttrs: []models.DriftedAttr{
+						{Path: "instance_type", Kind: models.DriftUnapplied},
+						{Path: "ami", Kind: models.DriftOutOfBand},
+					},
+				},
+			},
+		}
+
+		var buf bytes.Buffer
+		_ = f.Format(&buf, report)
+
+		if !strings.Contains(buf.String(), "instance_type (not applied), ami (out-of-band)") {
+			t.Errorf("expected three-way labels in output:\n%s", buf.String())
+		}
+	})
 }
 
 func TestTextFormatter(t *testing.T) {
@@ -196,17 +249,35 @@ func TestTextFormatter(t *testing.T) {
 			Results: []models.DriftResult{
 				{
 					InstanceID: "i-123",
+					Address:    "aws_instance.web",
+					StateFile:  "envs/prod.tfstate",
 					HasDrift:   true,
 					DriftedAttrs: []models.DriftedAttr{
 						{
 							Path:           "instance_type",
 							AWSValue:       "t2.large",
 							TerraformValue: "t2.micro",
+							Source:         &models.Source{File: "main.tf", Line: 27, Column: 3},
+						},
+						{
+							Path:           "ami",
+							AWSValue:       "ami-new",
+							TerraformValue: "ami-old",
+							Ignored:        true,
 						},
 					},
 				},
 				{InstanceID: "i-456", HasDrift: false},
 			},
+			Conflicts: []models.StateConflict{
+				{
+					InstanceID: "i-123",
+					States: []models.StateRef{
+						{StateFile: "envs/prod.tfstate"},
+						{StateFile: "terraform.tfstate.d/prod/terraform.tfstate", Workspace: "prod"},
+					},
+				},
+			},
 		}
 
 		var buf bytes.Buffer
@@ -226,6 +297,24 @@ func TestTextFormatter(t *testing.T) {
 		if !strings.Contains(output, "Instance: i-123") {
 			t.Error("expected instance i-123")
 		}
+		if !strings.Contains(output, "Address: aws_instance.web") {
+			t.Error("expected resource address")
+		}
+		if !strings.Contains(output, "- ami (ignored):") {
+			t.Error("expected ignored attribute marker")
+		}
+		if !strings.Contains(output, "Source:    main.tf:27") {
+			t.Error("expected attribute source")
+		}
+		if !strings.Contains(output, "State: envs/prod.tfstate") {
+			t.Error("expected state file")
+		}
+		if !strings.Contains(output, "i-123 is declared in 2 states") {
+			t.Error("expected state conflict")
+		}
+		if !strings.Contains(output, "terraform.tfstate.d/prod/terraform.tfstate (workspace prod)") {
+			t.Error("expected conflicting workspace")
+		}
 
 		// Check for drift status
 		if !strings.Contains(output, "DRIFT DETECTED") {
@@ -319,6 +408,114 @@ func TestCompactFormatter(t *testing.T) {
 	})
 }
 
+func TestFormatters_MissingInAWS(t *testing.T) {
+	report := &models.DriftReport{
+		TotalInstances:        3,
+		DriftedInstances:      2,
+		MissingInstances:      1,
+		UncorrelatedInstances: 1,
+		UnappliedInstances:    1,
+		Results: []models.DriftResult{
+			{InstanceID: "i-123"},
+			{
+				InstanceID: "i-456",
+				Address:    "aws_instance.db",
+				Status:     models.StatusMissingInAWS,
+				HasDrift:   true,
+			},
+			{InstanceID: "web", Address: "aws_instance.web", Status: models.StatusUncorrelated},
+			{InstanceID: "aws_instance.api", Address: "aws_instance.api", Status: models.StatusUnapplied, HasDrift: true},
+		},
+	}
+
+	tests := []struct {
+		formatter Formatter
+		want      []string
+	}{
+		{formatter: &TextFormatter{}, want: []string{
+			"  Status: MISSING IN AWS",
+			"  Status: NOT CORRELATED (no instance ID)",
+			"  Status: NOT APPLIED (in configuration, not in state)",
+			"Missing in AWS:          1",
+			"Not applied:             1",
+			"Not correlated:          1",
+		}},
+		{formatter: &TableFormatter{}, want: []string{
+			"MISSING IN AWS",
+			"NOT CORRELATED",
+			"NOT APPLIED",
+			"Summary: 2/3 instances with drift, 1 missing in AWS, 1 not applied, 1 not correlated",
+		}},
+		{formatter: &JSONFormatter{}, want: []string{
+			`"status": "missing_in_aws"`,
+			`"status": "uncorrelated"`,
+			`"missing_instances": 1`,
+			`"uncorrelated_instances": 1`,
+			`"status": "unapplied"`,
+			`"unapplied_instances": 1`,
+		}},
+		{formatter: &CompactFormatter{}, want: []string{"DRIFT: 2/3 instances have drift, 1 missing in AWS, 1 not applied, 1 not correlated"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formatter.Name(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.formatter.Format(&buf, report); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestFormatters_Unmanaged(t *testing.T) {
+	launched := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+	report := &models.DriftReport{
+		TotalInstances: 1,
+		Results:        []models.DriftResult{{InstanceID: "i-123"}},
+		Unmanaged: []models.UnmanagedInstance{
+			{
+				InstanceID:   "i-0shadow",
+				InstanceType: "t3.micro",
+				LaunchTime:   &launched,
+				OwnerTags:    map[string]string{"Team": "data"},
+			},
+		},
+	}
+
+	tests := []struct {
+		formatter Formatter
+		want      []string
+	}{
+		{formatter: &TextFormatter{}, want: []string{
+			"Unmanaged Instances",
+			"i-0shadow (t3.micro, launched 2024-02-01T09:00:00Z)",
+			"  Owner tags: Team=data",
+		}},
+		{formatter: &TableFormatter{}, want: []string{"Unmanaged Instances", "i-0shadow (t3.micro"}},
+		{formatter: &JSONFormatter{}, want: []string{`"unmanaged": [`, `"owner_tags": {`}},
+		{formatter: &CompactFormatter{}, want: []string{"OK: No drift detected in 1 instances; 1 unmanaged instances"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formatter.Name(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.formatter.Format(&buf, report); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
 func TestFormatValue(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -333,6 +530,7 @@ func TestFormatValue(t *testing.T) {
 		{"string map", map[string]string{"key": "val"}, "{key=val}"},
 		{"int", 42, "42"},
 		{"bool", true, "true"},
+		{"bool false", false, "false"},
 	}
 
 	for _, tt := range tests {
@@ -2,22 +2,12 @@
 package reporter
 
 import (
-	"encoding/json"
-	"fmt"
 	"io"
-	"strings"
-	"text/tabwriter"
 
-	"github.com/solomon-os/go-test/internal/logger"
 	"github.com/solomon-os/go-test/internal/models"
+	"github.com/solomon-os/go-test/internal/reporter/formatter"
 )
 
-func writef(w io.Writer, format string, args ...any) {
-	if _, err := fmt.Fprintf(w, format, args...); err != nil {
-		logger.Warn("failed to write output", "error", err)
-	}
-}
-
 // Format represents the output format for reports.
 type Format string
 
@@ -68,121 +58,28 @@ func (r *Reporter) ReportSingle(result *models.DriftResult) error {
 	if result.HasDrift {
 		report.DriftedInstances = 1
 	}
+	switch result.Status {
+	case models.StatusMissingInAWS:
+		report.MissingInstances = 1
+	case models.StatusUncorrelated:
+		report.TotalInstances = 0
+		report.UncorrelatedInstances = 1
+	case models.StatusUnapplied:
+		report.UnappliedInstances = 1
+	}
 	return r.Report(report)
 }
 
+// reportJSON, reportTable and reportText render report with the formatter
+// of the same name, so both packages produce the same output.
 func (r *Reporter) reportJSON(report *models.DriftReport) error {
-	encoder := json.NewEncoder(r.writer)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(report)
+	return (&formatter.JSONFormatter{}).Format(r.writer, report)
 }
 
 func (r *Reporter) reportTable(report *models.DriftReport) error {
-	w := tabwriter.NewWriter(r.writer, 0, 0, 2, ' ', 0)
-
-	writef(w, "INSTANCE ID\tDRIFT DETECTED\tDRIFTED ATTRIBUTES\n")
-	writef(w, "-----------\t--------------\t------------------\n")
-
-	for _, result := range report.Results {
-		driftStatus := "No"
-		if result.HasDrift {
-			driftStatus = "Yes"
-		}
-
-		attrs := "-"
-		if len(result.DriftedAttrs) > 0 {
-			attrNames := make([]string, len(result.DriftedAttrs))
-			for i, a := range result.DriftedAttrs {
-				attrNames[i] = a.Path
-			}
-			attrs = strings.Join(attrNames, ", ")
-		}
-
-		if result.Error != "" {
-			attrs = fmt.Sprintf("ERROR: %s", result.Error)
-		}
-
-		writef(w, "%s\t%s\t%s\n", result.InstanceID, driftStatus, attrs)
-	}
-
-	writef(w, "\n")
-	_, _ = fmt.Fprintf(
-		w,
-		"Summary: %d/%d instances with drift\n",
-		report.DriftedInstances,
-		report.TotalInstances,
-	)
-
-	return w.Flush()
+	return (&formatter.TableFormatter{}).Format(r.writer, report)
 }
 
 func (r *Reporter) reportText(report *models.DriftReport) error {
-	writef(r.writer, "EC2 Drift Detection Report\n")
-	writef(r.writer, "==========================\n\n")
-
-	for _, result := range report.Results {
-		writef(r.writer, "Instance: %s\n", result.InstanceID)
-
-		if result.Error != "" {
-			writef(r.writer, "  Error: %s\n\n", result.Error)
-			continue
-		}
-
-		if !result.HasDrift {
-			writef(r.writer, "  Status: No drift detected\n\n")
-			continue
-		}
-
-		writef(r.writer, "  Status: DRIFT DETECTED\n")
-		writef(r.writer, "  Drifted Attributes:\n")
-
-		for _, attr := range result.DriftedAttrs {
-			writef(r.writer, "    - %s:\n", attr.Path)
-			writef(r.writer, "        AWS:       %v\n", formatValue(attr.AWSValue))
-			_, _ = fmt.Fprintf(
-				r.writer,
-				"        Terraform: %v\n",
-				formatValue(attr.TerraformValue),
-			)
-		}
-		writef(r.writer, "\n")
-	}
-
-	writef(r.writer, "Summary\n")
-	writef(r.writer, "-------\n")
-	writef(r.writer, "Total instances checked: %d\n", report.TotalInstances)
-	writef(r.writer, "Instances with drift:    %d\n", report.DriftedInstances)
-	_, _ = fmt.Fprintf(
-		r.writer,
-		"Instances without drift: %d\n",
-		report.TotalInstances-report.DriftedInstances,
-	)
-
-	return nil
-}
-
-func formatValue(v any) string {
-	switch val := v.(type) {
-	case []string:
-		if len(val) == 0 {
-			return "[]"
-		}
-		return fmt.Sprintf("[%s]", strings.Join(val, ", "))
-	case map[string]string:
-		if len(val) == 0 {
-			return "{}"
-		}
-		pairs := make([]string, 0, len(val))
-		for k, v := range val {
-			pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
-		}
-		return fmt.Sprintf("{%s}", strings.Join(pairs, ", "))
-	case string:
-		if val == "" {
-			return "(empty)"
-		}
-		return val
-	default:
-		return fmt.Sprintf("%v", v)
-	}
+	return (&formatter.TextFormatter{}).Format(r.writer, report)
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/solomon-os/go-test/internal/models"
 )
@@ -79,6 +80,7 @@ func TestReporter_Report_Table(t *testing.T) {
 		Results: []models.DriftResult{
 			{
 				InstanceID: "i-123",
+				Address:    "module.web.aws_instance.this[0]",
 				HasDrift:   true,
 				DriftedAttrs: []models.DriftedAttr{
 					{Path: "instance_type"},
@@ -106,6 +108,12 @@ func TestReporter_Report_Table(t *testing.T) {
 	if !strings.Contains(output, "DRIFT DETECTED") {
 		t.Error("Table output missing DRIFT DETECTED header")
 	}
+	if !strings.Contains(output, "ADDRESS") {
+		t.Error("Table output missing ADDRESS header")
+	}
+	if !strings.Contains(output, "module.web.aws_instance.this[0]") {
+		t.Error("Table output missing resource address")
+	}
 
 	// Check for instance data
 	if !strings.Contains(output, "i-123") {
@@ -187,6 +195,395 @@ func TestReporter_Report_Text(t *testing.T) {
 	}
 }
 
+func TestReporter_Report_Conflicts(t *testing.T) {
+	report := &models.DriftReport{
+		TotalInstances: 1,
+		Results: []models.DriftResult{
+			{
+				InstanceID: "i-123",
+				StateFile:  "terraform.tfstate.d/prod/terraform.tfstate",
+				Workspace:  "prod",
+			},
+		},
+		Conflicts: []models.StateConflict{
+			{
+				InstanceID: "i-123",
+				States: []models.StateRef{
+					{StateFile: "terraform.tfstate.d/prod/terraform.tfstate", Workspace: "prod"},
+					{StateFile: "legacy.tfstate"},
+				},
+			},
+		},
+	}
+
+	for _, format := range []Format{FormatText, FormatTable} {
+		t.Run(string(format), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := New(buf, format).Report(report); err != nil {
+				t.Fatalf("Report() error = %v", err)
+			}
+
+			output := buf.String()
+			for _, want := range []string{
+				"i-123 is declared in 2 states",
+				"terraform.tfstate.d/prod/terraform.tfstate (workspace prod)",
+				"  - legacy.tfstate",
+			} {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+
+	t.Run("text shows state", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if err := New(buf, FormatText).Report(report); err != nil {
+			t.Fatalf("Report() error = %v", err)
+		}
+		if !strings.Contains(buf.String(), "  State: terraform.tfstate.d/prod/terraform.tfstate (workspace prod)") {
+			t.Errorf("text output missing state line:\n%s", buf.String())
+		}
+	})
+}
+
+func TestReporter_Report_IgnoredChanges(t *testing.T) {
+	report := &models.DriftReport{
+		TotalInstances:   2,
+		DriftedInstances: 1,
+		Results: []models.DriftResult{
+			{
+				InstanceID: "i-123",
+				HasDrift:   true,
+				DriftedAttrs: []models.DriftedAttr{
+					{Path: "instance_type", AWSValue: "t3.large", TerraformValue: "t3.micro"},
+					{Path: "ami", AWSValue: "ami-new", TerraformValue: "ami-old", Ignored: true},
+				},
+			},
+			{
+				InstanceID: "i-456",
+				DriftedAttrs: []models.DriftedAttr{
+					{Path: "tags", AWSValue: map[string]string{}, TerraformValue: map[string]string{}, Ignored: true},
+				},
+			},
+		},
+	}
+
+	t.Run("text", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if err := New(buf, FormatText).Report(report); err != nil {
+			t.Fatalf("Report() error = %v", err)
+		}
+		output := buf.String()
+		for _, want := range []string{"    - ami (ignored):", "Ignored Changes:", "    - tags (ignored):"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("output missing %q:\n%s", want, output)
+			}
+		}
+		if strings.Contains(output, "instance_type (ignored)") {
+			t.Error("instance_type should not be marked as ignored")
+		}
+	})
+
+	t.Run("table", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if err := New(buf, FormatTable).Report(report); err != nil {
+			t.Fatalf("Report() error = %v", err)
+		}
+		if !strings.Contains(buf.String(), "instance_type, ami (ignored)") {
+			t.Errorf("table output missing ignored marker:\n%s", buf.String())
+		}
+	})
+}
+
+func TestReporter_Report_Sources(t *testing.T) {
+	report := &models.DriftReport{
+		TotalInstances:   1,
+		DriftedInstances: 1,
+		Results: []models.DriftResult{
+			{
+				InstanceID: "i-123",
+				HasDrift:   true,
+				DriftedAttrs: []models.DriftedAttr{
+					{
+						Path: "instance_type", AWSValue: "t3.large", TerraformValue: "t3.micro",
+						Source: &models.Source{File: "main.tf", Line: 27, Column: 3},
+					},
+					{
+						Path: "ami", AWSValue: "ami-new", TerraformValue: "ami-old",
+						Source: &models.Source{
+							File:    "terraform.tfstate",
+							Address: "module.web.aws_instance.this[0]",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("text", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if err := New(buf, FormatText).Report(report); err != nil {
+			t.Fatalf("Report() error = %v", err)
+		}
+		output := buf.String()
+		for _, want := range []string{
+			"Source:    main.tf:27",
+			"Source:    module.web.aws_instance.this[0] (terraform.tfstate)",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("output missing %q:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if err := New(buf, FormatJSON).Report(report); err != nil {
+			t.Fatalf("Report() error = %v", err)
+		}
+		var decoded models.DriftReport
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		src := decoded.Results[0].DriftedAttrs[0].Source
+		if src == nil || src.File != "main.tf" || src.Line != 27 || src.Column != 3 {
+			t.Errorf("Source = %+v, want main.tf:27:3", src)
+		}
+	})
+}
+
+func TestReporter_Report_UnknownValues(t *testing.T) {
+	report := &models.DriftReport{
+		TotalInstances: 1,
+		Results: []models.DriftResult{
+			{
+				InstanceID: "i-123",
+				DriftedAttrs: []models.DriftedAttr{
+					{Path: "tags.CreatedAt", AWSValue: "2024-01-01", TerraformValue: models.UnknownValue, Unknown: true},
+				},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := New(buf, FormatText).Report(report); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	output := buf.String()
+	for _, want := range []string{"Unknown Values:", "    - tags.CreatedAt (unknown):", "Terraform: unknown"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Ignored Changes:") {
+		t.Errorf("output should not list ignored changes:\n%s", output)
+	}
+}
+
+func TestReporter_Report_ThreeWay(t *testing.T) {
+	report := &models.DriftReport{
+		TotalInstances:   1,
+		DriftedInstances: 1,
+		Results: []models.DriftResult{
+			{
+				InstanceID: "i-123",
+				HasDrift:   true,
+				DriftedAttrs: []models.DriftedAttr{
+					{
+						Path:           "instance_type",
+						AWSValue:       "t3.micro",
+						TerraformValue: "t3.large",
+						StateValue:     "t3.micro",
+						Kind:           models.DriftUnapplied,
+					},
+					{
+						Path:           "ami",
+						AWSValue:       "ami-manual",
+						TerraformValue: "ami-new",
+						StateValue:     "ami-old",
+						Kind:           models.DriftBoth,
+					},
+				},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := New(buf, FormatText).Report(report); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	output := buf.String()
+	for _, want := range []string{
+		"    - instance_type (not applied):",
+		"    - ami (not applied, out-of-band):",
+		"        Config:    ami-new",
+		"        State:     ami-old",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Terraform:") {
+		t.Errorf("three-way output should not show a Terraform value:\n%s", output)
+	}
+
+	buf.Reset()
+	if err := New(buf, FormatJSON).Report(report); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	for _, want := range []string{`"state_value": "ami-old"`, `"kind": "both"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("JSON output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestReporter_Report_MatchNote(t *testing.T) {
+	report := &models.DriftReport{
+		TotalInstances: 2,
+		Results: []models.DriftResult{
+			{
+				InstanceID: "i-123",
+				Address:    "aws_instance.app",
+				MatchedBy:  models.MatchedByMoved,
+				MovedFrom:  "aws_instance.server",
+			},
+			{
+				InstanceID: "i-456",
+				Address:    "aws_instance.web",
+				MatchedBy:  models.MatchedByImport,
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := New(buf, FormatText).Report(report); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	for _, want := range []string{"  Matched: moved from aws_instance.server", "  Matched: import block"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := New(buf, FormatTable).Report(report); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "aws_instance.app (moved from aws_instance.server)") {
+		t.Errorf("table output missing match note:\n%s", buf.String())
+	}
+}
+
+func TestReporter_Report_MissingInAWS(t *testing.T) {
+	report := &models.DriftReport{
+		TotalInstances:        3,
+		DriftedInstances:      2,
+		MissingInstances:      1,
+		UncorrelatedInstances: 1,
+		UnappliedInstances:    1,
+		Results: []models.DriftResult{
+			{InstanceID: "i-123"},
+			{
+				InstanceID: "i-456",
+				Address:    "aws_instance.db",
+				Status:     models.StatusMissingInAWS,
+				HasDrift:   true,
+			},
+			{InstanceID: "web", Address: "aws_instance.web", Status: models.StatusUncorrelated},
+			{InstanceID: "aws_instance.api", Address: "aws_instance.api", Status: models.StatusUnapplied, HasDrift: true},
+		},
+	}
+
+	tests := []struct {
+		format Format
+		want   []string
+	}{
+		{format: FormatText, want: []string{
+			"  Status: MISSING IN AWS",
+			"  Status: NOT CORRELATED (no instance ID)",
+			"  Status: NOT APPLIED (in configuration, not in state)",
+			"Missing in AWS:          1",
+			"Not applied:             1",
+			"Not correlated:          1",
+		}},
+		{format: FormatTable, want: []string{
+			"MISSING IN AWS",
+			"NOT CORRELATED",
+			"NOT APPLIED",
+			"Summary: 2/3 instances with drift, 1 missing in AWS, 1 not applied, 1 not correlated",
+		}},
+		{format: FormatJSON, want: []string{
+			`"status": "missing_in_aws"`,
+			`"status": "uncorrelated"`,
+			`"missing_instances": 1`,
+			`"uncorrelated_instances": 1`,
+			`"status": "unapplied"`,
+			`"unapplied_instances": 1`,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := New(buf, tt.format).Report(report); err != nil {
+				t.Fatalf("Report() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestReporter_Report_Unmanaged(t *testing.T) {
+	launched := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+	report := &models.DriftReport{
+		TotalInstances: 1,
+		Results:        []models.DriftResult{{InstanceID: "i-123"}},
+		Unmanaged: []models.UnmanagedInstance{
+			{
+				InstanceID:   "i-0shadow",
+				Region:       "us-east-1",
+				InstanceType: "t3.micro",
+				State:        "running",
+				LaunchTime:   &launched,
+				OwnerTags:    map[string]string{"Owner": "alice", "Name": "scratch"},
+			},
+		},
+	}
+
+	tests := []struct {
+		format Format
+		want   []string
+	}{
+		{format: FormatText, want: []string{
+			"Unmanaged Instances",
+			"i-0shadow (us-east-1, t3.micro, running, launched 2024-02-01T09:00:00Z)",
+			"  Owner tags: Name=scratch, Owner=alice",
+		}},
+		{format: FormatTable, want: []string{"Unmanaged Instances", "i-0shadow (us-east-1"}},
+		{format: FormatJSON, want: []string{`"unmanaged": [`, `"launch_time": "2024-02-01T09:00:00Z"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := New(buf, tt.format).Report(report); err != nil {
+				t.Fatalf("Report() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
 func TestReporter_Report_WithError(t *testing.T) {
 	buf := &bytes.Buffer{}
 	r := New(buf, FormatText)
@@ -316,30 +713,3 @@ func TestReporter_DefaultFormat(t *testing.T) {
 		t.Error("Expected text format for unknown format type")
 	}
 }
-
-func TestFormatValue(t *testing.T) {
-	tests := []struct {
-		name  string
-		value any
-		want  string
-	}{
-		{"empty string", "", "(empty)"},
-		{"non-empty string", "test", "test"},
-		{"empty slice", []string{}, "[]"},
-		{"string slice", []string{"a", "b"}, "[a, b]"},
-		{"empty map", map[string]string{}, "{}"},
-		{"string map", map[string]string{"k": "v"}, "{k=v}"},
-		{"integer", 42, "42"},
-		{"boolean true", true, "true"},
-		{"boolean false", false, "false"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := formatValue(tt.value)
-			if got != tt.want {
-				t.Errorf("formatValue() = %s, want %s", got, tt.want)
-			}
-		})
-	}
-}
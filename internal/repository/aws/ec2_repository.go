@@ -7,6 +7,9 @@ package aws
 import (
 	"context"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
 	"github.com/solomon-os/go-test/internal/aws"
 	"github.com/solomon-os/go-test/internal/models"
 	"github.com/solomon-os/go-test/internal/repository"
@@ -48,13 +51,22 @@ func (r *EC2Repository) GetByIDs(ctx context.Context, instanceIDs []string) ([]*
 	return r.client.GetInstances(ctx, instanceIDs)
 }
 
-// List retrieves all EC2 instances matching the given filters.
-// Note: Currently, filters are not implemented - all instances are returned.
-// This is a placeholder for future filter support.
+// List retrieves all EC2 instances matching every given filter, paging
+// through DescribeInstances. With no filters, all instances are returned.
 func (r *EC2Repository) List(ctx context.Context, filters ...repository.Filter) ([]*models.EC2Instance, error) {
-	// TODO: Implement filter support using AWS DescribeInstances filters
-	// For now, this method requires instance IDs to be provided via GetByIDs
-	return nil, nil
+	return r.client.ListInstances(ctx, ec2Filters(filters)...)
+}
+
+// ec2Filters converts repository filters into DescribeInstances filters.
+func ec2Filters(filters []repository.Filter) []types.Filter {
+	converted := make([]types.Filter, 0, len(filters))
+	for _, f := range filters {
+		converted = append(converted, types.Filter{
+			Name:   awssdk.String(f.Name),
+			Values: f.Values,
+		})
+	}
+	return converted
 }
 
 // Client returns the underlying AWS client.
@@ -3,8 +3,14 @@ package aws
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/solomon-os/go-test/internal/aws"
 	"github.com/solomon-os/go-test/internal/models"
 	"github.com/solomon-os/go-test/internal/repository"
 )
@@ -90,18 +96,65 @@ func TestEC2Repository_GetByIDs(t *testing.T) {
 	})
 }
 
+// fakeEC2API implements aws.EC2Client, serving pages of instances.
+type fakeEC2API struct {
+	pages [][]types.Instance
+	calls []*ec2.DescribeInstancesInput
+}
+
+func (f *fakeEC2API) DescribeInstances(
+	ctx context.Context,
+	params *ec2.DescribeInstancesInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.DescribeInstancesOutput, error) {
+	f.calls = append(f.calls, params)
+	page := len(f.calls) - 1
+	output := &ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{{Instances: f.pages[page]}},
+	}
+	if page+1 < len(f.pages) {
+		output.NextToken = awssdk.String(fmt.Sprintf("page-%d", page+1))
+	}
+	return output, nil
+}
+
+func (f *fakeEC2API) DescribeVolumes(
+	ctx context.Context,
+	params *ec2.DescribeVolumesInput,
+	optFns ...func(*ec2.Options),
+) (*ec2.DescribeVolumesOutput, error) {
+	return &ec2.DescribeVolumesOutput{}, nil
+}
+
 func TestEC2Repository_List(t *testing.T) {
-	t.Run("returns nil (not implemented)", func(t *testing.T) {
-		repo := NewEC2Repository(nil)
+	api := &fakeEC2API{pages: [][]types.Instance{
+		{{InstanceId: awssdk.String("i-1")}},
+		{{InstanceId: awssdk.String("i-2")}},
+	}}
+	repo := NewEC2Repository(aws.NewClientWithEC2(api))
+
+	result, err := repo.List(context.Background(),
+		repository.TagFilter("Env", "prod"), repository.FilterRunning)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(result) != 2 || result[0].InstanceID != "i-1" || result[1].InstanceID != "i-2" {
+		t.Errorf("List() = %+v, want i-1 and i-2 from both pages", result)
+	}
+	if len(api.calls) != 2 || api.calls[1].NextToken == nil {
+		t.Fatalf("DescribeInstances calls = %+v, want two pages", api.calls)
+	}
 
-		result, err := repo.List(context.Background())
-		if err != nil {
-			t.Errorf("unexpected error: %v", err)
-		}
-		if result != nil {
-			t.Error("expected nil result for unimplemented List")
-		}
-	})
+	filters := api.calls[0].Filters
+	if len(filters) != 2 {
+		t.Fatalf("Filters = %+v, want 2", filters)
+	}
+	if *filters[0].Name != "tag:Env" || filters[0].Values[0] != "prod" {
+		t.Errorf("Filters[0] = %+v, want tag:Env=prod", filters[0])
+	}
+	if *filters[1].Name != "instance-state-name" || filters[1].Values[0] != "running" {
+		t.Errorf("Filters[1] = %+v, want instance-state-name=running", filters[1])
+	}
 }
 
 func TestEC2Repository_Client(t *testing.T) {
@@ -22,6 +22,9 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/solomon-os/go-test/internal/errors"
 	"github.com/solomon-os/go-test/internal/models"
@@ -86,6 +89,11 @@ var (
 
 	// FilterStopped filters for stopped instances.
 	FilterStopped = NewFilter("instance-state-name", "stopped")
+
+	// FilterNotTerminated filters out terminated instances, which
+	// DescribeInstances keeps returning for a while after termination.
+	FilterNotTerminated = NewFilter("instance-state-name",
+		"pending", "running", "shutting-down", "stopping", "stopped")
 )
 
 // TagFilter creates a filter for a specific tag key-value pair.
@@ -93,6 +101,33 @@ func TagFilter(key, value string) Filter {
 	return NewFilter("tag:"+key, value)
 }
 
+// ParseFilter parses a filter of the form name=value[,value...], such as
+// tag:Env=prod or instance-type=t3.micro,t3.small.
+func ParseFilter(spec string) (Filter, error) {
+	name, values, ok := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || values == "" {
+		return Filter{}, fmt.Errorf("invalid filter %q: expected name=value[,value...]", spec)
+	}
+	return NewFilter(name, strings.Split(values, ",")...), nil
+}
+
+// VPCFilter creates a filter for instances in a specific VPC.
+func VPCFilter(vpcID string) Filter {
+	return NewFilter("vpc-id", vpcID)
+}
+
+// ExcludeTerminated returns filters with FilterNotTerminated added, unless
+// one of them already selects instances by state.
+func ExcludeTerminated(filters []Filter) []Filter {
+	for _, f := range filters {
+		if f.Name == FilterNotTerminated.Name {
+			return filters
+		}
+	}
+	return append(slices.Clip(filters), FilterNotTerminated)
+}
+
 // InstanceTypeFilter creates a filter for a specific instance type.
 func InstanceTypeFilter(instanceType string) Filter {
 	return NewFilter("instance-type", instanceType)
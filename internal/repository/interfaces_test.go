@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -46,6 +47,37 @@ func TestInstanceTypeFilter(t *testing.T) {
 	})
 }
 
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		spec       string
+		wantName   string
+		wantValues []string
+		wantErr    bool
+	}{
+		{spec: "tag:Env=prod", wantName: "tag:Env", wantValues: []string{"prod"}},
+		{spec: "instance-type=t3.micro,t3.small", wantName: "instance-type", wantValues: []string{"t3.micro", "t3.small"}},
+		{spec: "tag:Owner=a=b", wantName: "tag:Owner", wantValues: []string{"a=b"}},
+		{spec: "tag:Env", wantErr: true},
+		{spec: "=prod", wantErr: true},
+		{spec: "tag:Env=", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			f, err := ParseFilter(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if f.Name != tt.wantName || !slices.Equal(f.Values, tt.wantValues) {
+				t.Errorf("ParseFilter() = %+v, want %s=%v", f, tt.wantName, tt.wantValues)
+			}
+		})
+	}
+}
+
 func TestPredefinedFilters(t *testing.T) {
 	t.Run("FilterRunning has correct values", func(t *testing.T) {
 		if FilterRunning.Name != "instance-state-name" {
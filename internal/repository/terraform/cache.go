@@ -0,0 +1,90 @@
+package terraform
+
+import (
+	"context"
+	"sync"
+
+	"github.com/solomon-os/go-test/internal/models"
+	"github.com/solomon-os/go-test/internal/repository"
+)
+
+// stateCache holds the instances parsed from a state source and implements
+// the read side of repository.TerraformRepository. Repositories embed it
+// and differ only in how their Refresh loads state.
+type stateCache struct {
+	mu        sync.RWMutex
+	instances map[string]*models.EC2Instance
+	loaded    bool
+
+	// refresh loads the instances on first access.
+	refresh func(ctx context.Context) error
+}
+
+// GetByID retrieves a single instance from Terraform state.
+func (c *stateCache) GetByID(ctx context.Context, instanceID string) (*models.EC2Instance, error) {
+	if instanceID == "" {
+		return nil, repository.ErrInvalidID
+	}
+
+	// Ensure data is loaded
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	inst, ok := c.instances[instanceID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return inst, nil
+}
+
+// GetAll retrieves all EC2 instances from Terraform state.
+func (c *stateCache) GetAll(ctx context.Context) (map[string]*models.EC2Instance, error) {
+	// Ensure data is loaded
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Return a copy to prevent external mutation
+	result := make(map[string]*models.EC2Instance, len(c.instances))
+	for k, v := range c.instances {
+		result[k] = v
+	}
+	return result, nil
+}
+
+// IsLoaded returns whether the repository data has been loaded.
+func (c *stateCache) IsLoaded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.loaded
+}
+
+// InstanceCount returns the number of instances in the repository.
+func (c *stateCache) InstanceCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.instances)
+}
+
+// store replaces the cached instances.
+func (c *stateCache) store(instances map[string]*models.EC2Instance) {
+	c.mu.Lock()
+	c.instances = instances
+	c.loaded = true
+	c.mu.Unlock()
+}
+
+// ensureLoaded loads data if not already loaded.
+func (c *stateCache) ensureLoaded(ctx context.Context) error {
+	if c.IsLoaded() {
+		return nil
+	}
+	return c.refresh(ctx)
+}
@@ -0,0 +1,331 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/solomon-os/go-test/internal/logger"
+	"github.com/solomon-os/go-test/internal/models"
+	"github.com/solomon-os/go-test/internal/repository"
+	tf "github.com/solomon-os/go-test/internal/terraform"
+)
+
+// DefaultCloudBaseURL is the address of Terraform Cloud.
+const DefaultCloudBaseURL = "https://app.terraform.io"
+
+// defaultCloudPageSize is the number of workspaces requested per page.
+const defaultCloudPageSize = 100
+
+// errCloudNotFound is returned for a 404 response from the API.
+var errCloudNotFound = errors.New("not found")
+
+// CloudConfig selects the Terraform Cloud or Enterprise workspaces whose
+// current state is read.
+type CloudConfig struct {
+	// BaseURL is the address of Terraform Cloud or a Terraform Enterprise
+	// installation. Defaults to DefaultCloudBaseURL.
+	BaseURL string
+	// Organization owns the workspaces.
+	Organization string
+	// Workspace selects a single workspace by name.
+	Workspace string
+	// Tags selects every workspace carrying all of the tags. It is used
+	// when Workspace is empty.
+	Tags []string
+	// Token is a user or team API token.
+	Token string
+	// PageSize is the number of workspaces listed per request. Defaults to
+	// 100, the API maximum.
+	PageSize int
+}
+
+// Validate checks that the workspaces and credentials are configured.
+func (c CloudConfig) Validate() error {
+	switch {
+	case c.Organization == "":
+		return fmt.Errorf("terraform cloud requires an organization")
+	case c.Workspace == "" && len(c.Tags) == 0:
+		return fmt.Errorf("terraform cloud requires a workspace name or tags")
+	case c.Token == "":
+		return fmt.Errorf("terraform cloud requires an API token")
+	}
+	return nil
+}
+
+func (c CloudConfig) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultCloudBaseURL
+	}
+	return strings.TrimRight(c.BaseURL, "/")
+}
+
+// cloudWorkspace is a workspace resource of the Terraform Cloud API.
+type cloudWorkspace struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Name string `json:"name"`
+	} `json:"attributes"`
+}
+
+// cloudStateVersion is a state version resource of the Terraform Cloud API.
+type cloudStateVersion struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Serial                 int64  `json:"serial"`
+		HostedStateDownloadURL string `json:"hosted-state-download-url"`
+	} `json:"attributes"`
+}
+
+// cloudPagination is the pagination metadata of a list response.
+type cloudPagination struct {
+	CurrentPage int  `json:"current-page"`
+	NextPage    *int `json:"next-page"`
+	TotalPages  int  `json:"total-pages"`
+}
+
+// CloudRepository implements repository.TerraformRepository for the current
+// state versions of Terraform Cloud or Enterprise workspaces. With Tags, the
+// instances of every matching workspace are combined as local states are,
+// each recording its workspace and the workspace URL as its state file.
+type CloudRepository struct {
+	stateCache
+
+	client *http.Client
+	parser tf.StateParser
+	config CloudConfig
+
+	// refreshMu serializes refreshes and guards workspaces and conflicts,
+	// the names of the workspaces read by the last refresh and the
+	// instances found in more than one of them.
+	refreshMu  sync.Mutex
+	workspaces []string
+	conflicts  []models.StateConflict
+}
+
+// NewCloudRepository creates a repository reading state through the
+// Terraform Cloud API. A nil client uses http.DefaultClient.
+func NewCloudRepository(client *http.Client, parser tf.StateParser, cfg CloudConfig) *CloudRepository {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r := &CloudRepository{
+		client: client,
+		parser: parser,
+		config: cfg,
+	}
+	r.refresh = r.Refresh
+	return r
+}
+
+// Refresh lists the selected workspaces and downloads the current state
+// version of each. Workspaces without state are skipped.
+func (r *CloudRepository) Refresh(ctx context.Context) error {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	if err := r.config.Validate(); err != nil {
+		return err
+	}
+
+	workspaces, err := r.listWorkspaces(ctx)
+	if err != nil {
+		logger.Error("failed to list workspaces", "organization", r.config.Organization, "error", err)
+		return err
+	}
+
+	names := make([]string, 0, len(workspaces))
+	refs := make([]models.StateRef, 0, len(workspaces))
+	states := make([]map[string]*models.EC2Instance, 0, len(workspaces))
+	for _, ws := range workspaces {
+		wsInstances, err := r.workspaceInstances(ctx, ws)
+		if err != nil {
+			return err
+		}
+		names = append(names, ws.Attributes.Name)
+		refs = append(refs, models.StateRef{
+			StateFile: r.workspaceURL(ws.Attributes.Name),
+			Workspace: ws.Attributes.Name,
+		})
+		states = append(states, wsInstances)
+	}
+	instances, conflicts := tf.MergeStates(refs, states)
+
+	logger.Info("read state from terraform cloud",
+		"organization", r.config.Organization,
+		"workspaces", len(names),
+		"instance_count", len(instances),
+	)
+	r.workspaces = names
+	r.conflicts = conflicts
+	r.store(instances)
+	return nil
+}
+
+// listWorkspaces returns the configured workspace, or every workspace
+// carrying the configured tags.
+func (r *CloudRepository) listWorkspaces(ctx context.Context) ([]cloudWorkspace, error) {
+	org := url.PathEscape(r.config.Organization)
+	if r.config.Workspace != "" {
+		var resp struct {
+			Data cloudWorkspace `json:"data"`
+		}
+		path := "/api/v2/organizations/" + org + "/workspaces/" + url.PathEscape(r.config.Workspace)
+		if err := r.get(ctx, path, nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to read workspace %s: %w", r.config.Workspace, err)
+		}
+		return []cloudWorkspace{resp.Data}, nil
+	}
+
+	pageSize := r.config.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultCloudPageSize
+	}
+
+	var workspaces []cloudWorkspace
+	for page := 1; ; {
+		query := url.Values{
+			"search[tags]": {strings.Join(r.config.Tags, ",")},
+			"page[number]": {strconv.Itoa(page)},
+			"page[size]":   {strconv.Itoa(pageSize)},
+		}
+		var resp struct {
+			Data []cloudWorkspace `json:"data"`
+			Meta struct {
+				Pagination cloudPagination `json:"pagination"`
+			} `json:"meta"`
+		}
+		if err := r.get(ctx, "/api/v2/organizations/"+org+"/workspaces", query, &resp); err != nil {
+			return nil, fmt.Errorf("failed to list workspaces: %w", err)
+		}
+		workspaces = append(workspaces, resp.Data...)
+
+		next := resp.Meta.Pagination.NextPage
+		if next == nil || *next <= page {
+			break
+		}
+		page = *next
+	}
+
+	logger.Debug("listed workspaces", "tags", r.config.Tags, "count", len(workspaces))
+	return workspaces, nil
+}
+
+// workspaceInstances downloads and parses the current state version of a
+// workspace. It returns no instances if the workspace has no state yet.
+func (r *CloudRepository) workspaceInstances(
+	ctx context.Context,
+	ws cloudWorkspace,
+) (map[string]*models.EC2Instance, error) {
+	var resp struct {
+		Data cloudStateVersion `json:"data"`
+	}
+	path := "/api/v2/workspaces/" + url.PathEscape(ws.ID) + "/current-state-version"
+	err := r.get(ctx, path, nil, &resp)
+	if errors.Is(err, errCloudNotFound) {
+		logger.Warn("workspace has no state", "workspace", ws.Attributes.Name)
+		return map[string]*models.EC2Instance{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read current state version of %s: %w", ws.Attributes.Name, err)
+	}
+
+	data, err := r.download(ctx, resp.Data.Attributes.HostedStateDownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download state of %s: %w", ws.Attributes.Name, err)
+	}
+	logger.Debug("downloaded workspace state",
+		"workspace", ws.Attributes.Name,
+		"state_version", resp.Data.ID,
+		"serial", resp.Data.Attributes.Serial,
+	)
+	return r.parser.ParseStateJSON(data)
+}
+
+// get sends an authenticated API request and decodes the JSON response.
+func (r *CloudRepository) get(ctx context.Context, path string, query url.Values, out any) error {
+	u := r.config.baseURL() + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	data, err := r.download(ctx, u)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", path, err)
+	}
+	return nil
+}
+
+// download GETs u with the API token.
+func (r *CloudRepository) download(ctx context.Context, u string) ([]byte, error) {
+	if u == "" {
+		return nil, fmt.Errorf("no download URL")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.config.Token)
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errCloudNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Workspaces returns the names of the workspaces read by the last refresh.
+func (r *CloudRepository) Workspaces() []string {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	return append([]string(nil), r.workspaces...)
+}
+
+// Conflicts returns the instances found in more than one workspace by the
+// last refresh. Each is kept from the first workspace listed.
+func (r *CloudRepository) Conflicts() []models.StateConflict {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	return append([]models.StateConflict(nil), r.conflicts...)
+}
+
+// FilePath returns the URL of the workspace, or of the organization's
+// workspace list when workspaces are selected by tag.
+func (r *CloudRepository) FilePath() string {
+	if r.config.Workspace != "" {
+		return r.workspaceURL(r.config.Workspace)
+	}
+	return r.workspacesURL() + "?tag=" + strings.Join(r.config.Tags, ",")
+}
+
+// workspacesURL returns the URL of the organization's workspace list.
+func (r *CloudRepository) workspacesURL() string {
+	return r.config.baseURL() + "/app/" + r.config.Organization + "/workspaces"
+}
+
+// workspaceURL returns the URL of the named workspace.
+func (r *CloudRepository) workspaceURL(name string) string {
+	return r.workspacesURL() + "/" + name
+}
+
+// Verify interface compliance at compile time.
+var _ repository.TerraformRepository = (*CloudRepository)(nil)
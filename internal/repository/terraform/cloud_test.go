@@ -0,0 +1,229 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/solomon-os/go-test/internal/models"
+	tf "github.com/solomon-os/go-test/internal/terraform"
+)
+
+func cloudTestState(id, instanceType string) string {
+	return fmt.Sprintf(`{
+		"version": 4,
+		"resources": [{
+			"mode": "managed",
+			"type": "aws_instance",
+			"name": "web",
+			"instances": [{"attributes": {"id": %q, "instance_type": %q}}]
+		}]
+	}`, id, instanceType)
+}
+
+type fakeCloudWorkspace struct {
+	id    string
+	name  string
+	tags  []string
+	state string
+}
+
+// newFakeCloud serves the subset of the Terraform Cloud API used by
+// CloudRepository for organization "acme".
+func newFakeCloud(t *testing.T, workspaces []fakeCloudWorkspace) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/v2/organizations/acme/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		tags := strings.Split(r.URL.Query().Get("search[tags]"), ",")
+		page, _ := strconv.Atoi(r.URL.Query().Get("page[number]"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("page[size]"))
+
+		var matched []string
+		for _, ws := range workspaces {
+			if hasAllTags(ws.tags, tags) {
+				matched = append(matched, fmt.Sprintf(`{"id": %q, "attributes": {"name": %q}}`, ws.id, ws.name))
+			}
+		}
+
+		start, end := (page-1)*size, page*size
+		next := "null"
+		if end < len(matched) {
+			next = strconv.Itoa(page + 1)
+		} else {
+			end = len(matched)
+		}
+		fmt.Fprintf(w, `{"data": [%s], "meta": {"pagination": {"current-page": %d, "next-page": %s}}}`,
+			strings.Join(matched[start:end], ","), page, next)
+	})
+
+	for _, ws := range workspaces {
+		mux.HandleFunc("/api/v2/organizations/acme/workspaces/"+ws.name, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data": {"id": %q, "attributes": {"name": %q}}}`, ws.id, ws.name)
+		})
+		mux.HandleFunc("/api/v2/workspaces/"+ws.id+"/current-state-version", func(w http.ResponseWriter, r *http.Request) {
+			if ws.state == "" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintf(w, `{"data": {"id": "sv-%s", "attributes": {"serial": 3, "hosted-state-download-url": %q}}}`,
+				ws.id, server.URL+"/archivist/"+ws.id)
+		})
+		mux.HandleFunc("/archivist/"+ws.id, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(ws.state))
+		})
+	}
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func hasAllTags(have, want []string) bool {
+	for _, tag := range want {
+		found := false
+		for _, h := range have {
+			found = found || h == tag
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCloudRepository_Workspace(t *testing.T) {
+	server := newFakeCloud(t, []fakeCloudWorkspace{
+		{id: "ws-1", name: "prod", state: cloudTestState("i-123", "t3.micro")},
+		{id: "ws-2", name: "staging", state: cloudTestState("i-456", "t3.small")},
+	})
+
+	repo := NewCloudRepository(server.Client(), tf.NewParser(), CloudConfig{
+		BaseURL:      server.URL,
+		Organization: "acme",
+		Workspace:    "prod",
+		Token:        "secret",
+	})
+
+	all, err := repo.GetAll(context.Background())
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if len(all) != 1 || all["i-123"] == nil {
+		t.Fatalf("GetAll() = %v, want only i-123", all)
+	}
+	if got := repo.FilePath(); got != server.URL+"/app/acme/workspaces/prod" {
+		t.Errorf("FilePath() = %s", got)
+	}
+	if inst := all["i-123"]; inst.Workspace != "prod" || inst.StateFile != repo.FilePath() {
+		t.Errorf("i-123 read from %s (%s), want the prod workspace", inst.StateFile, inst.Workspace)
+	}
+}
+
+func TestCloudRepository_Conflicts(t *testing.T) {
+	server := newFakeCloud(t, []fakeCloudWorkspace{
+		{id: "ws-1", name: "blue", tags: []string{"web"}, state: cloudTestState("i-shared", "t3.micro")},
+		{id: "ws-2", name: "green", tags: []string{"web"}, state: cloudTestState("i-shared", "t3.large")},
+	})
+
+	repo := NewCloudRepository(server.Client(), tf.NewParser(), CloudConfig{
+		BaseURL:      server.URL,
+		Organization: "acme",
+		Tags:         []string{"web"},
+		Token:        "secret",
+	})
+	if err := repo.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	inst, err := repo.GetByID(context.Background(), "i-shared")
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if inst.Workspace != "blue" || inst.InstanceType != "t3.micro" {
+		t.Errorf("i-shared kept from %s (%s), want the blue workspace", inst.Workspace, inst.InstanceType)
+	}
+
+	conflicts := repo.Conflicts()
+	if len(conflicts) != 1 || conflicts[0].InstanceID != "i-shared" {
+		t.Fatalf("Conflicts() = %+v, want i-shared", conflicts)
+	}
+	want := []models.StateRef{
+		{StateFile: server.URL + "/app/acme/workspaces/blue", Workspace: "blue"},
+		{StateFile: server.URL + "/app/acme/workspaces/green", Workspace: "green"},
+	}
+	if !reflect.DeepEqual(conflicts[0].States, want) {
+		t.Errorf("conflicting states = %+v, want %+v", conflicts[0].States, want)
+	}
+}
+
+func TestCloudRepository_Tags(t *testing.T) {
+	workspaces := []fakeCloudWorkspace{
+		{id: "ws-1", name: "web-prod", tags: []string{"app:web", "prod"}, state: cloudTestState("i-1", "t3.micro")},
+		{id: "ws-2", name: "web-staging", tags: []string{"app:web"}, state: cloudTestState("i-2", "t3.micro")},
+		{id: "ws-3", name: "api-prod", tags: []string{"prod"}, state: cloudTestState("i-3", "t3.micro")},
+		{id: "ws-4", name: "db-prod", tags: []string{"prod"}, state: cloudTestState("i-4", "t3.micro")},
+		{id: "ws-5", name: "new-prod", tags: []string{"prod"}},
+	}
+	server := newFakeCloud(t, workspaces)
+
+	repo := NewCloudRepository(server.Client(), tf.NewParser(), CloudConfig{
+		BaseURL:      server.URL,
+		Organization: "acme",
+		Tags:         []string{"prod"},
+		Token:        "secret",
+		PageSize:     2,
+	})
+
+	if err := repo.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	got := repo.Workspaces()
+	sort.Strings(got)
+	want := []string{"api-prod", "db-prod", "new-prod", "web-prod"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Workspaces() = %v, want %v", got, want)
+	}
+	if repo.InstanceCount() != 3 {
+		t.Errorf("InstanceCount() = %d, want 3", repo.InstanceCount())
+	}
+}
+
+func TestCloudRepository_Errors(t *testing.T) {
+	server := newFakeCloud(t, []fakeCloudWorkspace{
+		{id: "ws-1", name: "prod", state: cloudTestState("i-123", "t3.micro")},
+	})
+
+	tests := []struct {
+		name   string
+		config CloudConfig
+	}{
+		{"invalid config", CloudConfig{BaseURL: server.URL, Organization: "acme", Token: "secret"}},
+		{"wrong token", CloudConfig{BaseURL: server.URL, Organization: "acme", Workspace: "prod", Token: "wrong"}},
+		{"unknown workspace", CloudConfig{BaseURL: server.URL, Organization: "acme", Workspace: "dev", Token: "secret"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewCloudRepository(server.Client(), tf.NewParser(), tt.config)
+			if err := repo.Refresh(context.Background()); err == nil {
+				t.Error("Refresh() expected error")
+			}
+		})
+	}
+}
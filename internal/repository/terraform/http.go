@@ -0,0 +1,369 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/solomon-os/go-test/internal/logger"
+	"github.com/solomon-os/go-test/internal/repository"
+	tf "github.com/solomon-os/go-test/internal/terraform"
+)
+
+// Default lock methods of Terraform's http backend.
+const (
+	defaultLockMethod   = "LOCK"
+	defaultUnlockMethod = "UNLOCK"
+)
+
+// unlockTimeout bounds the release of a lock taken by the lock check. The
+// release does not inherit the refresh's cancellation, so that a cancelled
+// refresh does not leave the state locked.
+const unlockTimeout = 10 * time.Second
+
+// HTTPConfig locates state served over Terraform's http backend protocol,
+// mirroring the arguments of the backend "http" block.
+type HTTPConfig struct {
+	// Address is the URL of the state.
+	Address string
+	// LockAddress is the URL used to lock the state.
+	LockAddress string
+	// CheckLock makes Refresh probe LockAddress and warn if the state is
+	// locked, e.g. by a running apply. The http backend protocol has no way
+	// to read a lock without taking it, so the probe acquires the lock and
+	// releases it straight away: a terraform apply starting at the same
+	// moment may fail with "state locked", and if the release fails the
+	// lock is left held until it is force-unlocked. It is off by default.
+	CheckLock bool
+	// UnlockAddress is the URL used to unlock the state. Defaults to
+	// LockAddress.
+	UnlockAddress string
+	// LockMethod and UnlockMethod are the HTTP methods of the lock
+	// endpoints. They default to LOCK and UNLOCK.
+	LockMethod   string
+	UnlockMethod string
+	// Username and Password enable HTTP basic authentication.
+	Username string
+	Password string
+	// Token is sent as a bearer token in the Authorization header.
+	Token string
+}
+
+// HTTPConfigFromBackend builds an HTTPConfig from a backend "http" block.
+func HTTPConfigFromBackend(backend tf.BackendConfig) (HTTPConfig, error) {
+	if backend.Type != "http" {
+		return HTTPConfig{}, fmt.Errorf("backend %q is not an http backend", backend.Type)
+	}
+
+	c := backend.Config
+	return HTTPConfig{
+		Address:       c["address"],
+		LockAddress:   c["lock_address"],
+		UnlockAddress: c["unlock_address"],
+		LockMethod:    c["lock_method"],
+		UnlockMethod:  c["unlock_method"],
+		Username:      c["username"],
+		Password:      c["password"],
+	}, nil
+}
+
+// Merge returns c with every empty field taken from other.
+func (c HTTPConfig) Merge(other HTTPConfig) HTTPConfig {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&c.Address, other.Address)
+	fill(&c.LockAddress, other.LockAddress)
+	fill(&c.UnlockAddress, other.UnlockAddress)
+	fill(&c.LockMethod, other.LockMethod)
+	fill(&c.UnlockMethod, other.UnlockMethod)
+	fill(&c.Username, other.Username)
+	fill(&c.Password, other.Password)
+	fill(&c.Token, other.Token)
+	c.CheckLock = c.CheckLock || other.CheckLock
+	return c
+}
+
+// Validate checks that the state can be located and that at most one
+// authentication method is configured.
+func (c HTTPConfig) Validate() error {
+	if c.Address == "" {
+		return fmt.Errorf("http backend requires an address")
+	}
+	if c.Token != "" && (c.Username != "" || c.Password != "") {
+		return fmt.Errorf("http backend accepts either basic auth or a bearer token, not both")
+	}
+	return nil
+}
+
+// LockInfo describes a state lock, as exchanged with the lock endpoint.
+type LockInfo struct {
+	ID        string    `json:"ID"`
+	Operation string    `json:"Operation"`
+	Info      string    `json:"Info"`
+	Who       string    `json:"Who"`
+	Version   string    `json:"Version"`
+	Created   time.Time `json:"Created"`
+	Path      string    `json:"Path"`
+}
+
+// HTTPRepository implements repository.TerraformRepository for state served
+// by Terraform's http backend, such as GitLab-managed state.
+type HTTPRepository struct {
+	stateCache
+
+	client *http.Client
+	parser tf.StateParser
+	config HTTPConfig
+
+	// refreshMu serializes refreshes and guards the fields below, which
+	// describe the state last read.
+	refreshMu sync.Mutex
+	serial    int64
+	lineage   string
+	lock      *LockInfo
+}
+
+// NewHTTPRepository creates a repository reading state over HTTP. A nil
+// client uses http.DefaultClient.
+func NewHTTPRepository(client *http.Client, parser tf.StateParser, cfg HTTPConfig) *HTTPRepository {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r := &HTTPRepository{
+		client: client,
+		parser: parser,
+		config: cfg,
+	}
+	r.refresh = r.Refresh
+	return r
+}
+
+// Refresh downloads and parses the state. An empty state, or one that does
+// not exist yet, yields no instances. If CheckLock is set and the state is
+// locked, a warning is logged since the state may be mid-apply.
+func (r *HTTPRepository) Refresh(ctx context.Context) error {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	if err := r.config.Validate(); err != nil {
+		return err
+	}
+
+	r.lock = nil
+	if r.config.CheckLock && r.config.LockAddress != "" {
+		r.lock = r.checkLock(ctx)
+	}
+
+	data, err := r.fetchState(ctx)
+	if err != nil {
+		logger.Error("failed to fetch state over HTTP", "address", r.config.Address, "error", err)
+		return err
+	}
+	if data == nil {
+		logger.Warn("empty state at http backend address", "address", r.config.Address)
+		r.serial, r.lineage = 0, ""
+		r.store(nil)
+		return nil
+	}
+
+	var meta struct {
+		Serial  int64  `json:"serial"`
+		Lineage string `json:"lineage"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return fmt.Errorf("failed to parse state from %s: %w", r.config.Address, err)
+	}
+
+	instances, err := r.parser.ParseStateJSON(data)
+	if err != nil {
+		return err
+	}
+
+	r.serial, r.lineage = meta.Serial, meta.Lineage
+	logger.Info("read state over HTTP",
+		"address", r.config.Address,
+		"serial", meta.Serial,
+		"lineage", meta.Lineage,
+	)
+	r.store(instances)
+	return nil
+}
+
+// fetchState GETs the state. It returns nil data if the state is empty or,
+// as the http backend protocol signals with 404, does not exist yet.
+func (r *HTTPRepository) fetchState(ctx context.Context) ([]byte, error) {
+	resp, err := r.do(ctx, http.MethodGet, r.config.Address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch state from %s: %w", r.config.Address, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusNotFound:
+		return nil, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("failed to fetch state from %s: access denied (%s)", r.config.Address, resp.Status)
+	default:
+		return nil, fmt.Errorf("failed to fetch state from %s: unexpected status %s", r.config.Address, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state from %s: %w", r.config.Address, err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// checkLock probes the lock endpoint by acquiring the lock and releasing it
+// straight away, as the protocol offers no read-only check; this briefly
+// holds the lock (see HTTPConfig.CheckLock). It returns the holder's lock
+// info if the state is already locked. Lock endpoint failures are logged
+// and otherwise ignored, as the state can still be read.
+func (r *HTTPRepository) checkLock(ctx context.Context) *LockInfo {
+	probe := newLockInfo(r.config.Address)
+	body, err := json.Marshal(probe)
+	if err != nil {
+		return nil
+	}
+
+	lockMethod := orDefault(r.config.LockMethod, defaultLockMethod)
+	resp, err := r.do(ctx, lockMethod, r.config.LockAddress, body)
+	if err != nil {
+		logger.Warn("failed to check state lock", "address", r.config.LockAddress, "error", err)
+		return nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		r.unlock(ctx, probe.ID, body)
+		return nil
+	case http.StatusConflict, http.StatusLocked:
+		held := &LockInfo{}
+		if data, err := io.ReadAll(resp.Body); err == nil {
+			_ = json.Unmarshal(data, held)
+		}
+		logger.Warn("state is locked, it may change while drift is detected",
+			"address", r.config.Address,
+			"lock_id", held.ID,
+			"operation", held.Operation,
+			"who", held.Who,
+			"created", held.Created,
+		)
+		return held
+	default:
+		logger.Debug("unexpected lock endpoint response", "address", r.config.LockAddress, "status", resp.Status)
+		return nil
+	}
+}
+
+// unlock releases the lock with the given ID acquired by checkLock. It runs
+// even if ctx has been cancelled, bounded by unlockTimeout. A failure is
+// logged as an error with the lock ID, as the state stays locked until it
+// is force-unlocked.
+func (r *HTTPRepository) unlock(ctx context.Context, id string, body []byte) {
+	address := orDefault(r.config.UnlockAddress, r.config.LockAddress)
+	method := orDefault(r.config.UnlockMethod, defaultUnlockMethod)
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), unlockTimeout)
+	defer cancel()
+
+	resp, err := r.do(ctx, method, address, body)
+	if err != nil {
+		logger.Error("failed to release state lock, run terraform force-unlock",
+			"address", address, "lock_id", id, "error", err)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logger.Error("failed to release state lock, run terraform force-unlock",
+			"address", address, "lock_id", id, "status", resp.Status)
+	}
+}
+
+// do sends an authenticated request.
+func (r *HTTPRepository) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	switch {
+	case r.config.Token != "":
+		req.Header.Set("Authorization", "Bearer "+r.config.Token)
+	case r.config.Username != "" || r.config.Password != "":
+		req.SetBasicAuth(r.config.Username, r.config.Password)
+	}
+	return r.client.Do(req)
+}
+
+// Serial returns the serial of the state last read.
+func (r *HTTPRepository) Serial() int64 {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	return r.serial
+}
+
+// Lineage returns the lineage of the state last read.
+func (r *HTTPRepository) Lineage() string {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	return r.lineage
+}
+
+// Lock returns the lock held on the state when it was last read, or nil if
+// it was not locked or the lock was not checked.
+func (r *HTTPRepository) Lock() *LockInfo {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	return r.lock
+}
+
+// FilePath returns the address of the state.
+func (r *HTTPRepository) FilePath() string {
+	return r.config.Address
+}
+
+// newLockInfo returns the lock info sent when probing the lock endpoint.
+func newLockInfo(path string) *LockInfo {
+	who, _ := os.Hostname()
+	return &LockInfo{
+		ID:        strconv.FormatInt(time.Now().UnixNano(), 16),
+		Operation: "OperationTypeInvalid",
+		Info:      "drift-detector lock check",
+		Who:       who,
+		Created:   time.Now().UTC(),
+		Path:      path,
+	}
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// Verify interface compliance at compile time.
+var _ repository.TerraformRepository = (*HTTPRepository)(nil)
@@ -0,0 +1,288 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	tf "github.com/solomon-os/go-test/internal/terraform"
+)
+
+const httpTestState = `{
+	"version": 4,
+	"serial": 42,
+	"lineage": "8d3c4a4e-1b2f-4c7a-9f1e-5a6b7c8d9e0f",
+	"resources": [
+		{
+			"mode": "managed",
+			"type": "aws_instance",
+			"name": "web",
+			"instances": [{"attributes": {"id": "i-123", "instance_type": "t3.micro"}}]
+		}
+	]
+}`
+
+// fakeStateServer implements the state and lock endpoints of Terraform's
+// http backend protocol.
+type fakeStateServer struct {
+	mu    sync.Mutex
+	state string
+	auth  func(r *http.Request) bool
+	held  *LockInfo
+	locks int
+}
+
+func (f *fakeStateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.auth != nil && !f.auth(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/missing":
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodGet && r.URL.Path == "/state":
+		if f.state == "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_, _ = w.Write([]byte(f.state))
+	case r.Method == "LOCK" && r.URL.Path == "/lock":
+		f.locks++
+		if f.held != nil {
+			w.WriteHeader(http.StatusLocked)
+			_ = json.NewEncoder(w).Encode(f.held)
+			return
+		}
+		f.held = &LockInfo{}
+		_ = json.NewDecoder(r.Body).Decode(f.held)
+	case r.Method == "UNLOCK" && r.URL.Path == "/lock":
+		f.held = nil
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeStateServer) heldLock() *LockInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.held
+}
+
+func TestHTTPRepository_Refresh(t *testing.T) {
+	fake := &fakeStateServer{state: httpTestState}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	repo := NewHTTPRepository(server.Client(), tf.NewParser(), HTTPConfig{Address: server.URL + "/state"})
+
+	inst, err := repo.GetByID(context.Background(), "i-123")
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if inst.InstanceType != "t3.micro" {
+		t.Errorf("InstanceType = %s, want t3.micro", inst.InstanceType)
+	}
+	if repo.Serial() != 42 {
+		t.Errorf("Serial() = %d, want 42", repo.Serial())
+	}
+	if repo.Lineage() != "8d3c4a4e-1b2f-4c7a-9f1e-5a6b7c8d9e0f" {
+		t.Errorf("Lineage() = %s", repo.Lineage())
+	}
+	if repo.FilePath() != server.URL+"/state" {
+		t.Errorf("FilePath() = %s", repo.FilePath())
+	}
+}
+
+func TestHTTPRepository_EmptyState(t *testing.T) {
+	server := httptest.NewServer(&fakeStateServer{})
+	defer server.Close()
+
+	repo := NewHTTPRepository(server.Client(), tf.NewParser(), HTTPConfig{Address: server.URL + "/state"})
+	if err := repo.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if !repo.IsLoaded() || repo.InstanceCount() != 0 {
+		t.Errorf("expected loaded empty repository, got loaded=%v count=%d", repo.IsLoaded(), repo.InstanceCount())
+	}
+}
+
+func TestHTTPRepository_MissingState(t *testing.T) {
+	server := httptest.NewServer(&fakeStateServer{})
+	defer server.Close()
+
+	repo := NewHTTPRepository(server.Client(), tf.NewParser(), HTTPConfig{Address: server.URL + "/missing"})
+	if err := repo.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v, want a state that does not exist read as empty", err)
+	}
+	if !repo.IsLoaded() || repo.InstanceCount() != 0 {
+		t.Errorf("expected loaded empty repository, got loaded=%v count=%d", repo.IsLoaded(), repo.InstanceCount())
+	}
+}
+
+func TestHTTPRepository_Auth(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  HTTPConfig
+		wantErr bool
+	}{
+		{
+			name:   "basic auth",
+			config: HTTPConfig{Username: "gitlab-ci-token", Password: "secret"},
+		},
+		{
+			name:   "bearer token",
+			config: HTTPConfig{Token: "secret"},
+		},
+		{
+			name:    "wrong password",
+			config:  HTTPConfig{Username: "gitlab-ci-token", Password: "wrong"},
+			wantErr: true,
+		},
+		{
+			name:    "no credentials",
+			wantErr: true,
+		},
+	}
+
+	fake := &fakeStateServer{
+		state: httpTestState,
+		auth: func(r *http.Request) bool {
+			if user, pass, ok := r.BasicAuth(); ok {
+				return user == "gitlab-ci-token" && pass == "secret"
+			}
+			return r.Header.Get("Authorization") == "Bearer secret"
+		},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.config
+			cfg.Address = server.URL + "/state"
+			repo := NewHTTPRepository(server.Client(), tf.NewParser(), cfg)
+
+			err := repo.Refresh(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Refresh() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPRepository_Lock(t *testing.T) {
+	fake := &fakeStateServer{state: httpTestState}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	repo := NewHTTPRepository(server.Client(), tf.NewParser(), HTTPConfig{
+		Address:     server.URL + "/state",
+		LockAddress: server.URL + "/lock",
+		CheckLock:   true,
+	})
+	ctx := context.Background()
+
+	t.Run("not checked by default", func(t *testing.T) {
+		repo := NewHTTPRepository(server.Client(), tf.NewParser(), HTTPConfig{
+			Address:     server.URL + "/state",
+			LockAddress: server.URL + "/lock",
+		})
+		if err := repo.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		if fake.locks != 0 {
+			t.Errorf("lock requests = %d, want 0", fake.locks)
+		}
+	})
+
+	t.Run("unlocked state", func(t *testing.T) {
+		if err := repo.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+		if repo.Lock() != nil {
+			t.Errorf("Lock() = %+v, want nil", repo.Lock())
+		}
+		if fake.heldLock() != nil {
+			t.Error("lock acquired by the check was not released")
+		}
+	})
+
+	t.Run("state locked by an apply", func(t *testing.T) {
+		fake.mu.Lock()
+		fake.held = &LockInfo{ID: "abc", Operation: "OperationTypeApply", Who: "ci@runner"}
+		fake.mu.Unlock()
+
+		if err := repo.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+		lock := repo.Lock()
+		if lock == nil {
+			t.Fatal("Lock() = nil, want the apply's lock")
+		}
+		if lock.Operation != "OperationTypeApply" || lock.Who != "ci@runner" {
+			t.Errorf("Lock() = %+v", lock)
+		}
+		if held := fake.heldLock(); held == nil || held.ID != "abc" {
+			t.Error("the apply's lock must not be released")
+		}
+		if repo.InstanceCount() != 1 {
+			t.Errorf("InstanceCount() = %d, want 1", repo.InstanceCount())
+		}
+	})
+}
+
+func TestHTTPConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  HTTPConfig
+		wantErr bool
+	}{
+		{"address only", HTTPConfig{Address: "https://example.com/state"}, false},
+		{"missing address", HTTPConfig{}, true},
+		{
+			"basic auth and token",
+			HTTPConfig{Address: "https://example.com/state", Username: "u", Password: "p", Token: "t"},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPConfigFromBackend(t *testing.T) {
+	cfg, err := HTTPConfigFromBackend(tf.BackendConfig{
+		Type: "http",
+		Config: map[string]string{
+			"address":      "https://gitlab.example.com/api/v4/projects/1/terraform/state/prod",
+			"lock_address": "https://gitlab.example.com/api/v4/projects/1/terraform/state/prod/lock",
+			"lock_method":  "POST",
+			"username":     "deploy",
+		},
+	})
+	if err != nil {
+		t.Fatalf("HTTPConfigFromBackend() error = %v", err)
+	}
+	merged := HTTPConfig{Password: "secret", CheckLock: true}.Merge(cfg)
+	if merged.Address == "" || merged.LockMethod != "POST" || merged.Username != "deploy" || merged.Password != "secret" || !merged.CheckLock {
+		t.Errorf("unexpected config %+v", merged)
+	}
+
+	if _, err := HTTPConfigFromBackend(tf.BackendConfig{Type: "s3"}); err == nil {
+		t.Error("HTTPConfigFromBackend() expected error for non-http backend")
+	}
+}
@@ -3,10 +3,13 @@ package terraform
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/solomon-os/go-test/internal/models"
 	"github.com/solomon-os/go-test/internal/repository"
+	tf "github.com/solomon-os/go-test/internal/terraform"
 )
 
 // mockParser implements terraform.StateParser for testing.
@@ -200,3 +203,32 @@ func TestRepository_IsLoaded(t *testing.T) {
 		}
 	})
 }
+
+func TestRepository_RefreshDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	writeConfig("web.tf", `resource "aws_instance" "web" { ami = "ami-1" }`)
+	repo := NewRepository(tf.NewParser(), dir)
+
+	result, err := repo.GetAll(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 1 {
+		t.Fatalf("expected 1 instance, got %d", len(result))
+	}
+
+	writeConfig("api.tf", `resource "aws_instance" "api" { ami = "ami-2" }`)
+	if err := repo.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.InstanceCount() != 2 {
+		t.Errorf("expected 2 instances after refresh, got %d", repo.InstanceCount())
+	}
+}
@@ -0,0 +1,226 @@
+package terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/solomon-os/go-test/internal/logger"
+	"github.com/solomon-os/go-test/internal/repository"
+	tf "github.com/solomon-os/go-test/internal/terraform"
+)
+
+// defaultWorkspaceKeyPrefix is the S3 backend's default prefix for the
+// state of non-default workspaces.
+const defaultWorkspaceKeyPrefix = "env:"
+
+// S3Client defines the subset of the S3 API used by S3Repository.
+type S3Client interface {
+	GetObject(
+		ctx context.Context,
+		params *s3.GetObjectInput,
+		optFns ...func(*s3.Options),
+	) (*s3.GetObjectOutput, error)
+}
+
+// S3Config locates a state object, mirroring the arguments of Terraform's
+// backend "s3" block.
+type S3Config struct {
+	// Bucket is the name of the bucket holding the state.
+	Bucket string
+	// Key is the object key of the default workspace's state.
+	Key string
+	// Region is the region of the bucket.
+	Region string
+	// Workspace selects a non-default workspace. Empty means "default".
+	Workspace string
+	// WorkspaceKeyPrefix is the prefix of non-default workspace state
+	// objects. Defaults to "env:".
+	WorkspaceKeyPrefix string
+	// Endpoint overrides the S3 endpoint, e.g. for an S3-compatible store.
+	Endpoint string
+	// UsePathStyle addresses the bucket in the URL path instead of the host.
+	UsePathStyle bool
+}
+
+// S3ConfigFromBackend builds an S3Config from a backend "s3" block.
+func S3ConfigFromBackend(backend tf.BackendConfig) (S3Config, error) {
+	if backend.Type != "s3" {
+		return S3Config{}, fmt.Errorf("backend %q is not an s3 backend", backend.Type)
+	}
+
+	c := backend.Config
+	cfg := S3Config{
+		Bucket:             c["bucket"],
+		Key:                c["key"],
+		Region:             c["region"],
+		WorkspaceKeyPrefix: c["workspace_key_prefix"],
+		Endpoint:           c["endpoint"],
+	}
+	for _, name := range []string{"use_path_style", "force_path_style"} {
+		if v, ok := c[name]; ok {
+			cfg.UsePathStyle, _ = strconv.ParseBool(v)
+		}
+	}
+	return cfg, nil
+}
+
+// Merge returns c with every empty field taken from other.
+func (c S3Config) Merge(other S3Config) S3Config {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&c.Bucket, other.Bucket)
+	fill(&c.Key, other.Key)
+	fill(&c.Region, other.Region)
+	fill(&c.Workspace, other.Workspace)
+	fill(&c.WorkspaceKeyPrefix, other.WorkspaceKeyPrefix)
+	fill(&c.Endpoint, other.Endpoint)
+	c.UsePathStyle = c.UsePathStyle || other.UsePathStyle
+	return c
+}
+
+// ObjectKey returns the key of the state object for the configured
+// workspace, following the S3 backend's <prefix>/<workspace>/<key> layout.
+func (c S3Config) ObjectKey() string {
+	if c.Workspace == "" || c.Workspace == "default" {
+		return c.Key
+	}
+	prefix := c.WorkspaceKeyPrefix
+	if prefix == "" {
+		prefix = defaultWorkspaceKeyPrefix
+	}
+	return prefix + "/" + c.Workspace + "/" + c.Key
+}
+
+// Validate checks that the state object can be located.
+func (c S3Config) Validate() error {
+	if c.Bucket == "" || c.Key == "" {
+		return fmt.Errorf("s3 backend requires bucket and key")
+	}
+	return nil
+}
+
+// NewS3Client creates an S3 client for cfg using the default AWS credential
+// chain. A configured Endpoint replaces the regional AWS endpoint.
+func NewS3Client(ctx context.Context, cfg S3Config, optFns ...func(*s3.Options)) (*s3.Client, error) {
+	logger.Debug("creating S3 client", "region", cfg.Region, "endpoint", cfg.Endpoint)
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(cfg.Region))
+	if err != nil {
+		logger.Error("failed to load AWS config", "error", err, "region", cfg.Region)
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	opts := append([]func(*s3.Options){func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = awssdk.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+	}}, optFns...)
+	return s3.NewFromConfig(awsCfg, opts...), nil
+}
+
+// S3Repository implements repository.TerraformRepository for state stored
+// by Terraform's S3 backend. Refresh sends the ETag of the last download so
+// an unchanged state object is not downloaded and parsed again.
+type S3Repository struct {
+	stateCache
+
+	client S3Client
+	parser tf.StateParser
+	config S3Config
+
+	// refreshMu serializes refreshes; etag is the ETag of the state object
+	// currently cached.
+	refreshMu sync.Mutex
+	etag      string
+}
+
+// NewS3Repository creates a repository reading state from S3.
+func NewS3Repository(client S3Client, parser tf.StateParser, cfg S3Config) *S3Repository {
+	r := &S3Repository{
+		client: client,
+		parser: parser,
+		config: cfg,
+	}
+	r.refresh = r.Refresh
+	return r
+}
+
+// Refresh downloads the state object unless it is unchanged since the last
+// download.
+func (r *S3Repository) Refresh(ctx context.Context) error {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	if err := r.config.Validate(); err != nil {
+		return err
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: awssdk.String(r.config.Bucket),
+		Key:    awssdk.String(r.config.ObjectKey()),
+	}
+	if r.etag != "" && r.IsLoaded() {
+		input.IfNoneMatch = awssdk.String(r.etag)
+	}
+
+	logger.Debug("fetching state from S3", "location", r.FilePath())
+	output, err := r.client.GetObject(ctx, input)
+	if err != nil {
+		if isNotModified(err) {
+			logger.Debug("state unchanged since last download", "location", r.FilePath(), "etag", r.etag)
+			return nil
+		}
+		logger.Error("failed to fetch state from S3", "location", r.FilePath(), "error", err)
+		return fmt.Errorf("failed to fetch state from %s: %w", r.FilePath(), err)
+	}
+	defer func() { _ = output.Body.Close() }()
+
+	etag := awssdk.ToString(output.ETag)
+	if etag != "" && etag == r.etag && r.IsLoaded() {
+		logger.Debug("state unchanged since last download", "location", r.FilePath(), "etag", etag)
+		return nil
+	}
+
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read state from %s: %w", r.FilePath(), err)
+	}
+
+	instances, err := r.parser.ParseStateJSON(data)
+	if err != nil {
+		return err
+	}
+
+	r.store(instances)
+	r.etag = etag
+	return nil
+}
+
+// FilePath returns the location of the state object as an s3:// URL.
+func (r *S3Repository) FilePath() string {
+	return "s3://" + r.config.Bucket + "/" + r.config.ObjectKey()
+}
+
+// isNotModified reports whether err is S3's response to a conditional
+// request whose If-None-Match ETag still matches.
+func isNotModified(err error) bool {
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotModified
+}
+
+// Verify interface compliance at compile time.
+var _ repository.TerraformRepository = (*S3Repository)(nil)
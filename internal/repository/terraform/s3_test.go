@@ -0,0 +1,188 @@
+package terraform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	tf "github.com/solomon-os/go-test/internal/terraform"
+)
+
+const s3TestState = `{
+	"version": 4,
+	"resources": [
+		{
+			"mode": "managed",
+			"type": "aws_instance",
+			"name": "web",
+			"instances": [{"attributes": {"id": "i-123", "instance_type": "t3.micro"}}]
+		}
+	]
+}`
+
+// fakeS3 serves a single state object the way S3 does for path-style
+// GetObject requests, including conditional requests with If-None-Match.
+type fakeS3 struct {
+	path      string
+	body      string
+	etag      string
+	downloads atomic.Int32
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != f.path {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`))
+		return
+	}
+	if r.Header.Get("If-None-Match") == f.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	f.downloads.Add(1)
+	w.Header().Set("ETag", f.etag)
+	_, _ = w.Write([]byte(f.body))
+}
+
+func newTestS3Repository(t *testing.T, server *httptest.Server, cfg S3Config) *S3Repository {
+	t.Helper()
+	cfg.Region = "us-east-1"
+	cfg.Endpoint = server.URL
+	cfg.UsePathStyle = true
+
+	client, err := NewS3Client(context.Background(), cfg, func(o *s3.Options) {
+		o.Credentials = awssdk.AnonymousCredentials{}
+	})
+	if err != nil {
+		t.Fatalf("NewS3Client() error = %v", err)
+	}
+	return NewS3Repository(client, tf.NewParser(), cfg)
+}
+
+func TestS3Repository_Refresh(t *testing.T) {
+	fake := &fakeS3{path: "/states/prod/terraform.tfstate", body: s3TestState, etag: `"v1"`}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	repo := newTestS3Repository(t, server, S3Config{Bucket: "states", Key: "prod/terraform.tfstate"})
+	ctx := context.Background()
+
+	inst, err := repo.GetByID(ctx, "i-123")
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if inst.InstanceType != "t3.micro" {
+		t.Errorf("InstanceType = %s, want t3.micro", inst.InstanceType)
+	}
+
+	t.Run("unchanged state is not downloaded again", func(t *testing.T) {
+		if err := repo.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+		if n := fake.downloads.Load(); n != 1 {
+			t.Errorf("downloads = %d, want 1", n)
+		}
+		if repo.InstanceCount() != 1 {
+			t.Errorf("InstanceCount() = %d, want 1", repo.InstanceCount())
+		}
+	})
+
+	t.Run("changed state is downloaded", func(t *testing.T) {
+		fake.etag = `"v2"`
+		fake.body = `{"version": 4, "resources": []}`
+		if err := repo.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+		if n := fake.downloads.Load(); n != 2 {
+			t.Errorf("downloads = %d, want 2", n)
+		}
+		if repo.InstanceCount() != 0 {
+			t.Errorf("InstanceCount() = %d, want 0", repo.InstanceCount())
+		}
+	})
+}
+
+func TestS3Repository_Workspace(t *testing.T) {
+	fake := &fakeS3{path: "/states/env:/staging/app.tfstate", body: s3TestState, etag: `"v1"`}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	repo := newTestS3Repository(t, server, S3Config{
+		Bucket:    "states",
+		Key:       "app.tfstate",
+		Workspace: "staging",
+	})
+
+	if err := repo.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if repo.FilePath() != "s3://states/env:/staging/app.tfstate" {
+		t.Errorf("FilePath() = %s", repo.FilePath())
+	}
+}
+
+func TestS3Repository_MissingObject(t *testing.T) {
+	fake := &fakeS3{path: "/states/other.tfstate", etag: `"v1"`}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	repo := newTestS3Repository(t, server, S3Config{Bucket: "states", Key: "missing.tfstate"})
+	if err := repo.Refresh(context.Background()); err == nil {
+		t.Error("Refresh() expected error for missing object")
+	}
+	if repo.IsLoaded() {
+		t.Error("repository should not be loaded after a failed refresh")
+	}
+}
+
+func TestS3Config_ObjectKey(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  S3Config
+		want string
+	}{
+		{"default workspace", S3Config{Key: "a.tfstate"}, "a.tfstate"},
+		{"explicit default workspace", S3Config{Key: "a.tfstate", Workspace: "default"}, "a.tfstate"},
+		{"named workspace", S3Config{Key: "a.tfstate", Workspace: "dev"}, "env:/dev/a.tfstate"},
+		{
+			"custom prefix",
+			S3Config{Key: "a.tfstate", Workspace: "dev", WorkspaceKeyPrefix: "workspaces"},
+			"workspaces/dev/a.tfstate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.ObjectKey(); got != tt.want {
+				t.Errorf("ObjectKey() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestS3ConfigFromBackend(t *testing.T) {
+	cfg, err := S3ConfigFromBackend(tf.BackendConfig{
+		Type: "s3",
+		Config: map[string]string{
+			"bucket":         "states",
+			"key":            "prod.tfstate",
+			"region":         "eu-west-1",
+			"use_path_style": "true",
+		},
+	})
+	if err != nil {
+		t.Fatalf("S3ConfigFromBackend() error = %v", err)
+	}
+	if cfg.Bucket != "states" || cfg.Key != "prod.tfstate" || cfg.Region != "eu-west-1" || !cfg.UsePathStyle {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	if _, err := S3ConfigFromBackend(tf.BackendConfig{Type: "gcs"}); err == nil {
+		t.Error("S3ConfigFromBackend() expected error for non-s3 backend")
+	}
+}
@@ -0,0 +1,107 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+
+	"github.com/solomon-os/go-test/internal/logger"
+)
+
+var terraformBlockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "backend", LabelNames: []string{"type"}},
+	},
+}
+
+// BackendConfig is the backend block declared in a configuration's
+// terraform block, e.g. backend "s3" { bucket = "..." }.
+type BackendConfig struct {
+	// Type is the backend type, e.g. "s3". Empty if no backend is declared.
+	Type string
+	// Config holds the backend's primitive arguments as strings. Nested
+	// blocks and complex values are not included.
+	Config map[string]string
+}
+
+// ReadBackendConfig returns the backend declared in the configuration at
+// path, which may be a .tf or .tf.json file or a module directory. The
+// returned Type is empty when no backend is declared, including when path
+// is not a configuration, e.g. a state file.
+func ReadBackendConfig(path string) (BackendConfig, error) {
+	blocks, err := readConfigBlocks(path)
+	if err != nil {
+		return BackendConfig{}, err
+	}
+
+	for _, block := range blocks {
+		if block.Type != "terraform" {
+			continue
+		}
+		content, _, diags := block.Body.PartialContent(terraformBlockSchema)
+		if diags.HasErrors() {
+			return BackendConfig{}, fmt.Errorf("failed to decode terraform block: %s", diags.Error())
+		}
+		if len(content.Blocks) > 0 {
+			return decodeBackend(content.Blocks[0]), nil
+		}
+	}
+	return BackendConfig{}, nil
+}
+
+// readConfigBlocks reads the top-level blocks of a configuration file or
+// module directory.
+func readConfigBlocks(path string) (hcl.Blocks, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	if info.IsDir() {
+		files, err := configFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		return loadConfigFiles(files)
+	}
+	if !strings.HasSuffix(path, ".tf") && !isJSONConfig(path) {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HCL file: %w", err)
+	}
+	return decodeHCLFile(hclparse.NewParser(), data, path)
+}
+
+// decodeBackend reads the literal primitive arguments of a backend block.
+// Backend configuration cannot refer to variables, so no evaluation context
+// is needed.
+func decodeBackend(block *hcl.Block) BackendConfig {
+	cfg := BackendConfig{
+		Type:   block.Labels[0],
+		Config: make(map[string]string),
+	}
+
+	// Nested blocks such as assume_role produce diagnostics here; the
+	// attributes that could be decoded are still returned.
+	attrs, _ := block.Body.JustAttributes()
+	for name, attr := range attrs {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || val.IsNull() || !val.IsKnown() || !val.Type().IsPrimitiveType() {
+			logger.Debug("skipping backend argument", "backend", cfg.Type, "argument", name)
+			continue
+		}
+		str, err := convert.Convert(val, cty.String)
+		if err != nil {
+			continue
+		}
+		cfg.Config[name] = str.AsString()
+	}
+	return cfg
+}
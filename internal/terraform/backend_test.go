@@ -0,0 +1,78 @@
+package terraform
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadBackendConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"backend.tf": `
+terraform {
+  required_version = ">= 1.5"
+
+  backend "s3" {
+    bucket  = "states"
+    key     = "prod/terraform.tfstate"
+    region  = "us-west-2"
+    encrypt = true
+
+    assume_role {
+      role_arn = "arn:aws:iam::123456789012:role/state"
+    }
+  }
+}`,
+		"main.tf": `
+resource "aws_instance" "web" {}`,
+		"terraform.tfstate": `{"version": 4}`,
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		backend, err := ReadBackendConfig(tmpDir)
+		if err != nil {
+			t.Fatalf("ReadBackendConfig() error = %v", err)
+		}
+		if backend.Type != "s3" {
+			t.Fatalf("Type = %s, want s3", backend.Type)
+		}
+
+		want := map[string]string{
+			"bucket":  "states",
+			"key":     "prod/terraform.tfstate",
+			"region":  "us-west-2",
+			"encrypt": "true",
+		}
+		for name, value := range want {
+			if backend.Config[name] != value {
+				t.Errorf("Config[%s] = %s, want %s", name, backend.Config[name], value)
+			}
+		}
+	})
+
+	t.Run("file without backend", func(t *testing.T) {
+		backend, err := ReadBackendConfig(filepath.Join(tmpDir, "main.tf"))
+		if err != nil {
+			t.Fatalf("ReadBackendConfig() error = %v", err)
+		}
+		if backend.Type != "" {
+			t.Errorf("Type = %s, want empty", backend.Type)
+		}
+	})
+
+	t.Run("state file", func(t *testing.T) {
+		backend, err := ReadBackendConfig(filepath.Join(tmpDir, "terraform.tfstate"))
+		if err != nil {
+			t.Fatalf("ReadBackendConfig() error = %v", err)
+		}
+		if backend.Type != "" {
+			t.Errorf("Type = %s, want empty", backend.Type)
+		}
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		if _, err := ReadBackendConfig(filepath.Join(tmpDir, "missing")); err == nil {
+			t.Error("ReadBackendConfig() expected error for missing path")
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package terraform
+
+import (
+	"sort"
+
+	"github.com/solomon-os/go-test/internal/logger"
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+// AttachConfiguration pairs the instances of a state with their declarations
+// in HCL by resource address, setting Configuration on each state instance
+// so that drift is classified three ways. A state instance still recorded
+// under an address a moved block refactored away is paired with the
+// resource it moved to, and takes its address. It returns the configured
+// instances missing from the state, i.e. not applied yet, sorted by
+// address.
+func AttachConfiguration(state, config map[string]*models.EC2Instance) []*models.EC2Instance {
+	byAddress := make(map[string]*models.EC2Instance, len(config))
+	moved := make(map[string]*models.EC2Instance)
+	for _, inst := range config {
+		byAddress[inst.Address] = inst
+		for _, prev := range inst.PreviousAddresses {
+			moved[prev] = inst
+		}
+	}
+
+	attached := make(map[*models.EC2Instance]bool, len(config))
+	for id, inst := range state {
+		if cfg, ok := byAddress[inst.Address]; ok {
+			inst.Configuration = cfg
+			attached[cfg] = true
+			continue
+		}
+		cfg, ok := moved[inst.Address]
+		if !ok {
+			logger.Warn("instance not found in configuration",
+				"instance_id", id, "address", inst.Address)
+			continue
+		}
+		logger.Debug("instance moved", "instance_id", id, "from", inst.Address, "to", cfg.Address)
+		inst.MatchedBy = models.MatchedByMoved
+		inst.MovedFrom = inst.Address
+		inst.Address = cfg.Address
+		inst.Configuration = cfg
+		attached[cfg] = true
+	}
+
+	unapplied := make([]*models.EC2Instance, 0, len(config)-len(attached))
+	for _, cfg := range config {
+		if !attached[cfg] {
+			unapplied = append(unapplied, cfg)
+		}
+	}
+	sort.Slice(unapplied, func(i, j int) bool {
+		return unapplied[i].Address < unapplied[j].Address
+	})
+	for _, cfg := range unapplied {
+		logger.Warn("configured instance not found in state", "address", cfg.Address)
+	}
+	return unapplied
+}
@@ -0,0 +1,99 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+func TestAttachConfiguration(t *testing.T) {
+	parser := NewParser()
+	state, err := parser.ParseStateJSON([]byte(`{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "instances": [
+        {"index_key": 0, "attributes": {"id": "i-0", "instance_type": "t3.micro"}},
+        {"index_key": 1, "attributes": {"id": "i-1", "instance_type": "t3.micro"}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "legacy",
+      "instances": [{"attributes": {"id": "i-legacy", "instance_type": "t2.micro"}}]
+    }
+  ]
+}`))
+	if err != nil {
+		t.Fatalf("ParseStateJSON() error = %v", err)
+	}
+	config, err := parser.ParseHCL([]byte(`
+resource "aws_instance" "web" {
+  count         = 3
+  instance_type = "t3.large"
+}`), "main.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+
+	unapplied := AttachConfiguration(state, config)
+
+	if len(unapplied) != 1 || unapplied[0].Address != "aws_instance.web[2]" {
+		t.Errorf("AttachConfiguration() = %v, want aws_instance.web[2]", unapplied)
+	}
+	for _, id := range []string{"i-0", "i-1"} {
+		cfg := state[id].Configuration
+		if cfg == nil || cfg.Address != state[id].Address || cfg.InstanceType != "t3.large" {
+			t.Errorf("%s: Configuration = %+v, want %s from HCL", id, cfg, state[id].Address)
+		}
+	}
+	if state["i-legacy"].Configuration != nil {
+		t.Error("i-legacy: Configuration set for an instance missing from HCL")
+	}
+}
+
+func TestAttachConfiguration_Moved(t *testing.T) {
+	parser := NewParser()
+	state, err := parser.ParseStateJSON([]byte(`{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "server",
+      "instances": [{"attributes": {"id": "i-0aaa", "instance_type": "t3.micro"}}]
+    }
+  ]
+}`))
+	if err != nil {
+		t.Fatalf("ParseStateJSON() error = %v", err)
+	}
+	config, err := parser.ParseHCL([]byte(`
+moved {
+  from = aws_instance.server
+  to   = aws_instance.app
+}
+
+resource "aws_instance" "app" {
+  instance_type = "t3.large"
+}`), "main.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+
+	if unapplied := AttachConfiguration(state, config); len(unapplied) != 0 {
+		t.Errorf("AttachConfiguration() = %v, want none unapplied", unapplied)
+	}
+	inst := state["i-0aaa"]
+	if inst.Configuration == nil || inst.Address != "aws_instance.app" {
+		t.Fatalf("i-0aaa = %+v, want paired with aws_instance.app", inst)
+	}
+	if inst.MatchedBy != models.MatchedByMoved || inst.MovedFrom != "aws_instance.server" {
+		t.Errorf("MatchedBy = %q, MovedFrom = %q, want moved from aws_instance.server",
+			inst.MatchedBy, inst.MovedFrom)
+	}
+}
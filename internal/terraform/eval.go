@@ -0,0 +1,331 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+
+	"github.com/solomon-os/go-test/internal/logger"
+)
+
+var variableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "default"},
+		{Name: "type"},
+		{Name: "description"},
+		{Name: "sensitive"},
+		{Name: "nullable"},
+		{Name: "ephemeral"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "validation"},
+	},
+}
+
+// variable is an input variable declared by a variable block.
+type variable struct {
+	name       string
+	typ        cty.Type
+	defaults   *typeexpr.Defaults
+	def        cty.Value
+	hasDefault bool
+}
+
+// decodeVariable reads the type constraint and default value of a variable block.
+func decodeVariable(block *hcl.Block) (*variable, error) {
+	v := &variable{
+		name: block.Labels[0],
+		typ:  cty.DynamicPseudoType,
+	}
+
+	content, diags := block.Body.Content(variableSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to decode variable %s: %s", v.name, diags.Error())
+	}
+
+	if attr, ok := content.Attributes["type"]; ok {
+		typ, defaults, diags := typeexpr.TypeConstraintWithDefaults(attr.Expr)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid type for variable %s: %s", v.name, diags.Error())
+		}
+		v.typ = typ
+		v.defaults = defaults
+	}
+
+	if attr, ok := content.Attributes["default"]; ok {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid default for variable %s: %s", v.name, diags.Error())
+		}
+		val, err := v.convert(val)
+		if err != nil {
+			return nil, err
+		}
+		v.def = val
+		v.hasDefault = true
+	}
+
+	return v, nil
+}
+
+// convert applies the variable's type constraint and optional attribute defaults to val.
+func (v *variable) convert(val cty.Value) (cty.Value, error) {
+	if v.defaults != nil {
+		val = v.defaults.Apply(val)
+	}
+	if v.typ == cty.DynamicPseudoType {
+		return val, nil
+	}
+	converted, err := convert.Convert(val, v.typ)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("invalid value for variable %s: %w", v.name, err)
+	}
+	return converted, nil
+}
+
+// parseRaw interprets a value given on the command line. Variables with a
+// primitive or unspecified type take the string literally; complex types
+// are parsed as HCL expressions, matching Terraform's -var handling.
+func (v *variable) parseRaw(raw string) (cty.Value, error) {
+	if v.typ == cty.DynamicPseudoType || v.typ.IsPrimitiveType() {
+		return v.convert(cty.StringVal(raw))
+	}
+
+	expr, diags := hclsyntax.ParseExpression([]byte(raw), "<value for var."+v.name+">", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.NilVal, fmt.Errorf("invalid value for variable %s: %s", v.name, diags.Error())
+	}
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.NilVal, fmt.Errorf("invalid value for variable %s: %s", v.name, diags.Error())
+	}
+	return v.convert(val)
+}
+
+// resolveVariables computes the final value of each declared variable.
+// Sources are applied in Terraform's precedence order: defaults, then
+// var files (automatic files first, then explicit ones), then -var values.
+func (p *Parser) resolveVariables(
+	vars map[string]*variable,
+	varFiles []string,
+) (map[string]cty.Value, error) {
+	values := variableDefaults(vars)
+
+	for _, path := range varFiles {
+		if err := loadVarFile(path, vars, values); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, raw := range p.variables {
+		v, ok := vars[name]
+		if !ok {
+			logger.Warn("value given for undeclared variable", "variable", name)
+			continue
+		}
+		val, err := v.parseRaw(raw)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = val
+	}
+
+	warnUnsetVariables("", vars, values)
+	return values, nil
+}
+
+// declareVariables decodes every variable block in blocks.
+func declareVariables(blocks hcl.Blocks) (map[string]*variable, error) {
+	vars := make(map[string]*variable)
+	for _, block := range blocks {
+		if block.Type != "variable" {
+			continue
+		}
+		v, err := decodeVariable(block)
+		if err != nil {
+			return nil, err
+		}
+		vars[v.name] = v
+	}
+	return vars, nil
+}
+
+// variableDefaults returns the default value of each variable that has one.
+func variableDefaults(vars map[string]*variable) map[string]cty.Value {
+	values := make(map[string]cty.Value, len(vars))
+	for name, v := range vars {
+		if v.hasDefault {
+			values[name] = v.def
+		}
+	}
+	return values
+}
+
+// warnUnsetVariables logs variables that ended up without a value. Any
+// attribute that refers to them cannot be evaluated and is skipped.
+func warnUnsetVariables(module string, vars map[string]*variable, values map[string]cty.Value) {
+	for name := range vars {
+		if _, ok := values[name]; !ok {
+			logger.Warn("variable has no value, dependent attributes will be skipped",
+				"module", module, "variable", name)
+		}
+	}
+}
+
+// loadVarFile reads a .tfvars or .tfvars.json file into values.
+func loadVarFile(path string, vars map[string]*variable, values map[string]cty.Value) error {
+	logger.Debug("loading variable file", "path", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read variable file: %w", err)
+	}
+
+	parser := hclparse.NewParser()
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		file, diags = parser.ParseJSON(data, path)
+	} else {
+		file, diags = parser.ParseHCL(data, path)
+	}
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse variable file %s: %s", path, diags.Error())
+	}
+
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to decode variable file %s: %s", path, diags.Error())
+	}
+
+	for name, attr := range attrs {
+		v, ok := vars[name]
+		if !ok {
+			logger.Warn("variable file sets undeclared variable", "path", path, "variable", name)
+			continue
+		}
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return fmt.Errorf("invalid value for variable %s in %s: %s", name, path, diags.Error())
+		}
+		if values[name], err = v.convert(val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// autoVarFiles returns the variable files Terraform loads automatically from
+// dir: terraform.tfvars, terraform.tfvars.json, then *.auto.tfvars and
+// *.auto.tfvars.json in lexical order.
+func autoVarFiles(dir string) []string {
+	var files []string
+	for _, name := range []string{"terraform.tfvars", "terraform.tfvars.json"} {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return files
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, ".auto.tfvars") || strings.HasSuffix(name, ".auto.tfvars.json") {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	return files
+}
+
+// scope holds the named values visible to expressions within one module.
+type scope struct {
+	vars      map[string]cty.Value
+	locals    map[string]cty.Value
+	moduleDir string
+	rootDir   string
+	// resources are the values of the module's resources and data sources
+	// from the reference state, keyed by resource type or "data".
+	resources map[string]cty.Value
+}
+
+// evalLocals evaluates the locals blocks of a module into s.locals. Locals
+// may refer to each other, so evaluation repeats until no further local can
+// be resolved. Locals that never resolve (e.g. references to resources) are
+// left out.
+func (s *scope) evalLocals(blocks hcl.Blocks) error {
+	pending := make(map[string]*hcl.Attribute)
+	for _, block := range blocks {
+		if block.Type != "locals" {
+			continue
+		}
+		attrs, diags := block.Body.JustAttributes()
+		if diags.HasErrors() {
+			return fmt.Errorf("failed to decode locals: %s", diags.Error())
+		}
+		for name, attr := range attrs {
+			pending[name] = attr
+		}
+	}
+
+	s.locals = make(map[string]cty.Value, len(pending))
+	for progress := true; progress && len(pending) > 0; {
+		progress = false
+		ctx := s.evalContext()
+
+		names := make([]string, 0, len(pending))
+		for name := range pending {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			val, diags := pending[name].Expr.Value(ctx)
+			if diags.HasErrors() {
+				continue
+			}
+			s.locals[name] = val
+			delete(pending, name)
+			progress = true
+		}
+	}
+
+	for name := range pending {
+		logger.Debug("unable to evaluate local value", "local", name)
+	}
+
+	return nil
+}
+
+// evalContext builds the evaluation context for expressions in the module,
+// with the module's named values, any resources from the reference state,
+// and Terraform's built-in functions.
+func (s *scope) evalContext() *hcl.EvalContext {
+	variables := make(map[string]cty.Value, len(s.resources)+3)
+	for name, val := range s.resources {
+		variables[name] = val
+	}
+	variables["var"] = cty.ObjectVal(s.vars)
+	variables["local"] = cty.ObjectVal(s.locals)
+	variables["path"] = cty.ObjectVal(map[string]cty.Value{
+		"module": cty.StringVal(s.moduleDir),
+		"root":   cty.StringVal(s.rootDir),
+	})
+	return &hcl.EvalContext{
+		Functions: functions,
+		Variables: variables,
+	}
+}
@@ -0,0 +1,118 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// repetitionSchema selects the meta-arguments that expand a resource or
+// module block into multiple instances.
+var repetitionSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "count"},
+		{Name: "for_each"},
+	},
+}
+
+// errUnknownRepetition is returned by expandBlock when count or for_each
+// cannot be known without applying the configuration, such as when it
+// refers to another resource or an unset variable. The block is skipped
+// rather than rejected.
+var errUnknownRepetition = errors.New("value is not known until apply")
+
+// blockInstance is one instance of a resource or module block after count
+// and for_each have been expanded.
+type blockInstance struct {
+	// suffix is the instance key as it appears in the address, e.g. "[0]"
+	// or `["api"]`, or empty for a block without count or for_each.
+	suffix string
+	// ctx is the evaluation context for the instance's arguments, exposing
+	// count.index or each.key and each.value.
+	ctx *hcl.EvalContext
+}
+
+// expandBlock evaluates the count or for_each argument of a block and
+// returns one instance per element. A block with neither argument yields a
+// single instance evaluated in ctx. An unknown count or for_each yields
+// errUnknownRepetition; any other error means the argument is invalid.
+func expandBlock(count, forEach *hcl.Attribute, ctx *hcl.EvalContext) ([]blockInstance, error) {
+	switch {
+	case count != nil && forEach != nil:
+		return nil, fmt.Errorf("count and for_each cannot be used together")
+	case count != nil:
+		return expandCount(count, ctx)
+	case forEach != nil:
+		return expandForEach(forEach, ctx)
+	default:
+		return []blockInstance{{ctx: ctx}}, nil
+	}
+}
+
+func expandCount(attr *hcl.Attribute, ctx *hcl.EvalContext) ([]blockInstance, error) {
+	val := evalAttribute(attr, ctx)
+	if !val.IsWhollyKnown() {
+		return nil, fmt.Errorf("count: %w", errUnknownRepetition)
+	}
+	if val.IsNull() {
+		return nil, fmt.Errorf("count must not be null")
+	}
+	val, err := convert.Convert(val, cty.Number)
+	if err != nil {
+		return nil, fmt.Errorf("invalid count: %w", err)
+	}
+	var n int
+	if err := gocty.FromCtyValue(val, &n); err != nil || n < 0 {
+		return nil, fmt.Errorf("count must be a non-negative whole number")
+	}
+
+	instances := make([]blockInstance, n)
+	for i := range instances {
+		child := ctx.NewChild()
+		child.Variables = map[string]cty.Value{
+			"count": cty.ObjectVal(map[string]cty.Value{"index": cty.NumberIntVal(int64(i))}),
+		}
+		instances[i] = blockInstance{suffix: "[" + strconv.Itoa(i) + "]", ctx: child}
+	}
+	return instances, nil
+}
+
+func expandForEach(attr *hcl.Attribute, ctx *hcl.EvalContext) ([]blockInstance, error) {
+	val := evalAttribute(attr, ctx)
+	if !val.IsWhollyKnown() {
+		return nil, fmt.Errorf("for_each: %w", errUnknownRepetition)
+	}
+	if val.IsNull() {
+		return nil, fmt.Errorf("for_each must not be null")
+	}
+
+	ty := val.Type()
+	switch {
+	case ty.IsMapType() || ty.IsObjectType():
+	case ty.IsSetType() && ty.ElementType() == cty.String:
+	default:
+		return nil, fmt.Errorf("for_each must be a map or set of strings, got %s", ty.FriendlyName())
+	}
+
+	instances := make([]blockInstance, 0, val.LengthInt())
+	for it := val.ElementIterator(); it.Next(); {
+		key, value := it.Element()
+		if ty.IsSetType() {
+			key = value
+		}
+		child := ctx.NewChild()
+		child.Variables = map[string]cty.Value{
+			"each": cty.ObjectVal(map[string]cty.Value{"key": key, "value": value}),
+		}
+		instances = append(instances, blockInstance{
+			suffix: "[" + strconv.Quote(key.AsString()) + "]",
+			ctx:    child,
+		})
+	}
+	return instances, nil
+}
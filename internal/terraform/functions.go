@@ -0,0 +1,330 @@
+package terraform
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// functions are the Terraform built-in functions available to expressions.
+// Functions that read files, query the environment or are otherwise
+// provider-specific are not included; calls to them fail to evaluate.
+var functions = map[string]function.Function{
+	// String functions.
+	"chomp":       stdlib.ChompFunc,
+	"endswith":    endsWithFunc,
+	"format":      stdlib.FormatFunc,
+	"formatlist":  stdlib.FormatListFunc,
+	"indent":      stdlib.IndentFunc,
+	"join":        stdlib.JoinFunc,
+	"lower":       stdlib.LowerFunc,
+	"regex":       stdlib.RegexFunc,
+	"regexall":    stdlib.RegexAllFunc,
+	"replace":     replaceFunc,
+	"split":       stdlib.SplitFunc,
+	"startswith":  startsWithFunc,
+	"strcontains": strContainsFunc,
+	"strrev":      stdlib.ReverseFunc,
+	"substr":      stdlib.SubstrFunc,
+	"title":       stdlib.TitleFunc,
+	"trim":        stdlib.TrimFunc,
+	"trimprefix":  stdlib.TrimPrefixFunc,
+	"trimspace":   stdlib.TrimSpaceFunc,
+	"trimsuffix":  stdlib.TrimSuffixFunc,
+	"upper":       stdlib.UpperFunc,
+
+	// Collection functions.
+	"chunklist":       stdlib.ChunklistFunc,
+	"coalesce":        stdlib.CoalesceFunc,
+	"coalescelist":    stdlib.CoalesceListFunc,
+	"compact":         stdlib.CompactFunc,
+	"concat":          stdlib.ConcatFunc,
+	"contains":        stdlib.ContainsFunc,
+	"distinct":        stdlib.DistinctFunc,
+	"element":         stdlib.ElementFunc,
+	"flatten":         stdlib.FlattenFunc,
+	"index":           stdlib.IndexFunc,
+	"keys":            stdlib.KeysFunc,
+	"length":          lengthFunc,
+	"lookup":          stdlib.LookupFunc,
+	"merge":           stdlib.MergeFunc,
+	"range":           stdlib.RangeFunc,
+	"reverse":         stdlib.ReverseListFunc,
+	"setintersection": stdlib.SetIntersectionFunc,
+	"setproduct":      stdlib.SetProductFunc,
+	"setsubtract":     stdlib.SetSubtractFunc,
+	"setunion":        stdlib.SetUnionFunc,
+	"slice":           stdlib.SliceFunc,
+	"sort":            stdlib.SortFunc,
+	"values":          stdlib.ValuesFunc,
+	"zipmap":          stdlib.ZipmapFunc,
+
+	// Encoding functions.
+	"base64decode": base64DecodeFunc,
+	"base64encode": base64EncodeFunc,
+	"csvdecode":    stdlib.CSVDecodeFunc,
+	"jsondecode":   stdlib.JSONDecodeFunc,
+	"jsonencode":   stdlib.JSONEncodeFunc,
+	"urlencode":    urlEncodeFunc,
+
+	// Numeric functions.
+	"abs":      stdlib.AbsoluteFunc,
+	"ceil":     stdlib.CeilFunc,
+	"floor":    stdlib.FloorFunc,
+	"log":      stdlib.LogFunc,
+	"max":      stdlib.MaxFunc,
+	"min":      stdlib.MinFunc,
+	"parseint": stdlib.ParseIntFunc,
+	"pow":      stdlib.PowFunc,
+	"signum":   stdlib.SignumFunc,
+
+	// Network functions.
+	"cidrhost":    cidrHostFunc,
+	"cidrnetmask": cidrNetmaskFunc,
+	"cidrsubnet":  cidrSubnetFunc,
+
+	// Type conversion functions.
+	"can":      tryfunc.CanFunc,
+	"tobool":   stdlib.MakeToFunc(cty.Bool),
+	"tolist":   stdlib.MakeToFunc(cty.List(cty.DynamicPseudoType)),
+	"tomap":    stdlib.MakeToFunc(cty.Map(cty.DynamicPseudoType)),
+	"tonumber": stdlib.MakeToFunc(cty.Number),
+	"toset":    stdlib.MakeToFunc(cty.Set(cty.DynamicPseudoType)),
+	"tostring": stdlib.MakeToFunc(cty.String),
+	"try":      tryfunc.TryFunc,
+
+	// Functions whose result is only known when Terraform applies the
+	// configuration.
+	"timestamp": unknownFunc(cty.String),
+	"uuid":      unknownFunc(cty.String),
+}
+
+// unknownFunc returns a function whose result is an unknown value of type
+// ty, for functions such as timestamp() that Terraform evaluates at apply
+// time.
+func unknownFunc(ty cty.Type) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{},
+		Type:   function.StaticReturnType(ty),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.UnknownVal(retType), nil
+		},
+	})
+}
+
+// stringPredicateFunc returns a function of two strings returning a bool.
+func stringPredicateFunc(names [2]string, fn func(s, arg string) bool) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: names[0], Type: cty.String},
+			{Name: names[1], Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.Bool),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.BoolVal(fn(args[0].AsString(), args[1].AsString())), nil
+		},
+	})
+}
+
+var (
+	startsWithFunc  = stringPredicateFunc([2]string{"str", "prefix"}, strings.HasPrefix)
+	endsWithFunc    = stringPredicateFunc([2]string{"str", "suffix"}, strings.HasSuffix)
+	strContainsFunc = stringPredicateFunc([2]string{"str", "substr"}, strings.Contains)
+)
+
+// lengthFunc returns the length of a collection or, unlike the cty
+// function, the number of characters of a string.
+var lengthFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "value", Type: cty.DynamicPseudoType},
+	},
+	Type: function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if args[0].Type() == cty.String {
+			return stdlib.Strlen(args[0])
+		}
+		return stdlib.Length(args[0])
+	},
+})
+
+// replaceFunc replaces substr in str, treating substr as a regular
+// expression if it is wrapped in slashes, as Terraform does.
+var replaceFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "str", Type: cty.String},
+		{Name: "substr", Type: cty.String},
+		{Name: "replace", Type: cty.String},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		str, substr, replace := args[0].AsString(), args[1].AsString(), args[2].AsString()
+		if len(substr) > 1 && strings.HasPrefix(substr, "/") && strings.HasSuffix(substr, "/") {
+			re, err := regexp.Compile(substr[1 : len(substr)-1])
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+			return cty.StringVal(re.ReplaceAllString(str, replace)), nil
+		}
+		return cty.StringVal(strings.ReplaceAll(str, substr, replace)), nil
+	},
+})
+
+var base64EncodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "str", Type: cty.String}},
+	Type:   function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return cty.StringVal(base64.StdEncoding.EncodeToString([]byte(args[0].AsString()))), nil
+	},
+})
+
+var base64DecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "str", Type: cty.String}},
+	Type:   function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		decoded, err := base64.StdEncoding.DecodeString(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("failed to decode base64 data: %w", err)
+		}
+		if !utf8.Valid(decoded) {
+			return cty.UnknownVal(cty.String), fmt.Errorf("decoded base64 data is not valid UTF-8")
+		}
+		return cty.StringVal(string(decoded)), nil
+	},
+})
+
+var urlEncodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "str", Type: cty.String}},
+	Type:   function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return cty.StringVal(url.QueryEscape(args[0].AsString())), nil
+	},
+})
+
+var cidrSubnetFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "prefix", Type: cty.String},
+		{Name: "newbits", Type: cty.Number},
+		{Name: "netnum", Type: cty.Number},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		newbits, err := intArg(args[1], "newbits")
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+		netnum, err := intArg(args[2], "netnum")
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+		subnet, err := cidrSubnet(args[0].AsString(), newbits, netnum)
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+		return cty.StringVal(subnet), nil
+	},
+})
+
+var cidrHostFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "prefix", Type: cty.String},
+		{Name: "hostnum", Type: cty.Number},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		hostnum, err := intArg(args[1], "hostnum")
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+		host, err := cidrHost(args[0].AsString(), hostnum)
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+		return cty.StringVal(host), nil
+	},
+})
+
+var cidrNetmaskFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "prefix", Type: cty.String}},
+	Type:   function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		_, network, err := net.ParseCIDR(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("invalid CIDR expression: %w", err)
+		}
+		if network.IP.To4() == nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("cidrnetmask only supports IPv4 prefixes")
+		}
+		return cty.StringVal(net.IP(network.Mask).String()), nil
+	},
+})
+
+// intArg converts a whole number argument to an int64.
+func intArg(val cty.Value, name string) (int64, error) {
+	n, acc := val.AsBigFloat().Int64()
+	if acc != big.Exact {
+		return 0, fmt.Errorf("%s must be a whole number", name)
+	}
+	return n, nil
+}
+
+// cidrSubnet calculates the netnum'th subnet of prefix with newbits
+// additional prefix bits, e.g. cidrSubnet("10.0.0.0/16", 8, 2) is
+// "10.0.2.0/24".
+func cidrSubnet(prefix string, newbits, netnum int64) (string, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return "", fmt.Errorf("invalid CIDR expression: %w", err)
+	}
+	ones, bits := network.Mask.Size()
+	if newbits < 0 || int64(ones)+newbits > int64(bits) {
+		return "", fmt.Errorf("insufficient address space to extend prefix of %d by %d", ones, newbits)
+	}
+	if netnum < 0 || big.NewInt(netnum).Cmp(new(big.Int).Lsh(big.NewInt(1), uint(newbits))) >= 0 {
+		return "", fmt.Errorf("prefix extension of %d does not accommodate a subnet numbered %d", newbits, netnum)
+	}
+
+	length := ones + int(newbits)
+	ip := new(big.Int).SetBytes(network.IP)
+	ip.Or(ip, new(big.Int).Lsh(big.NewInt(netnum), uint(bits-length)))
+	subnet := net.IPNet{IP: bigToIP(ip, len(network.IP)), Mask: net.CIDRMask(length, bits)}
+	return subnet.String(), nil
+}
+
+// cidrHost calculates the address of host number hostnum within prefix.
+// Negative numbers count back from the end of the range.
+func cidrHost(prefix string, hostnum int64) (string, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return "", fmt.Errorf("invalid CIDR expression: %w", err)
+	}
+	ones, bits := network.Mask.Size()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+
+	num := big.NewInt(hostnum)
+	if hostnum < 0 {
+		num.Add(num, size)
+	}
+	if num.Sign() < 0 || num.Cmp(size) >= 0 {
+		return "", fmt.Errorf("prefix of %d does not accommodate a host numbered %d", ones, hostnum)
+	}
+
+	ip := new(big.Int).SetBytes(network.IP)
+	ip.Or(ip, num)
+	return bigToIP(ip, len(network.IP)).String(), nil
+}
+
+// bigToIP converts n to an IP address of size bytes.
+func bigToIP(n *big.Int, size int) net.IP {
+	ip := make(net.IP, size)
+	return n.FillBytes(ip)
+}
@@ -0,0 +1,62 @@
+package terraform
+
+import (
+	"testing"
+)
+
+func TestCIDRSubnet(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		newbits int64
+		netnum  int64
+		want    string
+		wantErr bool
+	}{
+		{prefix: "10.0.0.0/16", newbits: 8, netnum: 2, want: "10.0.2.0/24"},
+		{prefix: "10.0.0.0/16", newbits: 4, netnum: 15, want: "10.0.240.0/20"},
+		{prefix: "172.16.0.0/12", newbits: 4, netnum: 2, want: "172.18.0.0/16"},
+		{prefix: "fd00:fd12:3456:7890::/56", newbits: 16, netnum: 162, want: "fd00:fd12:3456:7800:a200::/72"},
+		{prefix: "10.0.0.0/16", newbits: 8, netnum: 256, wantErr: true},
+		{prefix: "10.0.0.0/30", newbits: 4, netnum: 0, wantErr: true},
+		{prefix: "not-a-cidr", newbits: 8, netnum: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := cidrSubnet(tt.prefix, tt.newbits, tt.netnum)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("cidrSubnet(%s, %d, %d) error = %v, wantErr %v",
+				tt.prefix, tt.newbits, tt.netnum, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("cidrSubnet(%s, %d, %d) = %s, want %s",
+				tt.prefix, tt.newbits, tt.netnum, got, tt.want)
+		}
+	}
+}
+
+func TestCIDRHost(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		hostnum int64
+		want    string
+		wantErr bool
+	}{
+		{prefix: "10.12.112.0/20", hostnum: 16, want: "10.12.112.16"},
+		{prefix: "10.12.112.0/20", hostnum: 268, want: "10.12.113.12"},
+		{prefix: "10.0.0.0/24", hostnum: -2, want: "10.0.0.254"},
+		{prefix: "fd00:fd12:3456:7890:00a2::/72", hostnum: 34, want: "fd00:fd12:3456:7890::22"},
+		{prefix: "10.0.0.0/24", hostnum: 256, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := cidrHost(tt.prefix, tt.hostnum)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("cidrHost(%s, %d) error = %v, wantErr %v", tt.prefix, tt.hostnum, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("cidrHost(%s, %d) = %s, want %s", tt.prefix, tt.hostnum, got, tt.want)
+		}
+	}
+}
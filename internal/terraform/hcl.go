@@ -3,6 +3,8 @@ package terraform
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
@@ -20,49 +22,216 @@ func (p *Parser) ParseHCLFile(filePath string) (map[string]*models.EC2Instance,
 		return nil, fmt.Errorf("failed to read HCL file: %w", err)
 	}
 
-	return p.ParseHCL(data, filePath)
+	varFiles := append(autoVarFiles(filepath.Dir(filePath)), p.varFiles...)
+	return p.parseHCL(data, filePath, varFiles)
 }
 
+// ParseHCL parses HCL content. Variables are resolved from their defaults,
+// any var files passed with WithVarFiles and values passed with WithVariables.
 func (p *Parser) ParseHCL(data []byte, filename string) (map[string]*models.EC2Instance, error) {
+	return p.parseHCL(data, filename, p.varFiles)
+}
+
+// ParseHCLDir parses every *.tf and *.tf.json file in dir together as one
+// root module, as Terraform does. Resources declared more than once across
+// the files are reported as errors.
+func (p *Parser) ParseHCLDir(dir string) (map[string]*models.EC2Instance, error) {
+	logger.Debug("reading Terraform module directory", "path", dir)
+	files, err := configFiles(dir)
+	if err != nil {
+		logger.Error("failed to list configuration files", "path", dir, "error", err)
+		return nil, err
+	}
+	if len(files) == 0 {
+		logger.Error("no configuration files found", "path", dir)
+		return nil, fmt.Errorf("no Terraform configuration files found in %s", dir)
+	}
+
+	blocks, err := loadConfigFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	varFiles := append(autoVarFiles(dir), p.varFiles...)
+	return p.parseBlocks(blocks, dir, dir, varFiles)
+}
+
+func (p *Parser) parseHCL(
+	data []byte,
+	filename string,
+	varFiles []string,
+) (map[string]*models.EC2Instance, error) {
 	logger.Debug("parsing HCL content", "filename", filename, "bytes", len(data))
+	blocks, err := decodeHCLFile(hclparse.NewParser(), data, filename)
+	if err != nil {
+		return nil, err
+	}
+	return p.parseBlocks(blocks, filename, filepath.Dir(filename), varFiles)
+}
+
+// loadConfigFiles reads and decodes files, returning their top-level blocks
+// in order.
+func loadConfigFiles(files []string) (hcl.Blocks, error) {
 	parser := hclparse.NewParser()
-	file, diags := parser.ParseHCL(data, filename)
+	var blocks hcl.Blocks
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logger.Error("failed to read HCL file", "path", path, "error", err)
+			return nil, fmt.Errorf("failed to read HCL file: %w", err)
+		}
+		fileBlocks, err := decodeHCLFile(parser, data, path)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, fileBlocks...)
+	}
+	return blocks, nil
+}
+
+// tfJSONExt is the extension of configuration files written in the JSON
+// syntax, such as those synthesized by CDK for Terraform.
+const tfJSONExt = ".tf.json"
+
+// tfvarsJSONExt is the extension of variable files written in the JSON
+// syntax.
+const tfvarsJSONExt = ".tfvars.json"
+
+// isJSONConfig reports whether path is a JSON-syntax configuration file.
+func isJSONConfig(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), tfJSONExt)
+}
+
+// isJSONVarFile reports whether path is a JSON-syntax variable file.
+func isJSONVarFile(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), tfvarsJSONExt)
+}
+
+// decodeHCLFile parses a single configuration file, using the JSON syntax
+// for .tf.json files and native syntax otherwise, and returns its top-level
+// blocks. Variable files are not configuration and are rejected.
+func decodeHCLFile(parser *hclparse.Parser, data []byte, filename string) (hcl.Blocks, error) {
+	if isJSONVarFile(filename) {
+		return nil, fmt.Errorf("%s is a variable file, not a configuration file", filename)
+	}
+
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if isJSONConfig(filename) {
+		file, diags = parser.ParseJSON(data, filename)
+	} else {
+		file, diags = parser.ParseHCL(data, filename)
+	}
 	if diags.HasErrors() {
 		logger.Error("failed to parse HCL", "filename", filename, "error", diags.Error())
 		return nil, fmt.Errorf("failed to parse HCL: %s", diags.Error())
 	}
 
-	instances := make(map[string]*models.EC2Instance)
 	content, diags := file.Body.Content(terraformSchema)
 	if diags.HasErrors() {
 		logger.Error("failed to decode HCL content", "filename", filename, "error", diags.Error())
 		return nil, fmt.Errorf("failed to decode HCL content: %s", diags.Error())
 	}
+	return content.Blocks, nil
+}
+
+// parseBlocks evaluates the top-level blocks of a root module located in
+// dir and extracts its aws_instance resources, including those declared in
+// local child modules. source names the file or directory for logging.
+func (p *Parser) parseBlocks(
+	blocks hcl.Blocks,
+	source, dir string,
+	varFiles []string,
+) (map[string]*models.EC2Instance, error) {
+	vars, err := declareVariables(blocks)
+	if err != nil {
+		logger.Error("failed to evaluate variables", "source", source, "error", err)
+		return nil, err
+	}
+
+	values, err := p.resolveVariables(vars, varFiles)
+	if err != nil {
+		logger.Error("failed to evaluate variables", "source", source, "error", err)
+		return nil, err
+	}
+
+	refs, err := p.loadReferenceState()
+	if err != nil {
+		logger.Error("failed to load reference state", "source", source, "error", err)
+		return nil, err
+	}
+
+	root := &hclModule{blocks: blocks, dir: dir, rootDir: dir, refs: refs}
+	instances := make(map[string]*models.EC2Instance)
+	if err := p.parseModule(root, values, instances); err != nil {
+		logger.Error("failed to parse HCL configuration", "source", source, "error", err)
+		return nil, err
+	}
+
+	logger.Info("parsed HCL configuration", "source", source, "instance_count", len(instances))
+	return instances, nil
+}
 
-	for _, block := range content.Blocks {
-		if block.Type != "resource" {
+// configFiles lists the configuration files of the module in dir in lexical
+// order. Override files are not merged and are skipped with a warning.
+func configFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
 			continue
 		}
-
-		if len(block.Labels) < 2 || block.Labels[0] != "aws_instance" {
+		if !strings.HasSuffix(name, ".tf") && !isJSONConfig(name) {
+			continue
+		}
+		base := strings.TrimSuffix(strings.TrimSuffix(name, ".json"), ".tf")
+		if base == "override" || strings.HasSuffix(base, "_override") {
+			logger.Warn("skipping unsupported override file", "path", filepath.Join(dir, name))
 			continue
 		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files, nil
+}
 
-		resourceName := block.Labels[1]
-		instance, err := p.parseHCLResource(block, resourceName)
-		if err != nil {
-			logger.Error("failed to parse HCL resource", "resource", resourceName, "error", err)
-			return nil, fmt.Errorf("failed to parse resource %s: %w", resourceName, err)
+// checkDuplicateResources reports a resource, data source or module call
+// that is declared more than once, with the positions of both declarations.
+func checkDuplicateResources(blocks hcl.Blocks) error {
+	seen := make(map[string]hcl.Range)
+	for _, block := range blocks {
+		addr := blockAddress(block)
+		if addr == "" {
+			continue
 		}
 
-		if instance.InstanceID == "" {
-			instance.InstanceID = resourceName
+		if first, ok := seen[addr]; ok {
+			return NewParseError(block.DefRange.Filename, "hcl",
+				fmt.Errorf("duplicate declaration of %s: previously declared at %s",
+					addr, first.String())).
+				WithLineNumber(block.DefRange.Start.Line)
 		}
-		instances[instance.InstanceID] = instance
+		seen[addr] = block.DefRange
 	}
+	return nil
+}
 
-	logger.Info("parsed HCL file", "filename", filename, "instance_count", len(instances))
-	return instances, nil
+// blockAddress returns the module-relative address of a resource, data or
+// module block, or an empty string for any other block.
+func blockAddress(block *hcl.Block) string {
+	switch {
+	case block.Type == "resource" && len(block.Labels) == 2:
+		return block.Labels[0] + "." + block.Labels[1]
+	case block.Type == "data" && len(block.Labels) == 2:
+		return "data." + block.Labels[0] + "." + block.Labels[1]
+	case block.Type == "module" && len(block.Labels) == 1:
+		return "module." + block.Labels[0]
+	}
+	return ""
 }
 
 var terraformSchema = &hcl.BodySchema{
@@ -75,11 +244,19 @@ var terraformSchema = &hcl.BodySchema{
 		{Type: "output", LabelNames: []string{"name"}},
 		{Type: "locals"},
 		{Type: "module", LabelNames: []string{"name"}},
+		{Type: "import"},
+		{Type: "moved"},
+		{Type: "removed"},
+		{Type: "check", LabelNames: []string{"name"}},
 	},
 }
 
 var resourceSchema = &hcl.BodySchema{
 	Attributes: []hcl.AttributeSchema{
+		{Name: "count"},
+		{Name: "for_each"},
+		{Name: "provider"},
+		{Name: "depends_on"},
 		{Name: "ami"},
 		{Name: "instance_type"},
 		{Name: "availability_zone"},
@@ -94,6 +271,7 @@ var resourceSchema = &hcl.BodySchema{
 	},
 	Blocks: []hcl.BlockHeaderSchema{
 		{Type: "root_block_device"},
+		{Type: "lifecycle"},
 	},
 }
 
@@ -108,7 +286,11 @@ var rootBlockDeviceSchema = &hcl.BodySchema{
 	},
 }
 
-func (p *Parser) parseHCLResource(block *hcl.Block, name string) (*models.EC2Instance, error) {
+func (p *Parser) parseHCLResource(
+	block *hcl.Block,
+	name string,
+	ctx *hcl.EvalContext,
+) (*models.EC2Instance, error) {
 	content, diags := block.Body.Content(resourceSchema)
 	if diags.HasErrors() {
 		return nil, fmt.Errorf("failed to decode resource: %s", diags.Error())
@@ -118,18 +300,23 @@ func (p *Parser) parseHCLResource(block *hcl.Block, name string) (*models.EC2Ins
 		InstanceID:     name,
 		Tags:           make(map[string]string),
 		SecurityGroups: make([]string, 0),
+		Sources:        make(map[string]models.Source),
 	}
 
-	ctx := &hcl.EvalContext{}
 	p.applyHCLAttributes(instance, content.Attributes, ctx)
 
 	for _, blk := range content.Blocks {
-		if blk.Type == "root_block_device" {
-			rbd, err := p.parseRootBlockDevice(blk)
+		switch blk.Type {
+		case "root_block_device":
+			if err := p.parseRootBlockDevice(instance, blk, ctx); err != nil {
+				return nil, err
+			}
+		case "lifecycle":
+			ignore, err := decodeIgnoreChanges(blk)
 			if err != nil {
 				return nil, err
 			}
-			instance.RootBlockDevice = rbd
+			instance.IgnoreChanges = ignore
 		}
 	}
 
@@ -142,15 +329,18 @@ func (p *Parser) applyHCLAttributes(
 	ctx *hcl.EvalContext,
 ) {
 	for attrName, attr := range attrs {
-		val, diags := attr.Expr.Value(ctx)
-		if diags.HasErrors() {
-			continue
+		val := evalAttribute(attr, ctx)
+		if p.setInstanceAttribute(instance, attrName, val) {
+			path := attributePath(attrName)
+			instance.Sources[path] = sourceOf(attr.Range)
+			instance.Unknown = append(instance.Unknown, unknownPaths(path, val)...)
 		}
-		p.setInstanceAttribute(instance, attrName, val)
 	}
 }
 
-func (p *Parser) setInstanceAttribute(instance *models.EC2Instance, name string, val cty.Value) {
+// setInstanceAttribute sets the instance field of the aws_instance argument
+// name and reports whether name is one.
+func (p *Parser) setInstanceAttribute(instance *models.EC2Instance, name string, val cty.Value) bool {
 	switch name {
 	case "ami":
 		instance.AMI = valueToString(val)
@@ -172,26 +362,30 @@ func (p *Parser) setInstanceAttribute(instance *models.EC2Instance, name string,
 		instance.SecurityGroups = valueToStringSlice(val)
 	case "tags":
 		instance.Tags = valueToStringMap(val)
+	default:
+		return false
 	}
+	return true
 }
 
-func (p *Parser) parseRootBlockDevice(block *hcl.Block) (models.BlockDevice, error) {
+func (p *Parser) parseRootBlockDevice(
+	instance *models.EC2Instance,
+	block *hcl.Block,
+	ctx *hcl.EvalContext,
+) error {
 	content, diags := block.Body.Content(rootBlockDeviceSchema)
 	if diags.HasErrors() {
-		return models.BlockDevice{}, fmt.Errorf(
+		return fmt.Errorf(
 			"failed to decode root_block_device: %s",
 			diags.Error(),
 		)
 	}
 
-	bd := models.BlockDevice{}
-	ctx := &hcl.EvalContext{}
+	bd := &instance.RootBlockDevice
+	instance.Sources["root_block_device"] = sourceOf(block.DefRange)
 
 	for attrName, attr := range content.Attributes {
-		val, diags := attr.Expr.Value(ctx)
-		if diags.HasErrors() {
-			continue
-		}
+		val := evalAttribute(attr, ctx)
 
 		switch attrName {
 		case "volume_size":
@@ -207,9 +401,53 @@ func (p *Parser) parseRootBlockDevice(block *hcl.Block) (models.BlockDevice, err
 		case "throughput":
 			bd.Throughput = valueToInt(val)
 		}
+		path := "root_block_device." + attrName
+		instance.Sources[path] = sourceOf(attr.Range)
+		instance.Unknown = append(instance.Unknown, unknownPaths(path, val)...)
 	}
 
-	return bd, nil
+	return nil
+}
+
+// evalAttribute evaluates attr. An expression that cannot be evaluated, such
+// as a call to file or a reference to another resource, yields an unknown
+// value so the attribute is reported as unknown rather than compared as
+// empty.
+func evalAttribute(attr *hcl.Attribute, ctx *hcl.EvalContext) cty.Value {
+	val, diags := attr.Expr.Value(ctx)
+	if diags.HasErrors() {
+		logger.Debug("treating unevaluable HCL expression as unknown",
+			"attribute", attr.Name, "source", sourceOf(attr.Range), "error", diags.Error())
+		return cty.DynamicVal
+	}
+	return val
+}
+
+// sourceOf converts the range of an HCL construct to a models.Source.
+func sourceOf(rng hcl.Range) models.Source {
+	return models.Source{File: rng.Filename, Line: rng.Start.Line, Column: rng.Start.Column}
+}
+
+// unknownPaths returns the attribute paths of the parts of val that are not
+// known until apply: path itself or, for a known map or object, path.<key>
+// for each unknown element.
+func unknownPaths(path string, val cty.Value) []string {
+	if val.IsWhollyKnown() {
+		return nil
+	}
+	ty := val.Type()
+	if !val.IsKnown() || val.IsNull() || !(ty.IsMapType() || ty.IsObjectType()) {
+		return []string{path}
+	}
+
+	var paths []string
+	for it := val.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		if !v.IsWhollyKnown() {
+			paths = append(paths, path+"."+k.AsString())
+		}
+	}
+	return paths
 }
 
 func valueToString(val cty.Value) string {
@@ -254,7 +492,7 @@ func valueToStringSlice(val cty.Value) []string {
 	result := make([]string, 0)
 	for it := val.ElementIterator(); it.Next(); {
 		_, v := it.Element()
-		if v.Type() == cty.String {
+		if v.Type() == cty.String && v.IsKnown() && !v.IsNull() {
 			result = append(result, v.AsString())
 		}
 	}
@@ -272,7 +510,7 @@ func valueToStringMap(val cty.Value) map[string]string {
 	result := make(map[string]string)
 	for it := val.ElementIterator(); it.Next(); {
 		k, v := it.Element()
-		if k.Type() == cty.String && v.Type() == cty.String {
+		if k.Type() == cty.String && v.Type() == cty.String && v.IsKnown() && !v.IsNull() {
 			result[k.AsString()] = v.AsString()
 		}
 	}
@@ -0,0 +1,1437 @@
+package terraform
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+func TestParser_ParseHCL_Variables(t *testing.T) {
+	hcl := `
+variable "instance_type" {
+  type    = string
+  default = "t2.micro"
+}
+
+variable "volume_size" {
+  type    = number
+  default = 20
+}
+
+variable "security_groups" {
+  type    = list(string)
+  default = ["sg-111"]
+}
+
+resource "aws_instance" "web" {
+  ami                    = "ami-123"
+  instance_type          = var.instance_type
+  vpc_security_group_ids = var.security_groups
+
+  root_block_device {
+    volume_size = var.volume_size
+  }
+}`
+
+	t.Run("uses defaults", func(t *testing.T) {
+		p := NewParser()
+		instances, err := p.ParseHCL([]byte(hcl), "test.tf")
+		if err != nil {
+			t.Fatalf("ParseHCL() error = %v", err)
+		}
+
+		inst := instances["aws_instance.web"]
+		if inst.InstanceType != "t2.micro" {
+			t.Errorf("InstanceType = %s, want t2.micro", inst.InstanceType)
+		}
+		if inst.RootBlockDevice.VolumeSize != 20 {
+			t.Errorf("VolumeSize = %d, want 20", inst.RootBlockDevice.VolumeSize)
+		}
+		if len(inst.SecurityGroups) != 1 || inst.SecurityGroups[0] != "sg-111" {
+			t.Errorf("SecurityGroups = %v, want [sg-111]", inst.SecurityGroups)
+		}
+	})
+
+	t.Run("command line values override defaults", func(t *testing.T) {
+		p := NewParser(WithVariables(map[string]string{
+			"instance_type":   "m5.large",
+			"volume_size":     "100",
+			"security_groups": `["sg-222", "sg-333"]`,
+		}))
+		instances, err := p.ParseHCL([]byte(hcl), "test.tf")
+		if err != nil {
+			t.Fatalf("ParseHCL() error = %v", err)
+		}
+
+		inst := instances["aws_instance.web"]
+		if inst.InstanceType != "m5.large" {
+			t.Errorf("InstanceType = %s, want m5.large", inst.InstanceType)
+		}
+		if inst.RootBlockDevice.VolumeSize != 100 {
+			t.Errorf("VolumeSize = %d, want 100", inst.RootBlockDevice.VolumeSize)
+		}
+		if len(inst.SecurityGroups) != 2 {
+			t.Errorf("SecurityGroups = %v, want 2 entries", inst.SecurityGroups)
+		}
+	})
+
+	t.Run("invalid command line value", func(t *testing.T) {
+		p := NewParser(WithVariables(map[string]string{"volume_size": "large"}))
+		if _, err := p.ParseHCL([]byte(hcl), "test.tf"); err == nil {
+			t.Error("ParseHCL() expected error for non-numeric volume_size")
+		}
+	})
+}
+
+func TestParser_ParseHCL_VariableWithoutValue(t *testing.T) {
+	hcl := `
+variable "ami" {}
+
+resource "aws_instance" "web" {
+  ami           = var.ami
+  instance_type = "t2.micro"
+}`
+
+	p := NewParser()
+	instances, err := p.ParseHCL([]byte(hcl), "test.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+
+	inst := instances["aws_instance.web"]
+	if inst.AMI != "" {
+		t.Errorf("AMI = %s, want empty for unset variable", inst.AMI)
+	}
+	if inst.InstanceType != "t2.micro" {
+		t.Errorf("InstanceType = %s, want t2.micro", inst.InstanceType)
+	}
+}
+
+func TestParser_ParseHCL_Locals(t *testing.T) {
+	hcl := `
+variable "env" {
+  default = "prod"
+}
+
+locals {
+  name_prefix = "${var.env}-web"
+  common_tags = {
+    Environment = var.env
+    Name        = local.name_prefix
+  }
+}
+
+locals {
+  size = "t3.small"
+}
+
+resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = local.size
+  tags          = local.common_tags
+}`
+
+	p := NewParser()
+	instances, err := p.ParseHCL([]byte(hcl), "test.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+
+	inst := instances["aws_instance.web"]
+	if inst.InstanceType != "t3.small" {
+		t.Errorf("InstanceType = %s, want t3.small", inst.InstanceType)
+	}
+	if inst.Tags["Environment"] != "prod" {
+		t.Errorf("Tags[Environment] = %s, want prod", inst.Tags["Environment"])
+	}
+	if inst.Tags["Name"] != "prod-web" {
+		t.Errorf("Tags[Name] = %s, want prod-web", inst.Tags["Name"])
+	}
+}
+
+func TestParser_ParseHCLFile_VarFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"main.tf": `
+variable "instance_type" {
+  default = "t2.micro"
+}
+
+variable "ami" {
+  default = "ami-default"
+}
+
+variable "key_name" {
+  default = "default-key"
+}
+
+resource "aws_instance" "web" {
+  ami           = var.ami
+  instance_type = var.instance_type
+  key_name      = var.key_name
+}`,
+		"terraform.tfvars":       `instance_type = "t2.small"`,
+		"b.auto.tfvars":          `instance_type = "t2.large"`,
+		"a.auto.tfvars.json":     `{"instance_type": "t2.medium", "ami": "ami-auto"}`,
+		"override.tfvars":        `key_name = "override-key"`,
+		"ignored.tfvars":         `ami = "ami-ignored"`,
+		"backup.auto.tfvars.bak": `ami = "ami-ignored"`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	p := NewParser(WithVarFiles(filepath.Join(tmpDir, "override.tfvars")))
+	instances, err := p.ParseHCLFile(filepath.Join(tmpDir, "main.tf"))
+	if err != nil {
+		t.Fatalf("ParseHCLFile() error = %v", err)
+	}
+
+	inst := instances["aws_instance.web"]
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"InstanceType from last auto var file", inst.InstanceType, "t2.large"},
+		{"AMI from auto JSON var file", inst.AMI, "ami-auto"},
+		{"KeyName from explicit var file", inst.KeyName, "override-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %s, want %s", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ConfigSyntax(t *testing.T) {
+	native := `resource "aws_instance" "web" { ami = "ami-123" }`
+	jsonConfig := `{"resource": {"aws_instance": {"web": {"ami": "ami-123"}}}}`
+
+	tests := []struct {
+		name     string
+		filename string
+		data     string
+		wantErr  bool
+	}{
+		{name: "tf.json uses the JSON syntax", filename: "main.tf.json", data: jsonConfig},
+		{name: "upper-case TF.JSON", filename: "MAIN.TF.JSON", data: jsonConfig},
+		{name: "other json uses the native syntax", filename: "main.json", data: native},
+		{name: "tfvars.json is not configuration", filename: "prod.tfvars.json", data: jsonConfig, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances, err := NewParser().ParseHCL([]byte(tt.data), tt.filename)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("ParseHCL() expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseHCL() error = %v", err)
+			}
+			if instances["aws_instance.web"] == nil || instances["aws_instance.web"].AMI != "ami-123" {
+				t.Errorf("ParseHCL() = %v, want aws_instance.web", instances)
+			}
+		})
+	}
+
+	t.Run("ParseFile rejects a tfvars.json", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "prod.tfvars.json")
+		if err := os.WriteFile(path, []byte(`{"ami": "ami-123"}`), 0o644); err != nil {
+			t.Fatalf("Failed to create var file: %v", err)
+		}
+		_, err := NewParser().ParseFile(path)
+		if err == nil || !strings.Contains(err.Error(), "variable file") {
+			t.Errorf("ParseFile() error = %v, want a variable file error", err)
+		}
+	})
+}
+
+func TestParser_ParseHCLFile_InvalidVarFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	hclPath := filepath.Join(tmpDir, "main.tf")
+
+	if err := os.WriteFile(hclPath, []byte(`variable "ami" {}`), 0o644); err != nil {
+		t.Fatalf("Failed to create temp HCL file: %v", err)
+	}
+
+	p := NewParser(WithVarFiles(filepath.Join(tmpDir, "missing.tfvars")))
+	if _, err := p.ParseHCLFile(hclPath); err == nil {
+		t.Error("ParseHCLFile() expected error for missing var file")
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+}
+
+func TestParser_ParseHCLDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"variables.tf": `
+variable "instance_type" {
+  default = "t2.micro"
+}`,
+		"locals.tf": `
+locals {
+  ami = "ami-shared"
+}`,
+		"web.tf": `
+resource "aws_instance" "web" {
+  ami           = local.ami
+  instance_type = var.instance_type
+}`,
+		"api.tf.json": `{
+  "resource": {
+    "aws_instance": {
+      "api": {
+        "ami": "${local.ami}",
+        "instance_type": "t3.small"
+      }
+    }
+  }
+}`,
+		"terraform.tfvars": `instance_type = "t2.small"`,
+		"README.md":        `not terraform`,
+		"override.tf":      `resource "aws_instance" "web" {}`,
+	})
+
+	p := NewParser()
+	instances, err := p.ParseHCLDir(tmpDir)
+	if err != nil {
+		t.Fatalf("ParseHCLDir() error = %v", err)
+	}
+
+	if len(instances) != 2 {
+		t.Fatalf("ParseHCLDir() returned %d instances, want 2", len(instances))
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"web AMI from local in other file", instances["aws_instance.web"].AMI, "ami-shared"},
+		{"web InstanceType from tfvars", instances["aws_instance.web"].InstanceType, "t2.small"},
+		{"api AMI from JSON syntax", instances["aws_instance.api"].AMI, "ami-shared"},
+		{"api InstanceType", instances["aws_instance.api"].InstanceType, "t3.small"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %s, want %s", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ParseHCLDir_DuplicateResource(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"a.tf": `
+resource "aws_instance" "web" {
+  ami = "ami-1"
+}`,
+		"b.tf": `
+
+resource "aws_instance" "web" {
+  ami = "ami-2"
+}`,
+	})
+
+	p := NewParser()
+	_, err := p.ParseHCLDir(tmpDir)
+	if err == nil {
+		t.Fatal("ParseHCLDir() expected error for duplicate resource")
+	}
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected ParseError, got %T", err)
+	}
+	if parseErr.FilePath != filepath.Join(tmpDir, "b.tf") {
+		t.Errorf("FilePath = %s, want b.tf", parseErr.FilePath)
+	}
+	if parseErr.LineNumber != 3 {
+		t.Errorf("LineNumber = %d, want 3", parseErr.LineNumber)
+	}
+	if !strings.Contains(err.Error(), "a.tf:2") {
+		t.Errorf("error %q should reference the first declaration", err.Error())
+	}
+}
+
+func TestParser_ParseHCLDir_Empty(t *testing.T) {
+	p := NewParser()
+	if _, err := p.ParseHCLDir(t.TempDir()); err == nil {
+		t.Error("ParseHCLDir() expected error for directory without configuration files")
+	}
+}
+
+func TestParser_ParseFile_Directory(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"main.tf": `
+resource "aws_instance" "web" {
+  ami = "ami-123"
+}`,
+	})
+
+	p := NewParser()
+	instances, err := p.ParseFile(tmpDir)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	if _, ok := instances["aws_instance.web"]; !ok {
+		t.Error("Instance 'web' not found")
+	}
+}
+
+func TestParser_ParseHCLDir_Modules(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"main.tf": `
+variable "env" {
+  default = "prod"
+}
+
+locals {
+  ami = "ami-root"
+}
+
+module "web" {
+  source        = "./modules/web"
+  ami           = local.ami
+  instance_type = "t3.large"
+  name          = "${var.env}-web"
+}
+
+module "remote" {
+  source  = "terraform-aws-modules/ec2-instance/aws"
+  version = "~> 5.0"
+}
+
+resource "aws_instance" "bastion" {
+  ami = local.ami
+}`,
+		"modules/web/main.tf": `
+variable "ami" {}
+
+variable "instance_type" {
+  default = "t2.micro"
+}
+
+variable "name" {}
+
+variable "volume_size" {
+  type    = number
+  default = 30
+}
+
+resource "aws_instance" "this" {
+  ami           = var.ami
+  instance_type = var.instance_type
+  tags = {
+    Name = var.name
+  }
+
+  root_block_device {
+    volume_size = var.volume_size
+  }
+}
+
+module "sidecar" {
+  source = "../sidecar"
+  ami    = var.ami
+}`,
+		"modules/sidecar/main.tf": `
+variable "ami" {}
+
+resource "aws_instance" "this" {
+  ami           = var.ami
+  instance_type = "t3.nano"
+}`,
+	})
+
+	p := NewParser()
+	instances, err := p.ParseHCLDir(tmpDir)
+	if err != nil {
+		t.Fatalf("ParseHCLDir() error = %v", err)
+	}
+
+	if len(instances) != 3 {
+		t.Fatalf("ParseHCLDir() returned %d instances, want 3", len(instances))
+	}
+
+	web := instances["module.web.aws_instance.this"]
+	sidecar := instances["module.web.module.sidecar.aws_instance.this"]
+	if web == nil || sidecar == nil || instances["aws_instance.bastion"] == nil {
+		t.Fatalf("missing expected instances, got keys %v", keys(instances))
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"web AMI from module argument", web.AMI, "ami-root"},
+		{"web InstanceType overrides default", web.InstanceType, "t3.large"},
+		{"web Name from interpolated argument", web.Tags["Name"], "prod-web"},
+		{"sidecar AMI passed through nested module", sidecar.AMI, "ami-root"},
+		{"sidecar InstanceType", sidecar.InstanceType, "t3.nano"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %s, want %s", tt.got, tt.want)
+			}
+		})
+	}
+
+	if web.Address != "module.web.aws_instance.this" {
+		t.Errorf("Address = %s, want module.web.aws_instance.this", web.Address)
+	}
+	if instances["aws_instance.bastion"].Address != "aws_instance.bastion" {
+		t.Errorf("Address = %s, want aws_instance.bastion", instances["aws_instance.bastion"].Address)
+	}
+	if web.RootBlockDevice.VolumeSize != 30 {
+		t.Errorf("VolumeSize = %d, want 30 from module variable default", web.RootBlockDevice.VolumeSize)
+	}
+}
+
+func TestParser_ParseHCLDir_ModuleErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name: "missing module directory",
+			files: map[string]string{
+				"main.tf": `
+module "web" {
+  source = "./modules/missing"
+}`,
+			},
+		},
+		{
+			name: "module without source",
+			files: map[string]string{
+				"main.tf": `
+module "web" {}`,
+			},
+		},
+		{
+			name: "self-referencing module",
+			files: map[string]string{
+				"main.tf": `
+module "loop" {
+  source = "./"
+}`,
+			},
+		},
+		{
+			name: "argument of wrong type",
+			files: map[string]string{
+				"main.tf": `
+module "web" {
+  source = "./modules/web"
+  size   = "large"
+}`,
+				"modules/web/main.tf": `
+variable "size" {
+  type = number
+}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			writeFiles(t, tmpDir, tt.files)
+
+			p := NewParser()
+			if _, err := p.ParseHCLDir(tmpDir); err == nil {
+				t.Error("ParseHCLDir() expected error")
+			}
+		})
+	}
+}
+
+func keys(instances map[string]*models.EC2Instance) []string {
+	names := make([]string, 0, len(instances))
+	for name := range instances {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestParser_ParseHCL_CountAndForEach(t *testing.T) {
+	hcl := `
+variable "servers" {
+  default = {
+    api    = "t3.small"
+    worker = "t3.large"
+  }
+}
+
+resource "aws_instance" "web" {
+  count         = 3
+  ami           = "ami-web"
+  instance_type = "t2.micro"
+  tags = {
+    Name = "web-${count.index}"
+  }
+}
+
+resource "aws_instance" "app" {
+  for_each      = var.servers
+  ami           = "ami-app"
+  instance_type = each.value
+  tags = {
+    Name = each.key
+  }
+}
+
+resource "aws_instance" "zones" {
+  for_each          = { a = "us-east-1a", b = "us-east-1b" }
+  availability_zone = each.value
+}
+
+resource "aws_instance" "disabled" {
+  count = 0
+  ami   = "ami-none"
+}`
+
+	p := NewParser()
+	instances, err := p.ParseHCL([]byte(hcl), "test.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+
+	if len(instances) != 7 {
+		t.Fatalf("ParseHCL() returned %d instances, want 7: %v", len(instances), keys(instances))
+	}
+
+	tests := []struct {
+		key         string
+		wantAddress string
+		wantType    string
+		wantName    string
+	}{
+		{"web[0]", "aws_instance.web[0]", "t2.micro", "web-0"},
+		{"web[2]", "aws_instance.web[2]", "t2.micro", "web-2"},
+		{`app["api"]`, `aws_instance.app["api"]`, "t3.small", "api"},
+		{`app["worker"]`, `aws_instance.app["worker"]`, "t3.large", "worker"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			inst, ok := instances[tt.wantAddress]
+			if !ok {
+				t.Fatalf("instance %s not found", tt.wantAddress)
+			}
+			if inst.Address != tt.wantAddress {
+				t.Errorf("Address = %s, want %s", inst.Address, tt.wantAddress)
+			}
+			if inst.InstanceType != tt.wantType {
+				t.Errorf("InstanceType = %s, want %s", inst.InstanceType, tt.wantType)
+			}
+			if inst.Tags["Name"] != tt.wantName {
+				t.Errorf("Tags[Name] = %s, want %s", inst.Tags["Name"], tt.wantName)
+			}
+		})
+	}
+
+	if inst := instances[`aws_instance.zones["b"]`]; inst == nil || inst.AvailabilityZone != "us-east-1b" {
+		t.Errorf("for_each over an object should expose each.value, got %+v", inst)
+	}
+}
+
+func TestParser_ParseHCL_UnexpandableResource(t *testing.T) {
+	hcl := `
+variable "size" {}
+
+resource "aws_instance" "web" {
+  count = var.size
+}
+
+module "app" {
+  source   = "./app"
+  for_each = var.size
+}`
+
+	instances, err := NewParser().ParseHCL([]byte(hcl), "test.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+	if len(instances) != 0 {
+		t.Errorf("ParseHCL() returned %d instances, want 0 for a count from an unset variable", len(instances))
+	}
+}
+
+func TestParser_ParseHCL_InvalidRepetition(t *testing.T) {
+	tests := []struct {
+		name string
+		hcl  string
+	}{
+		{
+			name: "negative count",
+			hcl: `
+resource "aws_instance" "web" {
+  count = -1
+}`,
+		},
+		{
+			name: "for_each over a list",
+			hcl: `
+resource "aws_instance" "web" {
+  for_each = ["a", "b"]
+}`,
+		},
+		{
+			name: "for_each over a string",
+			hcl: `
+resource "aws_instance" "web" {
+  for_each = "web"
+}`,
+		},
+		{
+			name: "count and for_each together",
+			hcl: `
+resource "aws_instance" "web" {
+  count    = 1
+  for_each = { a = "a" }
+}`,
+		},
+		{
+			name: "module with count and for_each together",
+			hcl: `
+module "app" {
+  source   = "./app"
+  count    = 1
+  for_each = { a = "a" }
+}`,
+		},
+	}
+
+	p := NewParser()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.ParseHCL([]byte(tt.hcl), "test.tf")
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("ParseHCL() error = %v, want a *ParseError", err)
+			}
+			if parseErr.LineNumber == 0 {
+				t.Errorf("ParseError line number not set: %v", err)
+			}
+		})
+	}
+}
+
+func TestParser_ParseHCLDir_ModuleCountAndForEach(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"main.tf": `
+module "web" {
+  source = "./modules/web"
+  count  = 2
+  name   = "web-${count.index}"
+}
+
+module "tier" {
+  source   = "./modules/web"
+  for_each = { api = "api-server" }
+  name     = each.value
+}`,
+		"modules/web/main.tf": `
+variable "name" {}
+
+resource "aws_instance" "this" {
+  tags = {
+    Name = var.name
+  }
+}`,
+	})
+
+	p := NewParser()
+	instances, err := p.ParseHCLDir(tmpDir)
+	if err != nil {
+		t.Fatalf("ParseHCLDir() error = %v", err)
+	}
+
+	want := map[string]string{
+		"module.web[0].aws_instance.this":      "web-0",
+		"module.web[1].aws_instance.this":      "web-1",
+		`module.tier["api"].aws_instance.this`: "api-server",
+	}
+	if len(instances) != len(want) {
+		t.Fatalf("ParseHCLDir() returned %v, want %d instances", keys(instances), len(want))
+	}
+	for key, name := range want {
+		inst, ok := instances[key]
+		if !ok {
+			t.Errorf("instance %s not found", key)
+			continue
+		}
+		if inst.Tags["Name"] != name {
+			t.Errorf("%s Tags[Name] = %s, want %s", key, inst.Tags["Name"], name)
+		}
+	}
+}
+
+func TestParser_ParseFile_TFJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"cdk.tf.json": `{
+  "//": {"metadata": {"backend": "local", "stackName": "app", "version": "0.20.0"}},
+  "variable": {
+    "instance_type": {"type": "string", "default": "t3.micro"},
+    "zones": {"type": "list(string)", "default": ["us-east-1a", "us-east-1b"]}
+  },
+  "locals": {
+    "common_tags": {"Team": "platform"}
+  },
+  "resource": {
+    "aws_instance": {
+      "web": {
+        "//": {"metadata": {"path": "app/web", "uniqueId": "web"}},
+        "count": 2,
+        "ami": "ami-12345678",
+        "instance_type": "${var.instance_type}",
+        "availability_zone": "${var.zones[count.index]}",
+        "tags": "${merge(local.common_tags, {Name = \"web-${count.index}\"})}",
+        "root_block_device": {"volume_size": 20, "volume_type": "gp3"}
+      }
+    }
+  }
+}`,
+		"terraform.tfvars": `instance_type = "t3.large"`,
+	})
+
+	p := NewParser()
+	instances, err := p.ParseFile(filepath.Join(tmpDir, "cdk.tf.json"))
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("expected 2 instances, got %v", keys(instances))
+	}
+
+	web1 := instances["aws_instance.web[1]"]
+	if web1 == nil {
+		t.Fatalf("missing web[1], got %v", keys(instances))
+	}
+	if web1.InstanceType != "t3.large" {
+		t.Errorf("InstanceType = %s, want t3.large from terraform.tfvars", web1.InstanceType)
+	}
+	if web1.AvailabilityZone != "us-east-1b" {
+		t.Errorf("AvailabilityZone = %s, want us-east-1b", web1.AvailabilityZone)
+	}
+	if web1.RootBlockDevice.VolumeSize != 20 || web1.RootBlockDevice.VolumeType != "gp3" {
+		t.Errorf("RootBlockDevice = %+v", web1.RootBlockDevice)
+	}
+	if web1.Tags["Team"] != "platform" || web1.Tags["Name"] != "web-1" {
+		t.Errorf("Tags = %v, want Team=platform and Name=web-1", web1.Tags)
+	}
+	if web1.Address != "aws_instance.web[1]" {
+		t.Errorf("Address = %s, want aws_instance.web[1]", web1.Address)
+	}
+
+	t.Run("state files still parse as state", func(t *testing.T) {
+		statePath := filepath.Join(tmpDir, "state.json")
+		state := `{"version": 4, "resources": [{"type": "aws_instance", "name": "db",
+			"instances": [{"attributes": {"id": "i-123"}}]}]}`
+		if err := os.WriteFile(statePath, []byte(state), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		instances, err := p.ParseFile(statePath)
+		if err != nil {
+			t.Fatalf("ParseFile() error = %v", err)
+		}
+		if _, ok := instances["i-123"]; !ok {
+			t.Errorf("expected i-123 from state, got %v", keys(instances))
+		}
+	})
+
+	t.Run("syntax errors are reported with the file", func(t *testing.T) {
+		badPath := filepath.Join(tmpDir, "bad.tf.json")
+		if err := os.WriteFile(badPath, []byte(`{"resource": `), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := p.ParseFile(badPath)
+		if err == nil || !strings.Contains(err.Error(), "bad.tf.json") {
+			t.Errorf("expected error naming bad.tf.json, got %v", err)
+		}
+	})
+}
+
+func TestParser_ParseHCL_IgnoreChanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		lifecycle string
+		want      []string
+	}{
+		{
+			name:      "attribute references",
+			lifecycle: `ignore_changes = [tags, ami]`,
+			want:      []string{"tags", "ami"},
+		},
+		{
+			name:      "nested block attribute",
+			lifecycle: `ignore_changes = [root_block_device[0].volume_size]`,
+			want:      []string{"root_block_device.volume_size"},
+		},
+		{
+			name:      "map key",
+			lifecycle: `ignore_changes = [tags["Name"], vpc_security_group_ids]`,
+			want:      []string{"tags.Name", "security_groups"},
+		},
+		{
+			name:      "quoted legacy paths",
+			lifecycle: `ignore_changes = ["tags", "root_block_device[0].volume_type"]`,
+			want:      []string{"tags", "root_block_device.volume_type"},
+		},
+		{
+			name:      "all",
+			lifecycle: `ignore_changes = all`,
+			want:      []string{models.IgnoreAllChanges},
+		},
+		{
+			name:      "other lifecycle arguments only",
+			lifecycle: `prevent_destroy = true`,
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hcl := `
+resource "aws_instance" "web" {
+  ami = "ami-123"
+
+  lifecycle {
+    ` + tt.lifecycle + `
+  }
+}`
+			instances, err := NewParser().ParseHCL([]byte(hcl), "main.tf")
+			if err != nil {
+				t.Fatalf("ParseHCL() error = %v", err)
+			}
+			got := instances["aws_instance.web"].IgnoreChanges
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("IgnoreChanges = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("json syntax", func(t *testing.T) {
+		config := `{"resource": {"aws_instance": {"web": {
+			"ami": "ami-123",
+			"lifecycle": {"ignore_changes": ["tags", "root_block_device[0].volume_size"]}
+		}}}}`
+		instances, err := NewParser().ParseHCL([]byte(config), "main.tf.json")
+		if err != nil {
+			t.Fatalf("ParseHCL() error = %v", err)
+		}
+		got := instances["aws_instance.web"].IgnoreChanges
+		if strings.Join(got, ",") != "tags,root_block_device.volume_size" {
+			t.Errorf("IgnoreChanges = %v", got)
+		}
+	})
+
+	t.Run("invalid ignore_changes", func(t *testing.T) {
+		hcl := `
+resource "aws_instance" "web" {
+  lifecycle {
+    ignore_changes = 5
+  }
+}`
+		if _, err := NewParser().ParseHCL([]byte(hcl), "main.tf"); err == nil {
+			t.Error("ParseHCL() expected error for invalid ignore_changes")
+		}
+	})
+}
+
+func TestParser_ParseHCL_DefaultTags(t *testing.T) {
+	hcl := `
+variable "team" {
+  default = "platform"
+}
+
+locals {
+  common = {
+    Team = var.team
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+
+  default_tags {
+    tags = {
+      Environment = "prod"
+      Owner       = "ops"
+      Team        = local.common.Team
+    }
+  }
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+
+  default_tags {
+    tags = {
+      Environment = "dr"
+    }
+  }
+}
+
+provider "aws" {
+  alias  = "plain"
+  region = "eu-west-1"
+}
+
+resource "aws_instance" "web" {
+  ami = "ami-123"
+
+  tags = {
+    Name  = "web"
+    Owner = "web-team"
+  }
+}
+
+resource "aws_instance" "untagged" {
+  ami = "ami-123"
+}
+
+resource "aws_instance" "west" {
+  provider = aws.west
+  ami      = "ami-123"
+
+  tags = {
+    Name = "west"
+  }
+}
+
+resource "aws_instance" "plain" {
+  provider = aws.plain
+  ami      = "ami-123"
+
+  tags = {
+    Name = "plain"
+  }
+}`
+
+	instances, err := NewParser().ParseHCL([]byte(hcl), "main.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want map[string]string
+	}{
+		{
+			name: "web",
+			want: map[string]string{
+				"Name": "web", "Owner": "web-team", "Environment": "prod", "Team": "platform",
+			},
+		},
+		{
+			name: "untagged",
+			want: map[string]string{"Owner": "ops", "Environment": "prod", "Team": "platform"},
+		},
+		{
+			name: "west",
+			want: map[string]string{"Name": "west", "Environment": "dr"},
+		},
+		{
+			name: "plain",
+			want: map[string]string{"Name": "plain"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst, ok := instances["aws_instance."+tt.name]
+			if !ok {
+				t.Fatalf("instance %q not found", tt.name)
+			}
+			if !reflect.DeepEqual(inst.Tags, tt.want) {
+				t.Errorf("Tags = %v, want %v", inst.Tags, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ParseHCLDir_ModuleDefaultTags(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"main.tf": `
+provider "aws" {
+  default_tags {
+    tags = { Environment = "prod" }
+  }
+}
+
+provider "aws" {
+  alias = "west"
+
+  default_tags {
+    tags = { Environment = "dr" }
+  }
+}
+
+module "primary" {
+  source = "./modules/web"
+}
+
+module "secondary" {
+  source = "./modules/web"
+
+  providers = {
+    aws = aws.west
+  }
+}`,
+		"modules/web/main.tf": `
+resource "aws_instance" "this" {
+  ami  = "ami-123"
+  tags = { Name = "web" }
+}`,
+	})
+
+	instances, err := NewParser().ParseHCLDir(tmpDir)
+	if err != nil {
+		t.Fatalf("ParseHCLDir() error = %v", err)
+	}
+
+	want := map[string]string{
+		"module.primary.aws_instance.this":   "prod",
+		"module.secondary.aws_instance.this": "dr",
+	}
+	for key, env := range want {
+		inst, ok := instances[key]
+		if !ok {
+			t.Fatalf("instance %q not found", key)
+		}
+		if inst.Tags["Environment"] != env || inst.Tags["Name"] != "web" {
+			t.Errorf("%s: Tags = %v, want Environment=%s and Name=web", key, inst.Tags, env)
+		}
+	}
+}
+
+func TestParser_ParseHCL_ProviderRegion(t *testing.T) {
+	hcl := `
+variable "dr_region" {
+  default = "us-west-2"
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  alias  = "west"
+  region = var.dr_region
+}
+
+provider "aws" {
+  alias = "noregion"
+}
+
+resource "aws_instance" "east" {
+  ami = "ami-123"
+}
+
+resource "aws_instance" "west" {
+  provider = aws.west
+  ami      = "ami-123"
+}
+
+resource "aws_instance" "zone" {
+  provider          = aws.noregion
+  ami               = "ami-123"
+  availability_zone = "eu-central-1a"
+}`
+
+	instances, err := NewParser().ParseHCL([]byte(hcl), "main.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		provider string
+		region   string
+	}{
+		{name: "east", provider: "aws", region: "us-east-1"},
+		{name: "west", provider: "aws.west", region: "us-west-2"},
+		{name: "zone", provider: "aws.noregion", region: "eu-central-1"},
+	}
+	for _, tt := range tests {
+		inst := instances["aws_instance."+tt.name]
+		if inst.Provider != tt.provider {
+			t.Errorf("%s: Provider = %q, want %q", tt.name, inst.Provider, tt.provider)
+		}
+		if inst.Region != tt.region {
+			t.Errorf("%s: Region = %q, want %q", tt.name, inst.Region, tt.region)
+		}
+	}
+}
+
+func TestParser_ParseHCL_Sources(t *testing.T) {
+	hcl := `provider "aws" {
+  default_tags {
+    tags = { Environment = "prod" }
+  }
+}
+
+resource "aws_instance" "web" {
+  ami                    = "ami-123"
+  instance_type          = "t3.micro"
+  vpc_security_group_ids = ["sg-1"]
+
+  tags = {
+    Name = "web"
+  }
+
+  root_block_device {
+    volume_size = 20
+  }
+}`
+
+	instances, err := NewParser().ParseHCL([]byte(hcl), "main.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+	inst := instances["aws_instance.web"]
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "ami", want: "main.tf:8"},
+		{path: "instance_type", want: "main.tf:9"},
+		{path: "security_groups", want: "main.tf:10"},
+		{path: "tags", want: "main.tf:12"},
+		{path: "tags.Name", want: "main.tf:12"},
+		{path: "tags.Environment", want: "main.tf:3"},
+		{path: "root_block_device.volume_size", want: "main.tf:17"},
+		{path: "root_block_device.volume_type", want: "main.tf:16"},
+		{path: "key_name", want: "aws_instance.web"},
+	}
+	for _, tt := range tests {
+		src, ok := inst.SourceOf(tt.path)
+		if !ok {
+			t.Errorf("SourceOf(%q) not found", tt.path)
+			continue
+		}
+		if src.String() != tt.want {
+			t.Errorf("SourceOf(%q) = %s, want %s", tt.path, src, tt.want)
+		}
+	}
+}
+
+func TestParser_ParseHCL_Functions(t *testing.T) {
+	hcl := `
+variable "env" {
+  default = "prod"
+}
+
+variable "sizes" {
+  default = {
+    prod = "m5.large"
+    dev  = "t3.micro"
+  }
+}
+
+locals {
+  common_tags = {
+    Environment = var.env
+    Team        = upper("platform")
+  }
+  extra_groups = ["sg-3"]
+}
+
+resource "aws_instance" "web" {
+  ami               = format("ami-%s-%03d", var.env, 7)
+  instance_type     = lookup(var.sizes, var.env, "t3.small")
+  availability_zone = "${lower("US-EAST-1")}a"
+  subnet_id         = replace("subnet_${var.env}", "/_/", "-")
+  key_name          = join("-", compact(["deploy", "", var.env]))
+
+  vpc_security_group_ids = concat(["sg-1", "sg-2"], local.extra_groups)
+
+  tags = merge(local.common_tags, {
+    Name    = "${var.env}-web"
+    Subnet  = cidrsubnet("10.0.0.0/16", 8, 2)
+    Host    = cidrhost("10.0.2.0/24", 5)
+    Config  = jsonencode({ port = 80 })
+    Encoded = base64encode("hello")
+    Count   = tostring(length(local.extra_groups))
+    Safe    = try(var.sizes["staging"], "none")
+  })
+}`
+
+	instances, err := NewParser().ParseHCL([]byte(hcl), "main.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+	inst := instances["aws_instance.web"]
+
+	if inst.AMI != "ami-prod-007" {
+		t.Errorf("AMI = %q, want ami-prod-007", inst.AMI)
+	}
+	if inst.InstanceType != "m5.large" {
+		t.Errorf("InstanceType = %q, want m5.large", inst.InstanceType)
+	}
+	if inst.AvailabilityZone != "us-east-1a" {
+		t.Errorf("AvailabilityZone = %q, want us-east-1a", inst.AvailabilityZone)
+	}
+	if inst.SubnetID != "subnet-prod" {
+		t.Errorf("SubnetID = %q, want subnet-prod", inst.SubnetID)
+	}
+	if inst.KeyName != "deploy-prod" {
+		t.Errorf("KeyName = %q, want deploy-prod", inst.KeyName)
+	}
+	if strings.Join(inst.SecurityGroups, ",") != "sg-1,sg-2,sg-3" {
+		t.Errorf("SecurityGroups = %v, want [sg-1 sg-2 sg-3]", inst.SecurityGroups)
+	}
+
+	wantTags := map[string]string{
+		"Environment": "prod",
+		"Team":        "PLATFORM",
+		"Name":        "prod-web",
+		"Subnet":      "10.0.2.0/24",
+		"Host":        "10.0.2.5",
+		"Config":      `{"port":80}`,
+		"Encoded":     "aGVsbG8=",
+		"Count":       "1",
+		"Safe":        "none",
+	}
+	if !reflect.DeepEqual(inst.Tags, wantTags) {
+		t.Errorf("Tags = %v, want %v", inst.Tags, wantTags)
+	}
+	if len(inst.Unknown) != 0 {
+		t.Errorf("Unknown = %v, want none", inst.Unknown)
+	}
+}
+
+func TestParser_ParseHCL_ForEachToset(t *testing.T) {
+	hcl := `
+variable "names" {
+  default = ["api", "worker", "api"]
+}
+
+resource "aws_instance" "app" {
+  for_each = toset(var.names)
+
+  ami  = "ami-123"
+  tags = { Name = each.key }
+}`
+
+	instances, err := NewParser().ParseHCL([]byte(hcl), "main.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("expected 2 instances, got %d", len(instances))
+	}
+	for _, key := range []string{`aws_instance.app["api"]`, `aws_instance.app["worker"]`} {
+		if _, ok := instances[key]; !ok {
+			t.Errorf("instance %s not found", key)
+		}
+	}
+}
+
+func TestParser_ParseHCL_UnknownValues(t *testing.T) {
+	hcl := `
+resource "aws_instance" "web" {
+  ami      = "ami-123"
+  key_name = uuid()
+
+  tags = {
+    Name      = "web"
+    CreatedAt = timestamp()
+  }
+}`
+
+	instances, err := NewParser().ParseHCL([]byte(hcl), "main.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+	inst := instances["aws_instance.web"]
+
+	unknown := append([]string(nil), inst.Unknown...)
+	sort.Strings(unknown)
+	if strings.Join(unknown, ",") != "key_name,tags.CreatedAt" {
+		t.Errorf("Unknown = %v, want [key_name tags.CreatedAt]", inst.Unknown)
+	}
+	if !reflect.DeepEqual(inst.Tags, map[string]string{"Name": "web"}) {
+		t.Errorf("Tags = %v, want only the known Name tag", inst.Tags)
+	}
+}
+
+func TestParser_ParseHCL_UnevaluableValues(t *testing.T) {
+	hcl := `
+resource "aws_instance" "web" {
+  ami       = "ami-123"
+  key_name  = file("key_name.txt")
+  subnet_id = aws_subnet.app.id
+
+  root_block_device {
+    volume_size = 20
+    volume_type = templatefile("volume_type.tpl", {})
+  }
+}`
+
+	instances, err := NewParser().ParseHCL([]byte(hcl), "main.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+	inst := instances["aws_instance.web"]
+
+	unknown := append([]string(nil), inst.Unknown...)
+	sort.Strings(unknown)
+	want := "key_name,root_block_device.volume_type,subnet_id"
+	if strings.Join(unknown, ",") != want {
+		t.Errorf("Unknown = %v, want %s", inst.Unknown, want)
+	}
+	for _, path := range strings.Split(want, ",") {
+		if _, ok := inst.Sources[path]; !ok {
+			t.Errorf("Sources[%q] not recorded", path)
+		}
+	}
+	if inst.AMI != "ami-123" || inst.RootBlockDevice.VolumeSize != 20 {
+		t.Errorf("known values not parsed: AMI=%q VolumeSize=%d", inst.AMI, inst.RootBlockDevice.VolumeSize)
+	}
+}
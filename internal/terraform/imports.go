@@ -0,0 +1,114 @@
+package terraform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/solomon-os/go-test/internal/logger"
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+var importSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "to", Required: true},
+		{Name: "id"},
+		{Name: "identity"},
+		{Name: "for_each"},
+		{Name: "provider"},
+	},
+}
+
+// applyImports records the id of each import block targeting an
+// aws_instance on the instance at its to address, as ImportID.
+func applyImports(blocks hcl.Blocks, ctx *hcl.EvalContext, instances map[string]*models.EC2Instance) error {
+	byAddress := make(map[string]*models.EC2Instance, len(instances))
+	for _, inst := range instances {
+		byAddress[inst.Address] = inst
+	}
+
+	for _, block := range blocks {
+		if block.Type != "import" {
+			continue
+		}
+		to, id, err := decodeImport(block, ctx)
+		if err != nil {
+			return NewParseError(block.DefRange.Filename, "hcl", err).
+				WithLineNumber(block.DefRange.Start.Line)
+		}
+		if id == "" {
+			continue
+		}
+		inst, ok := byAddress[to]
+		if !ok {
+			logger.Debug("import block targets no aws_instance", "to", to)
+			continue
+		}
+		logger.Debug("instance imported", "address", to, "instance_id", id)
+		inst.ImportID = id
+	}
+	return nil
+}
+
+// decodeImport returns the target address and instance ID of an import
+// block. The ID is empty for blocks that are skipped: those using
+// for_each or identity, and those whose id cannot be evaluated.
+func decodeImport(block *hcl.Block, ctx *hcl.EvalContext) (to, id string, err error) {
+	content, diags := block.Body.Content(importSchema)
+	if diags.HasErrors() {
+		return "", "", fmt.Errorf("failed to decode import: %s", diags.Error())
+	}
+	if _, ok := content.Attributes["for_each"]; ok {
+		logger.Warn("skipping import block with for_each", "line", block.DefRange.Start.Line)
+		return "", "", nil
+	}
+
+	traversal, diags := hcl.AbsTraversalForExpr(content.Attributes["to"].Expr)
+	if diags.HasErrors() {
+		return "", "", fmt.Errorf("import to must be a resource address: %s", diags.Error())
+	}
+	to = traversalAddress(traversal)
+
+	attr, ok := content.Attributes["id"]
+	if !ok {
+		return to, "", nil
+	}
+	val, diags := attr.Expr.Value(ctx)
+	if diags.HasErrors() || val.IsNull() || !val.IsKnown() || val.Type() != cty.String {
+		logger.Warn("unable to evaluate import id", "to", to)
+		return to, "", nil
+	}
+	return to, val.AsString(), nil
+}
+
+// traversalAddress renders a resource reference such as
+// module.web.aws_instance.this[0] as a Terraform address.
+func traversalAddress(traversal hcl.Traversal) string {
+	var b strings.Builder
+	for _, step := range traversal {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			b.WriteString(s.Name)
+		case hcl.TraverseAttr:
+			b.WriteString("." + s.Name)
+		case hcl.TraverseIndex:
+			b.WriteString("[" + indexKey(s.Key) + "]")
+		}
+	}
+	return b.String()
+}
+
+// indexKey renders a count index or for_each key as in an address.
+func indexKey(key cty.Value) string {
+	if key.Type() == cty.Number {
+		n, _ := key.AsBigFloat().Int64()
+		return strconv.FormatInt(n, 10)
+	}
+	if key.Type() == cty.String {
+		return strconv.Quote(key.AsString())
+	}
+	return "?"
+}
@@ -0,0 +1,87 @@
+package terraform
+
+import "testing"
+
+func TestParser_ParseHCLDir_ImportBlocks(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"main.tf": `
+variable "api_id" {
+  default = "i-0bbb"
+}
+
+import {
+  to = aws_instance.web[1]
+  id = "i-0aaa"
+}
+
+import {
+  to = aws_instance.api["blue"]
+  id = var.api_id
+}
+
+import {
+  to = module.db.aws_instance.this
+  id = "i-0ccc"
+}
+
+import {
+  to = aws_s3_bucket.logs
+  id = "logs"
+}
+
+resource "aws_instance" "web" {
+  count = 2
+  ami   = "ami-123"
+}
+
+resource "aws_instance" "api" {
+  for_each = toset(["blue"])
+  ami      = "ami-123"
+}
+
+module "db" {
+  source = "./modules/db"
+}`,
+		"modules/db/main.tf": `
+resource "aws_instance" "this" {
+  ami = "ami-123"
+}`,
+	})
+
+	instances, err := NewParser().ParseHCLDir(tmpDir)
+	if err != nil {
+		t.Fatalf("ParseHCLDir() error = %v", err)
+	}
+
+	want := map[string]string{
+		"aws_instance.web[0]":         "",
+		"aws_instance.web[1]":         "i-0aaa",
+		`aws_instance.api["blue"]`:    "i-0bbb",
+		"module.db.aws_instance.this": "i-0ccc",
+	}
+	for key, id := range want {
+		inst, ok := instances[key]
+		if !ok {
+			t.Fatalf("instance %q not found", key)
+		}
+		if inst.ImportID != id {
+			t.Errorf("%s: ImportID = %q, want %q", key, inst.ImportID, id)
+		}
+	}
+}
+
+func TestParser_ParseHCL_InvalidImport(t *testing.T) {
+	hcl := `
+import {
+  id = "i-0aaa"
+}
+
+resource "aws_instance" "web" {
+  ami = "ami-123"
+}`
+
+	if _, err := NewParser().ParseHCL([]byte(hcl), "main.tf"); err == nil {
+		t.Error("ParseHCL() expected error for import without to")
+	}
+}
@@ -0,0 +1,116 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+var lifecycleSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "ignore_changes"},
+		{Name: "create_before_destroy"},
+		{Name: "prevent_destroy"},
+		{Name: "replace_triggered_by"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "precondition"},
+		{Type: "postcondition"},
+	},
+}
+
+// attributePaths maps aws_instance argument names to the attribute paths
+// used in drift detection where they differ.
+var attributePaths = map[string]string{
+	"vpc_security_group_ids": "security_groups",
+}
+
+// decodeIgnoreChanges reads the ignore_changes argument of a lifecycle
+// block as attribute paths, e.g. root_block_device[0].volume_size becomes
+// "root_block_device.volume_size" and tags["Name"] becomes "tags.Name".
+// Like Terraform, it accepts the keyword all and, in JSON syntax or legacy
+// configurations, quoted paths.
+func decodeIgnoreChanges(block *hcl.Block) ([]string, error) {
+	content, diags := block.Body.Content(lifecycleSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to decode lifecycle: %s", diags.Error())
+	}
+
+	attr, ok := content.Attributes["ignore_changes"]
+	if !ok {
+		return nil, nil
+	}
+	if hcl.ExprAsKeyword(attr.Expr) == "all" {
+		return []string{models.IgnoreAllChanges}, nil
+	}
+
+	exprs, diags := hcl.ExprList(attr.Expr)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("ignore_changes must be a list of attribute references or all: %s", diags.Error())
+	}
+
+	paths := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		path, err := ignorePath(expr)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// ignorePath converts one ignore_changes element to an attribute path.
+func ignorePath(expr hcl.Expression) (string, error) {
+	traversal, diags := hcl.RelTraversalForExpr(expr)
+	if diags.HasErrors() {
+		// Terraform 0.11 style: a quoted attribute path.
+		val, valDiags := expr.Value(nil)
+		if valDiags.HasErrors() || val.IsNull() || val.Type() != cty.String {
+			return "", fmt.Errorf("invalid ignore_changes element: %s", diags.Error())
+		}
+		rng := expr.Range()
+		traversal, diags = hclsyntax.ParseTraversalAbs([]byte(val.AsString()), rng.Filename, rng.Start)
+		if diags.HasErrors() {
+			return "", fmt.Errorf("invalid ignore_changes element %q: %s", val.AsString(), diags.Error())
+		}
+	}
+	return traversalPath(traversal), nil
+}
+
+// traversalPath renders a relative traversal as a dotted attribute path.
+// Numeric indexes are dropped since nested blocks such as root_block_device
+// are compared as a single block; string keys become path segments.
+func traversalPath(traversal hcl.Traversal) string {
+	var parts []string
+	for _, step := range traversal {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			parts = append(parts, s.Name)
+		case hcl.TraverseAttr:
+			parts = append(parts, s.Name)
+		case hcl.TraverseIndex:
+			if s.Key.Type() == cty.String {
+				parts = append(parts, s.Key.AsString())
+			}
+		}
+	}
+	if len(parts) > 0 {
+		parts[0] = attributePath(parts[0])
+	}
+	return strings.Join(parts, ".")
+}
+
+// attributePath returns the drift detection attribute path of an
+// aws_instance argument.
+func attributePath(name string) string {
+	if mapped, ok := attributePaths[name]; ok {
+		return mapped
+	}
+	return name
+}
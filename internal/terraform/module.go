@@ -0,0 +1,316 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/solomon-os/go-test/internal/logger"
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+// maxModuleDepth bounds module nesting so that a module that (indirectly)
+// calls itself fails instead of recursing forever.
+const maxModuleDepth = 32
+
+// moduleMetaArguments are module block arguments that are interpreted by
+// Terraform itself rather than passed to the child module as variables.
+var moduleMetaArguments = map[string]bool{
+	"source":     true,
+	"version":    true,
+	"count":      true,
+	"for_each":   true,
+	"providers":  true,
+	"depends_on": true,
+}
+
+// hclModule is a module instance being evaluated.
+type hclModule struct {
+	blocks  hcl.Blocks
+	dir     string
+	rootDir string
+	// address is the module path, e.g. "module.web", or empty for the root module.
+	address string
+	depth   int
+	// providers are the AWS provider configurations available to the
+	// module, keyed by configuration address ("aws" or "aws.<alias>").
+	providers map[string]*providerConfig
+	// refs are the resource values of the reference state, shared by all
+	// modules of the configuration.
+	refs stateReferences
+}
+
+// resourceAddress returns the full address of an aws_instance resource of
+// the module, e.g. "module.web.aws_instance.this".
+func (m *hclModule) resourceAddress(name string) string {
+	if m.address == "" {
+		return "aws_instance." + name
+	}
+	return m.address + ".aws_instance." + name
+}
+
+// parseModule evaluates the blocks of mod with the given variable values,
+// adding its aws_instance resources to instances and recursing into local
+// child modules.
+func (p *Parser) parseModule(
+	mod *hclModule,
+	values map[string]cty.Value,
+	instances map[string]*models.EC2Instance,
+) error {
+	if err := checkDuplicateResources(mod.blocks); err != nil {
+		return err
+	}
+
+	s := &scope{
+		vars:      values,
+		moduleDir: mod.dir,
+		rootDir:   mod.rootDir,
+		resources: mod.refs[mod.address],
+	}
+	if err := s.evalLocals(mod.blocks); err != nil {
+		return err
+	}
+	ctx := s.evalContext()
+
+	providers, err := moduleProviders(mod.providers, mod.blocks, ctx)
+	if err != nil {
+		return err
+	}
+	mod.providers = providers
+
+	for _, block := range mod.blocks {
+		switch block.Type {
+		case "resource":
+			if len(block.Labels) < 2 || block.Labels[0] != "aws_instance" {
+				continue
+			}
+			if err := p.parseResourceBlock(mod, block, ctx, instances); err != nil {
+				return err
+			}
+		case "module":
+			if err := p.parseModuleCall(mod, block, ctx, instances); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := applyMoves(mod, instances); err != nil {
+		return err
+	}
+	// Terraform only accepts import blocks in the root module.
+	if mod.address == "" {
+		return applyImports(mod.blocks, ctx, instances)
+	}
+	return nil
+}
+
+// parseResourceBlock adds one EC2Instance per instance of an aws_instance
+// block, expanding count and for_each.
+func (p *Parser) parseResourceBlock(
+	mod *hclModule,
+	block *hcl.Block,
+	ctx *hcl.EvalContext,
+	instances map[string]*models.EC2Instance,
+) error {
+	name := block.Labels[1]
+	content, _, _ := block.Body.PartialContent(repetitionSchema)
+	expanded, err := expandBlock(content.Attributes["count"], content.Attributes["for_each"], ctx)
+	if errors.Is(err, errUnknownRepetition) {
+		logger.Warn("skipping resource that cannot be expanded",
+			"resource", mod.resourceAddress(name), "error", err)
+		return nil
+	}
+	if err != nil {
+		return NewParseError(block.DefRange.Filename, "hcl",
+			fmt.Errorf("resource %s: %w", mod.resourceAddress(name), err)).
+			WithLineNumber(block.DefRange.Start.Line)
+	}
+
+	provider, err := resourceProvider(block)
+	if err != nil {
+		return NewParseError(block.DefRange.Filename, "hcl",
+			fmt.Errorf("resource %s: %w", mod.resourceAddress(name), err)).
+			WithLineNumber(block.DefRange.Start.Line)
+	}
+	cfg, ok := mod.providers[provider]
+	if !ok && provider != defaultProvider {
+		logger.Warn("resource uses an undeclared provider configuration",
+			"resource", mod.resourceAddress(name), "provider", provider)
+	}
+
+	for _, inst := range expanded {
+		key := mod.resourceAddress(name) + inst.suffix
+		instance, err := p.parseHCLResource(block, key, inst.ctx)
+		if err != nil {
+			return fmt.Errorf("failed to parse resource %s: %w", key, err)
+		}
+		if instance.InstanceID == "" {
+			instance.InstanceID = key
+		}
+		instance.Address = mod.resourceAddress(name) + inst.suffix
+		cfg.recordTagSources(instance)
+		instance.Tags = cfg.tagsAll(instance.Tags)
+		instance.Provider = provider
+		instance.Region = cfg.instanceRegion(instance.AvailabilityZone)
+		instances[instance.InstanceID] = instance
+	}
+	return nil
+}
+
+// parseModuleCall loads the child module called by block and parses each of
+// its instances with the call's arguments as its variables. Only local
+// sources (./ or ../) can be followed; registry, git and other remote
+// sources are skipped.
+func (p *Parser) parseModuleCall(
+	parent *hclModule,
+	block *hcl.Block,
+	ctx *hcl.EvalContext,
+	instances map[string]*models.EC2Instance,
+) error {
+	address := block.Labels[0]
+	if parent.address != "" {
+		address = parent.address + ".module." + address
+	} else {
+		address = "module." + address
+	}
+
+	attrs, diags := block.Body.JustAttributes()
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to decode %s: %s", address, diags.Error())
+	}
+
+	source, err := moduleSource(block, attrs)
+	if err != nil {
+		return err
+	}
+	if !isLocalSource(source) {
+		logger.Warn("skipping module with non-local source", "module", address, "source", source)
+		return nil
+	}
+	if parent.depth >= maxModuleDepth {
+		return NewParseError(block.DefRange.Filename, "hcl",
+			fmt.Errorf("module %s exceeds maximum nesting depth of %d", address, maxModuleDepth)).
+			WithLineNumber(block.DefRange.Start.Line)
+	}
+
+	expanded, err := expandBlock(attrs["count"], attrs["for_each"], ctx)
+	if errors.Is(err, errUnknownRepetition) {
+		logger.Warn("skipping module that cannot be expanded", "module", address, "error", err)
+		return nil
+	}
+	if err != nil {
+		return NewParseError(block.DefRange.Filename, "hcl",
+			fmt.Errorf("module %s: %w", address, err)).
+			WithLineNumber(block.DefRange.Start.Line)
+	}
+
+	dir := filepath.Join(parent.dir, source)
+	logger.Debug("loading child module", "module", address, "path", dir)
+
+	files, err := configFiles(dir)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", address, err)
+	}
+	blocks, err := loadConfigFiles(files)
+	if err != nil {
+		return err
+	}
+
+	providers, err := childProviders(parent.providers, attrs)
+	if err != nil {
+		return NewParseError(block.DefRange.Filename, "hcl",
+			fmt.Errorf("module %s: %w", address, err)).
+			WithLineNumber(block.DefRange.Start.Line)
+	}
+
+	for _, inst := range expanded {
+		child := &hclModule{
+			blocks:    blocks,
+			dir:       dir,
+			rootDir:   parent.rootDir,
+			address:   address + inst.suffix,
+			depth:     parent.depth + 1,
+			providers: providers,
+			refs:      parent.refs,
+		}
+		values, err := moduleInputs(child.address, blocks, attrs, inst.ctx)
+		if err != nil {
+			return err
+		}
+		if err := p.parseModule(child, values, instances); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moduleSource returns the literal source argument of a module block.
+func moduleSource(block *hcl.Block, attrs hcl.Attributes) (string, error) {
+	attr, ok := attrs["source"]
+	if !ok {
+		return "", NewParseError(block.DefRange.Filename, "hcl",
+			fmt.Errorf("module %s has no source", block.Labels[0])).
+			WithLineNumber(block.DefRange.Start.Line)
+	}
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || val.IsNull() || val.Type() != cty.String {
+		return "", NewParseError(attr.Range.Filename, "hcl",
+			fmt.Errorf("module %s: source must be a literal string", block.Labels[0])).
+			WithLineNumber(attr.Range.Start.Line)
+	}
+	return val.AsString(), nil
+}
+
+// isLocalSource reports whether source refers to a directory on disk.
+func isLocalSource(source string) bool {
+	for _, prefix := range []string{"./", "../", ".\\", "..\\"} {
+		if strings.HasPrefix(source, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleInputs computes the variable values of a child module from the
+// arguments of its module block, evaluated in the calling module's context.
+// Variables that are not set fall back to their defaults; arguments that
+// cannot be evaluated leave the variable unset.
+func moduleInputs(
+	address string,
+	blocks hcl.Blocks,
+	attrs hcl.Attributes,
+	ctx *hcl.EvalContext,
+) (map[string]cty.Value, error) {
+	vars, err := declareVariables(blocks)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", address, err)
+	}
+	values := variableDefaults(vars)
+
+	for name, attr := range attrs {
+		if moduleMetaArguments[name] {
+			continue
+		}
+		v, ok := vars[name]
+		if !ok {
+			logger.Warn("module argument has no matching variable", "module", address, "argument", name)
+			continue
+		}
+		val, diags := attr.Expr.Value(ctx)
+		if diags.HasErrors() {
+			logger.Debug("unable to evaluate module argument", "module", address, "argument", name)
+			delete(values, name)
+			continue
+		}
+		if values[name], err = v.convert(val); err != nil {
+			return nil, fmt.Errorf("%s: %w", address, err)
+		}
+	}
+
+	warnUnsetVariables(address, vars, values)
+	return values, nil
+}
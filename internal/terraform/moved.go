@@ -0,0 +1,112 @@
+package terraform
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+var movedSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "from", Required: true},
+		{Name: "to", Required: true},
+	},
+}
+
+// move is a moved block, with addresses relative to the root module.
+type move struct {
+	from, to string
+}
+
+// applyMoves records on each instance the addresses the moved blocks of mod
+// say it was moved from, as PreviousAddresses.
+func applyMoves(mod *hclModule, instances map[string]*models.EC2Instance) error {
+	var moves []move
+	for _, block := range mod.blocks {
+		if block.Type != "moved" {
+			continue
+		}
+		m, err := decodeMove(block, mod.address)
+		if err != nil {
+			return NewParseError(block.DefRange.Filename, "hcl", err).
+				WithLineNumber(block.DefRange.Start.Line)
+		}
+		moves = append(moves, m)
+	}
+	if len(moves) == 0 {
+		return nil
+	}
+
+	for _, inst := range instances {
+		known := append([]string{inst.Address}, inst.PreviousAddresses...)
+		for _, addr := range known {
+			for _, prev := range previousAddresses(addr, moves) {
+				if !slices.Contains(known, prev) && !slices.Contains(inst.PreviousAddresses, prev) {
+					inst.PreviousAddresses = append(inst.PreviousAddresses, prev)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// decodeMove reads the from and to addresses of a moved block in the
+// module at moduleAddress.
+func decodeMove(block *hcl.Block, moduleAddress string) (move, error) {
+	content, diags := block.Body.Content(movedSchema)
+	if diags.HasErrors() {
+		return move{}, fmt.Errorf("failed to decode moved: %s", diags.Error())
+	}
+
+	var addrs [2]string
+	for i, name := range []string{"from", "to"} {
+		traversal, diags := hcl.AbsTraversalForExpr(content.Attributes[name].Expr)
+		if diags.HasErrors() {
+			return move{}, fmt.Errorf("moved %s must be an address: %s", name, diags.Error())
+		}
+		addrs[i] = traversalAddress(traversal)
+		if moduleAddress != "" {
+			addrs[i] = moduleAddress + "." + addrs[i]
+		}
+	}
+	return move{from: addrs[0], to: addrs[1]}, nil
+}
+
+// previousAddresses follows moves backwards from addr, returning each
+// address it had before, most recent first.
+func previousAddresses(addr string, moves []move) []string {
+	var prev []string
+	seen := map[string]bool{addr: true}
+	for changed := true; changed; {
+		changed = false
+		for _, m := range moves {
+			old, ok := movedFrom(addr, m)
+			if !ok || seen[old] {
+				continue
+			}
+			prev = append(prev, old)
+			seen[old] = true
+			addr = old
+			changed = true
+		}
+	}
+	return prev
+}
+
+// movedFrom returns the address addr had before m: m.from if addr is m.to,
+// or addr with the m.to prefix replaced when m moves a whole resource or
+// module containing addr.
+func movedFrom(addr string, m move) (string, bool) {
+	if addr == m.to {
+		return m.from, true
+	}
+	rest, ok := strings.CutPrefix(addr, m.to)
+	if ok && (strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, "[")) {
+		return m.from + rest, true
+	}
+	return "", false
+}
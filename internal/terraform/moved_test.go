@@ -0,0 +1,119 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParser_ParseHCLDir_MovedBlocks(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"main.tf": `
+moved {
+  from = aws_instance.server
+  to   = aws_instance.app
+}
+
+moved {
+  from = aws_instance.legacy
+  to   = aws_instance.server
+}
+
+moved {
+  from = aws_instance.single
+  to   = aws_instance.pool[0]
+}
+
+moved {
+  from = module.old_db
+  to   = module.db
+}
+
+resource "aws_instance" "app" {
+  ami = "ami-123"
+}
+
+resource "aws_instance" "pool" {
+  count = 2
+  ami   = "ami-123"
+}
+
+module "db" {
+  source = "./modules/db"
+}`,
+		"modules/db/main.tf": `
+moved {
+  from = aws_instance.primary
+  to   = aws_instance.this
+}
+
+resource "aws_instance" "this" {
+  ami = "ami-123"
+}`,
+	})
+
+	instances, err := NewParser().ParseHCLDir(tmpDir)
+	if err != nil {
+		t.Fatalf("ParseHCLDir() error = %v", err)
+	}
+
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{key: "aws_instance.app", want: []string{"aws_instance.server", "aws_instance.legacy"}},
+		{key: "aws_instance.pool[0]", want: []string{"aws_instance.single"}},
+		{key: "aws_instance.pool[1]", want: nil},
+		{key: "module.db.aws_instance.this", want: []string{
+			"module.db.aws_instance.primary",
+			"module.old_db.aws_instance.this",
+			"module.old_db.aws_instance.primary",
+		}},
+	}
+	for _, tt := range tests {
+		inst, ok := instances[tt.key]
+		if !ok {
+			t.Fatalf("instance %q not found", tt.key)
+		}
+		if !reflect.DeepEqual(inst.PreviousAddresses, tt.want) {
+			t.Errorf("%s: PreviousAddresses = %v, want %v", tt.key, inst.PreviousAddresses, tt.want)
+		}
+	}
+}
+
+func TestParser_ParseHCL_InvalidMoved(t *testing.T) {
+	hcl := `
+moved {
+  from = aws_instance.old
+}`
+
+	if _, err := NewParser().ParseHCL([]byte(hcl), "main.tf"); err == nil {
+		t.Error("ParseHCL() expected error for moved without to")
+	}
+}
+
+func TestParser_ParseHCL_CheckAndRemovedBlocks(t *testing.T) {
+	hcl := `
+check "health" {
+  assert {
+    condition     = true
+    error_message = "unhealthy"
+  }
+}
+
+removed {
+  from = aws_instance.old
+}
+
+resource "aws_instance" "web" {
+  ami = "ami-123"
+}`
+
+	instances, err := NewParser().ParseHCL([]byte(hcl), "main.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+	if _, ok := instances["aws_instance.web"]; !ok {
+		t.Error("instance web not found")
+	}
+}
@@ -26,20 +26,54 @@ type StateParser interface {
 }
 
 // Parser handles parsing of Terraform configuration files.
-type Parser struct{}
+type Parser struct {
+	variables      map[string]string
+	varFiles       []string
+	planSource     PlanSource
+	referenceState string
+}
+
+// ParserOption is a functional option for configuring the Parser.
+type ParserOption func(*Parser)
+
+// WithVariables sets input variable values, as given with -var on the
+// Terraform command line. They take precedence over defaults and var files.
+func WithVariables(vars map[string]string) ParserOption {
+	return func(p *Parser) {
+		p.variables = vars
+	}
+}
+
+// WithVarFiles adds variable definition files, as given with -var-file.
+// They are loaded after terraform.tfvars and *.auto.tfvars.
+func WithVarFiles(files ...string) ParserOption {
+	return func(p *Parser) {
+		p.varFiles = append(p.varFiles, files...)
+	}
+}
 
-func NewParser() *Parser {
-	return &Parser{}
+// NewParser creates a new parser with the given options.
+func NewParser(opts ...ParserOption) *Parser {
+	p := &Parser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
-// State represents the structure of a Terraform state file.
+// State represents the structure of a Terraform state file. FormatVersion
+// is only present in `terraform show -json` output, which is parsed by
+// ParseShowJSON instead.
 type State struct {
-	Version   int             `json:"version"`
-	Resources []StateResource `json:"resources"`
+	Version       int             `json:"version"`
+	FormatVersion string          `json:"format_version"`
+	Resources     []StateResource `json:"resources"`
 }
 
 // StateResource represents a resource in the Terraform state.
 type StateResource struct {
+	Module    string          `json:"module"`
+	Mode      string          `json:"mode"`
 	Type      string          `json:"type"`
 	Name      string          `json:"name"`
 	Provider  string          `json:"provider"`
@@ -48,12 +82,32 @@ type StateResource struct {
 
 // StateInstance represents an instance of a resource.
 type StateInstance struct {
+	// IndexKey is the count index (number) or for_each key (string) of the
+	// instance, absent for resources without count or for_each.
+	IndexKey   json.RawMessage `json:"index_key,omitempty"`
 	Attributes json.RawMessage `json:"attributes"`
 }
 
+// Address returns the full resource address of the resource instance,
+// e.g. "module.web.aws_instance.this[0]" or `aws_instance.app["api"]`.
+func (r *StateResource) Address(inst *StateInstance) string {
+	addr := r.Type + "." + r.Name
+	if r.Mode == "data" {
+		addr = "data." + addr
+	}
+	if r.Module != "" {
+		addr = r.Module + "." + addr
+	}
+	if len(inst.IndexKey) > 0 && string(inst.IndexKey) != "null" {
+		addr += "[" + string(inst.IndexKey) + "]"
+	}
+	return addr
+}
+
 // EC2Attributes represents the attributes of an EC2 instance in Terraform state.
 type EC2Attributes struct {
 	ID                  string                `json:"id"`
+	ARN                 string                `json:"arn"`
 	AMI                 string                `json:"ami"`
 	InstanceType        string                `json:"instance_type"`
 	AvailabilityZone    string                `json:"availability_zone"`
@@ -89,7 +143,14 @@ func (p *Parser) ParseStateFile(filePath string) (map[string]*models.EC2Instance
 		return nil, fmt.Errorf("failed to read state file: %w", err)
 	}
 
-	return p.ParseStateJSON(data)
+	instances, err := p.ParseStateJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, inst := range instances {
+		inst.StateFile = filePath
+	}
+	return instances, nil
 }
 
 func (p *Parser) ParseStateJSON(data []byte) (map[string]*models.EC2Instance, error) {
@@ -99,30 +160,39 @@ func (p *Parser) ParseStateJSON(data []byte) (map[string]*models.EC2Instance, er
 		logger.Error("failed to parse state JSON", "error", err)
 		return nil, fmt.Errorf("failed to parse state JSON: %w", err)
 	}
+	if state.FormatVersion != "" {
+		return p.ParseShowJSON(data)
+	}
 
 	instances := make(map[string]*models.EC2Instance)
 
-	for _, resource := range state.Resources {
-		if resource.Type != "aws_instance" {
+	for i := range state.Resources {
+		resource := &state.Resources[i]
+		// Data sources (mode "data") describe existing instances that are
+		// read, not managed, by Terraform and are not subject to drift.
+		if resource.Type != "aws_instance" || resource.Mode == "data" {
 			continue
 		}
 
-		for _, inst := range resource.Instances {
-			ec2Inst, err := p.parseEC2Attributes(inst.Attributes)
+		for j := range resource.Instances {
+			address := resource.Address(&resource.Instances[j])
+			ec2Inst, err := p.parseEC2Attributes(resource.Instances[j].Attributes)
 			if err != nil {
 				logger.Error(
 					"failed to parse EC2 attributes",
-					"resource",
-					resource.Name,
+					"address",
+					address,
 					"error",
 					err,
 				)
 				return nil, fmt.Errorf(
 					"failed to parse EC2 attributes for %s: %w",
-					resource.Name,
+					address,
 					err,
 				)
 			}
+			ec2Inst.Address = address
+			ec2Inst.Provider = stateProviderAddress(resource.Provider)
 			instances[ec2Inst.InstanceID] = ec2Inst
 		}
 	}
@@ -139,6 +209,7 @@ func (p *Parser) parseEC2Attributes(data json.RawMessage) (*models.EC2Instance,
 
 	instance := &models.EC2Instance{
 		InstanceID:         attrs.ID,
+		IDKnown:            attrs.ID != "",
 		InstanceType:       attrs.InstanceType,
 		AMI:                attrs.AMI,
 		AvailabilityZone:   attrs.AvailabilityZone,
@@ -150,9 +221,16 @@ func (p *Parser) parseEC2Attributes(data json.RawMessage) (*models.EC2Instance,
 		Monitoring:         attrs.Monitoring,
 		IAMInstanceProfile: attrs.IAMInstanceProfile,
 		Tags:               attrs.Tags,
+		Region:             regionFromARN(attrs.ARN),
+	}
+
+	if instance.Region == "" {
+		instance.Region = regionFromZone(attrs.AvailabilityZone)
 	}
 
-	if len(instance.Tags) == 0 && len(attrs.TagsAll) > 0 {
+	// tags_all includes the provider's default_tags and is what the
+	// instance actually carries in AWS.
+	if attrs.TagsAll != nil {
 		instance.Tags = attrs.TagsAll
 	}
 
@@ -178,13 +256,23 @@ func (p *Parser) parseEC2Attributes(data json.RawMessage) (*models.EC2Instance,
 }
 
 func (p *Parser) ParseFile(filePath string) (map[string]*models.EC2Instance, error) {
+	if info, err := os.Stat(filePath); err == nil && info.IsDir() {
+		return p.ParseHCLDir(filePath)
+	}
+
 	ext := strings.ToLower(filepath.Ext(filePath))
+	switch {
+	case isJSONConfig(filePath):
+		ext = tfJSONExt
+	case isJSONVarFile(filePath):
+		return nil, fmt.Errorf("%s is a variable file, load it with WithVarFiles", filePath)
+	}
 	logger.Debug("parsing Terraform file", "path", filePath, "extension", ext)
 
 	switch ext {
 	case ".tfstate", ".json":
 		return p.ParseStateFile(filePath)
-	case ".tf":
+	case ".tf", tfJSONExt:
 		return p.ParseHCLFile(filePath)
 	default:
 		logger.Error("unsupported file type", "path", filePath, "extension", ext)
@@ -404,6 +404,190 @@ func TestParser_TagsAllFallback(t *testing.T) {
 	}
 }
 
+func TestParser_TagsAllPreferred(t *testing.T) {
+	json := `{
+		"version": 4,
+		"resources": [
+			{
+				"type": "aws_instance",
+				"name": "test",
+				"instances": [
+					{
+						"attributes": {
+							"id": "i-123",
+							"tags": {"Name": "web"},
+							"tags_all": {"Name": "web", "Environment": "prod"}
+						}
+					}
+				]
+			}
+		]
+	}`
+
+	instances, err := NewParser().ParseStateJSON([]byte(json))
+	if err != nil {
+		t.Fatalf("ParseStateJSON() error = %v", err)
+	}
+
+	inst := instances["i-123"]
+	if len(inst.Tags) != 2 || inst.Tags["Environment"] != "prod" {
+		t.Errorf("Expected tags_all including default tags, got %v", inst.Tags)
+	}
+}
+
+func TestParser_ParseStateJSON_ProviderRegion(t *testing.T) {
+	json := `{
+		"version": 4,
+		"resources": [
+			{
+				"type": "aws_instance",
+				"name": "east",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [
+					{"attributes": {"id": "i-east", "arn": "arn:aws:ec2:us-east-1:123456789012:instance/i-east"}}
+				]
+			},
+			{
+				"module": "module.dr",
+				"type": "aws_instance",
+				"name": "west",
+				"provider": "module.dr.provider[\"registry.terraform.io/hashicorp/aws\"].west",
+				"instances": [
+					{"attributes": {"id": "i-west", "availability_zone": "us-west-2b"}}
+				]
+			},
+			{
+				"type": "aws_instance",
+				"name": "legacy",
+				"provider": "provider.aws.eu",
+				"instances": [
+					{"attributes": {"id": "i-legacy", "availability_zone": "us-west-2-lax-1a"}}
+				]
+			}
+		]
+	}`
+
+	instances, err := NewParser().ParseStateJSON([]byte(json))
+	if err != nil {
+		t.Fatalf("ParseStateJSON() error = %v", err)
+	}
+
+	tests := []struct {
+		id       string
+		provider string
+		region   string
+	}{
+		{id: "i-east", provider: "aws", region: "us-east-1"},
+		{id: "i-west", provider: "aws.west", region: "us-west-2"},
+		{id: "i-legacy", provider: "aws.eu", region: ""},
+	}
+	for _, tt := range tests {
+		inst := instances[tt.id]
+		if inst.Provider != tt.provider {
+			t.Errorf("%s: Provider = %q, want %q", tt.id, inst.Provider, tt.provider)
+		}
+		if inst.Region != tt.region {
+			t.Errorf("%s: Region = %q, want %q", tt.id, inst.Region, tt.region)
+		}
+	}
+}
+
+func TestParser_ParseStateFile_Sources(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "terraform.tfstate")
+	state := `{"version": 4, "resources": [{"module": "module.web", "type": "aws_instance", "name": "this",
+		"instances": [{"index_key": 0, "attributes": {"id": "i-123", "instance_type": "t2.micro"}}]}]}`
+	if err := os.WriteFile(statePath, []byte(state), 0o644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+
+	instances, err := NewParser().ParseStateFile(statePath)
+	if err != nil {
+		t.Fatalf("ParseStateFile() error = %v", err)
+	}
+
+	src, ok := instances["i-123"].SourceOf("instance_type")
+	if !ok {
+		t.Fatal("SourceOf() found no source")
+	}
+	if src.Address != "module.web.aws_instance.this[0]" || src.File != statePath || src.Line != 0 {
+		t.Errorf("SourceOf() = %+v, want the resource address in %s", src, statePath)
+	}
+}
+
+func TestParser_ParseStateJSON_Addresses(t *testing.T) {
+	json := `{
+		"version": 4,
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "bastion",
+				"instances": [
+					{"attributes": {"id": "i-root"}}
+				]
+			},
+			{
+				"module": "module.web",
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "this",
+				"instances": [
+					{"index_key": 0, "attributes": {"id": "i-web0"}},
+					{"index_key": 1, "attributes": {"id": "i-web1"}}
+				]
+			},
+			{
+				"module": "module.app.module.tier[\"api\"]",
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "server",
+				"instances": [
+					{"index_key": "blue", "attributes": {"id": "i-api-blue"}}
+				]
+			},
+			{
+				"mode": "data",
+				"type": "aws_instance",
+				"name": "lookup",
+				"instances": [
+					{"attributes": {"id": "i-data"}}
+				]
+			}
+		]
+	}`
+
+	p := NewParser()
+	instances, err := p.ParseStateJSON([]byte(json))
+	if err != nil {
+		t.Fatalf("ParseStateJSON() error = %v", err)
+	}
+
+	if _, ok := instances["i-data"]; ok {
+		t.Error("data source instance should be excluded")
+	}
+	if len(instances) != 4 {
+		t.Fatalf("expected 4 instances, got %d", len(instances))
+	}
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"i-root", "aws_instance.bastion"},
+		{"i-web0", "module.web.aws_instance.this[0]"},
+		{"i-web1", "module.web.aws_instance.this[1]"},
+		{"i-api-blue", `module.app.module.tier["api"].aws_instance.server["blue"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := instances[tt.id].Address; got != tt.want {
+				t.Errorf("Address = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParser_SecurityGroupsFallback(t *testing.T) {
 	t.Run("prefer vpc_security_group_ids", func(t *testing.T) {
 		json := `{
@@ -583,7 +767,7 @@ resource "aws_instance" "web" {
 		t.Fatalf("ParseHCL() error = %v", err)
 	}
 
-	inst, ok := instances["web"]
+	inst, ok := instances["aws_instance.web"]
 	if !ok {
 		t.Fatal("Instance 'web' not found")
 	}
@@ -593,7 +777,7 @@ resource "aws_instance" "web" {
 		got  any
 		want any
 	}{
-		{"InstanceID", inst.InstanceID, "web"},
+		{"InstanceID", inst.InstanceID, "aws_instance.web"},
 		{"AMI", inst.AMI, "ami-abc123"},
 		{"InstanceType", inst.InstanceType, "t3.medium"},
 		{"AvailabilityZone", inst.AvailabilityZone, "us-west-2a"},
@@ -643,7 +827,7 @@ resource "aws_instance" "test" {
 		t.Errorf("ParseHCLFile() returned %d instances, want 1", len(instances))
 	}
 
-	if _, ok := instances["test"]; !ok {
+	if _, ok := instances["aws_instance.test"]; !ok {
 		t.Error("Instance 'test' not found")
 	}
 }
@@ -0,0 +1,289 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/solomon-os/go-test/internal/logger"
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+// defaultProvider is the configuration address of the default, unaliased
+// AWS provider.
+const defaultProvider = "aws"
+
+// providerSchema selects the provider "aws" arguments the parser uses. Other
+// arguments are ignored.
+var providerSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "alias"},
+		{Name: "region"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "default_tags"},
+	},
+}
+
+var defaultTagsSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "tags"},
+	},
+}
+
+// resourceProviderSchema selects the provider meta-argument of a resource.
+var resourceProviderSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "provider"},
+	},
+}
+
+// providerConfig is the part of a provider "aws" configuration that
+// affects the attributes the AWS provider reports for an instance.
+type providerConfig struct {
+	// region is the region of the configuration, empty if it is not set
+	// or cannot be evaluated.
+	region string
+
+	// defaultTags are the tags of the default_tags block, merged into the
+	// tags of every resource using the provider.
+	defaultTags map[string]string
+
+	// defaultTagsRange is the range of the default_tags tags argument.
+	defaultTagsRange hcl.Range
+}
+
+// tagsAll returns the effective tags of a resource as the AWS provider
+// computes tags_all: the provider's default tags overridden by the
+// resource's own tags.
+func (c *providerConfig) tagsAll(tags map[string]string) map[string]string {
+	if c == nil || len(c.defaultTags) == 0 {
+		return tags
+	}
+	all := make(map[string]string, len(c.defaultTags)+len(tags))
+	for k, v := range c.defaultTags {
+		all[k] = v
+	}
+	for k, v := range tags {
+		all[k] = v
+	}
+	return all
+}
+
+// recordTagSources records the default_tags argument as the source of the
+// default tags the resource does not override, and of its tags as a whole
+// if the resource sets none. It must be called before the tags are merged.
+func (c *providerConfig) recordTagSources(instance *models.EC2Instance) {
+	if c == nil || len(c.defaultTags) == 0 {
+		return
+	}
+	src := sourceOf(c.defaultTagsRange)
+	if _, ok := instance.Sources["tags"]; !ok {
+		instance.Sources["tags"] = src
+	}
+	for key := range c.defaultTags {
+		if _, overridden := instance.Tags[key]; !overridden {
+			instance.Sources["tags."+key] = src
+		}
+	}
+}
+
+// instanceRegion returns the region an instance managed by the
+// configuration is in: the configuration's region or, without one, the
+// region of the instance's availability zone.
+func (c *providerConfig) instanceRegion(zone string) string {
+	if c != nil && c.region != "" {
+		return c.region
+	}
+	return regionFromZone(zone)
+}
+
+// moduleProviders returns the AWS provider configurations available in a
+// module: those passed in by the caller, overridden by provider "aws"
+// blocks declared in the module itself. Keys are configuration addresses,
+// "aws" or "aws.<alias>".
+func moduleProviders(
+	inherited map[string]*providerConfig,
+	blocks hcl.Blocks,
+	ctx *hcl.EvalContext,
+) (map[string]*providerConfig, error) {
+	providers := make(map[string]*providerConfig, len(inherited))
+	for addr, cfg := range inherited {
+		providers[addr] = cfg
+	}
+
+	for _, block := range blocks {
+		if block.Type != "provider" || len(block.Labels) == 0 || block.Labels[0] != "aws" {
+			continue
+		}
+		addr, cfg, err := decodeProvider(block, ctx)
+		if err != nil {
+			return nil, NewParseError(block.DefRange.Filename, "hcl", err).
+				WithLineNumber(block.DefRange.Start.Line)
+		}
+		providers[addr] = cfg
+	}
+	return providers, nil
+}
+
+// decodeProvider reads the alias and default_tags of a provider "aws" block.
+func decodeProvider(block *hcl.Block, ctx *hcl.EvalContext) (string, *providerConfig, error) {
+	content, _, diags := block.Body.PartialContent(providerSchema)
+	if diags.HasErrors() {
+		return "", nil, fmt.Errorf("failed to decode provider: %s", diags.Error())
+	}
+
+	addr := defaultProvider
+	if attr, ok := content.Attributes["alias"]; ok {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || val.IsNull() || !val.IsKnown() {
+			return "", nil, fmt.Errorf("provider alias must be a literal string")
+		}
+		addr += "." + valueToString(val)
+	}
+
+	cfg := &providerConfig{}
+	if attr, ok := content.Attributes["region"]; ok {
+		val, diags := attr.Expr.Value(ctx)
+		if diags.HasErrors() || val.IsNull() || !val.IsKnown() {
+			logger.Debug("unable to evaluate provider region", "provider", addr)
+		} else {
+			cfg.region = valueToString(val)
+		}
+	}
+	for _, blk := range content.Blocks {
+		tags, diags := blk.Body.Content(defaultTagsSchema)
+		if diags.HasErrors() {
+			return "", nil, fmt.Errorf("failed to decode default_tags of %s: %s", addr, diags.Error())
+		}
+		attr, ok := tags.Attributes["tags"]
+		if !ok {
+			continue
+		}
+		val, diags := attr.Expr.Value(ctx)
+		if diags.HasErrors() {
+			return "", nil, fmt.Errorf("failed to evaluate default_tags of %s: %s", addr, diags.Error())
+		}
+		cfg.defaultTags = valueToStringMap(val)
+		cfg.defaultTagsRange = attr.Range
+	}
+	return addr, cfg, nil
+}
+
+// resourceProvider returns the configuration address of the provider a
+// resource block uses, from its provider meta-argument, e.g. "aws.west".
+func resourceProvider(block *hcl.Block) (string, error) {
+	content, _, _ := block.Body.PartialContent(resourceProviderSchema)
+	attr, ok := content.Attributes["provider"]
+	if !ok {
+		return defaultProvider, nil
+	}
+	return providerAddress(attr.Expr)
+}
+
+// childProviders returns the provider configurations passed to a child
+// module. Without a providers argument the child inherits the default
+// provider; aliased configurations are only passed explicitly, as in
+// providers = { aws = aws.west }.
+func childProviders(parent map[string]*providerConfig, attrs hcl.Attributes) (map[string]*providerConfig, error) {
+	attr, ok := attrs["providers"]
+	if !ok {
+		if cfg, ok := parent[defaultProvider]; ok {
+			return map[string]*providerConfig{defaultProvider: cfg}, nil
+		}
+		return map[string]*providerConfig{}, nil
+	}
+
+	pairs, diags := hcl.ExprMap(attr.Expr)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("providers must be a map of provider references: %s", diags.Error())
+	}
+
+	child := make(map[string]*providerConfig, len(pairs))
+	for _, pair := range pairs {
+		key, err := providerAddress(pair.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := providerAddress(pair.Value)
+		if err != nil {
+			return nil, err
+		}
+		if cfg, ok := parent[value]; ok {
+			child[key] = cfg
+		}
+	}
+	return child, nil
+}
+
+// providerAddress renders a provider reference such as aws.west.
+func providerAddress(expr hcl.Expression) (string, error) {
+	traversal, diags := hcl.AbsTraversalForExpr(expr)
+	if diags.HasErrors() {
+		return "", fmt.Errorf("invalid provider reference: %s", diags.Error())
+	}
+
+	parts := make([]string, 0, len(traversal))
+	for _, step := range traversal {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			parts = append(parts, s.Name)
+		case hcl.TraverseAttr:
+			parts = append(parts, s.Name)
+		default:
+			return "", fmt.Errorf("invalid provider reference")
+		}
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// stateProviderAddress converts the provider of a state resource, e.g.
+// provider["registry.terraform.io/hashicorp/aws"].west, possibly prefixed
+// with a module path, or the legacy provider.aws.west, to a configuration
+// address such as "aws.west".
+func stateProviderAddress(provider string) string {
+	i := strings.LastIndex(provider, "provider[")
+	if i < 0 {
+		if i = strings.LastIndex(provider, "provider."); i < 0 {
+			return ""
+		}
+		return provider[i+len("provider."):]
+	}
+
+	rest := provider[i+len("provider["):]
+	end := strings.Index(rest, "]")
+	if end < 0 {
+		return ""
+	}
+	source := strings.Trim(rest[:end], `"`)
+	addr := source[strings.LastIndex(source, "/")+1:]
+	if alias := strings.TrimPrefix(rest[end+1:], "."); alias != "" {
+		addr += "." + alias
+	}
+	return addr
+}
+
+// zonePattern matches standard availability zone names such as us-west-2a.
+// Local and Wavelength zones do not follow it.
+var zonePattern = regexp.MustCompile(`^([a-z]{2}(?:-[a-z]+)+-\d+)[a-z]$`)
+
+// regionFromZone returns the region of a standard availability zone, or an
+// empty string.
+func regionFromZone(zone string) string {
+	m := zonePattern.FindStringSubmatch(zone)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// regionFromARN returns the region field of an ARN, or an empty string.
+func regionFromARN(arn string) string {
+	parts := strings.SplitN(arn, ":", 5)
+	if len(parts) < 5 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[3]
+}
@@ -0,0 +1,273 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/solomon-os/go-test/internal/logger"
+)
+
+// WithReferenceState evaluates HCL with the Terraform state at path, so that
+// references to other resources and data sources, such as aws_subnet.app.id
+// or data.aws_ami.ubuntu.id, resolve to the values recorded in the state.
+// path is a local state file, `terraform show -json` output of a state or
+// saved plan, or a directory resolved as by ResolveStatePaths to a single
+// state. Without it, attributes using such references are unknown.
+func WithReferenceState(path string) ParserOption {
+	return func(p *Parser) {
+		p.referenceState = path
+	}
+}
+
+// stateReferences holds the values of the resources and data sources of a
+// state, by module address ("" for the root module) and then by the name
+// expressions refer to them by: the resource type, or "data".
+type stateReferences map[string]map[string]cty.Value
+
+// loadReferenceState reads the state configured with WithReferenceState.
+// Without one, no references resolve.
+func (p *Parser) loadReferenceState() (stateReferences, error) {
+	if p.referenceState == "" {
+		return stateReferences{}, nil
+	}
+
+	path, err := resolveReferenceState(p.referenceState)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("loading reference state", "path", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reference state: %w", err)
+	}
+	refs, err := decodeStateReferences(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse reference state %s: %w", p.referenceState, err)
+	}
+	return refs, nil
+}
+
+// resolveReferenceState returns the local state file path names, resolved
+// as --tf-state paths are. Remote state, configurations and paths naming
+// more than one state are rejected.
+func resolveReferenceState(path string) (string, error) {
+	if strings.Contains(path, "://") {
+		return "", fmt.Errorf("reference state %s: remote state is not supported, pass a local state file", path)
+	}
+	refs, err := ResolveStatePaths([]string{path})
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve reference state: %w", err)
+	}
+	if len(refs) != 1 {
+		return "", fmt.Errorf("reference state %s names %d states, expected one", path, len(refs))
+	}
+
+	state := refs[0].StateFile
+	if info, err := os.Stat(state); err == nil && info.IsDir() {
+		return "", fmt.Errorf("reference state %s is a directory without %s", path, defaultStateFile)
+	}
+	if strings.EqualFold(filepath.Ext(state), ".tf") || isJSONConfig(state) {
+		return "", fmt.Errorf("reference state %s is a Terraform configuration, not a state", path)
+	}
+	return state, nil
+}
+
+// decodeStateReferences converts every resource and data source of a state
+// to the value an expression referring to it evaluates to: an object of its
+// attributes, or a tuple or object of them for resources using count or
+// for_each.
+func decodeStateReferences(data []byte) (stateReferences, error) {
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	resources := state.Resources
+	if state.FormatVersion != "" {
+		var err error
+		if resources, err = showStateResources(data); err != nil {
+			return nil, err
+		}
+	}
+
+	// Values by module, then resource type, then resource name.
+	managed := make(map[string]map[string]map[string]cty.Value)
+	dataSources := make(map[string]map[string]map[string]cty.Value)
+	for i := range resources {
+		res := &resources[i]
+		val, err := resourceValue(res)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", res.Type, res.Name, err)
+		}
+		tree := managed
+		if res.Mode == "data" {
+			tree = dataSources
+		}
+		addValue(tree, res, val)
+	}
+
+	refs := make(stateReferences)
+	for module, types := range managed {
+		refs.module(module)
+		for typ, names := range types {
+			refs[module][typ] = cty.ObjectVal(names)
+		}
+	}
+	for module, types := range dataSources {
+		objects := make(map[string]cty.Value, len(types))
+		for typ, names := range types {
+			objects[typ] = cty.ObjectVal(names)
+		}
+		refs.module(module)["data"] = cty.ObjectVal(objects)
+	}
+	return refs, nil
+}
+
+// showStateResources returns the resources of `terraform show -json`
+// output in the form of a state: those of a state's values, or of a saved
+// plan's prior state.
+func showStateResources(data []byte) ([]StateResource, error) {
+	var out ShowOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	values := out.Values
+	if values == nil && out.PriorState != nil {
+		values = out.PriorState.Values
+	}
+	if values == nil {
+		return nil, fmt.Errorf("show output has neither values nor prior_state")
+	}
+	return appendShowResources(nil, &values.RootModule), nil
+}
+
+// appendShowResources appends the resources of mod and its child modules,
+// grouping resource instances by resource.
+func appendShowResources(resources []StateResource, mod *ShowModule) []StateResource {
+	byName := make(map[string]int)
+	for _, res := range mod.Resources {
+		key := res.Mode + "." + res.Type + "." + res.Name
+		i, ok := byName[key]
+		if !ok {
+			i = len(resources)
+			byName[key] = i
+			resources = append(resources, StateResource{
+				Module: mod.Address,
+				Mode:   res.Mode,
+				Type:   res.Type,
+				Name:   res.Name,
+			})
+		}
+		resources[i].Instances = append(resources[i].Instances, StateInstance{
+			IndexKey:   res.Index,
+			Attributes: res.Values,
+		})
+	}
+	for i := range mod.ChildModules {
+		resources = appendShowResources(resources, &mod.ChildModules[i])
+	}
+	return resources
+}
+
+// module returns the values of module, creating them if needed.
+func (r stateReferences) module(address string) map[string]cty.Value {
+	if r[address] == nil {
+		r[address] = make(map[string]cty.Value)
+	}
+	return r[address]
+}
+
+// addValue records the value of res in tree.
+func addValue(tree map[string]map[string]map[string]cty.Value, res *StateResource, val cty.Value) {
+	if tree[res.Module] == nil {
+		tree[res.Module] = make(map[string]map[string]cty.Value)
+	}
+	if tree[res.Module][res.Type] == nil {
+		tree[res.Module][res.Type] = make(map[string]cty.Value)
+	}
+	tree[res.Module][res.Type][res.Name] = val
+}
+
+// resourceValue returns the value of a resource: the attributes of its
+// single instance, a tuple of instances by count index, or an object of
+// instances by for_each key.
+func resourceValue(res *StateResource) (cty.Value, error) {
+	byIndex := make(map[int]cty.Value)
+	byKey := make(map[string]cty.Value)
+	var single cty.Value
+
+	for j := range res.Instances {
+		inst := &res.Instances[j]
+		val, err := attributesValue(inst.Attributes)
+		if err != nil {
+			return cty.NilVal, err
+		}
+
+		key := string(inst.IndexKey)
+		if key == "" || key == "null" {
+			single = val
+			continue
+		}
+		if n, err := strconv.Atoi(key); err == nil {
+			byIndex[n] = val
+			continue
+		}
+		var name string
+		if err := json.Unmarshal(inst.IndexKey, &name); err != nil {
+			return cty.NilVal, fmt.Errorf("invalid index key %s", key)
+		}
+		byKey[name] = val
+	}
+
+	switch {
+	case len(byKey) > 0:
+		return cty.ObjectVal(byKey), nil
+	case len(byIndex) > 0:
+		return indexedTuple(byIndex), nil
+	case single != cty.NilVal:
+		return single, nil
+	}
+	return cty.DynamicVal, nil
+}
+
+// indexedTuple returns the values of a count resource as a tuple. Missing
+// indexes are unknown.
+func indexedTuple(byIndex map[int]cty.Value) cty.Value {
+	indexes := make([]int, 0, len(byIndex))
+	for n := range byIndex {
+		indexes = append(indexes, n)
+	}
+	sort.Ints(indexes)
+
+	elems := make([]cty.Value, indexes[len(indexes)-1]+1)
+	for i := range elems {
+		elems[i] = cty.DynamicVal
+		if val, ok := byIndex[i]; ok {
+			elems[i] = val
+		}
+	}
+	return cty.TupleVal(elems)
+}
+
+// attributesValue decodes the attributes of a resource instance.
+func attributesValue(data json.RawMessage) (cty.Value, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return cty.EmptyObjectVal, nil
+	}
+	ty, err := ctyjson.ImpliedType(data)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("invalid attributes: %w", err)
+	}
+	val, err := ctyjson.Unmarshal(data, ty)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("invalid attributes: %w", err)
+	}
+	return val, nil
+}
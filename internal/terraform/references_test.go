@@ -0,0 +1,250 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const referenceState = `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_subnet",
+      "name": "app",
+      "instances": [{"attributes": {"id": "subnet-app", "availability_zone": "us-east-1b"}}]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_security_group",
+      "name": "web",
+      "instances": [
+        {"index_key": 0, "attributes": {"id": "sg-0"}},
+        {"index_key": 1, "attributes": {"id": "sg-1"}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_key_pair",
+      "name": "deploy",
+      "instances": [{"index_key": "prod", "attributes": {"key_name": "deploy-prod"}}]
+    },
+    {
+      "mode": "data",
+      "type": "aws_ami",
+      "name": "ubuntu",
+      "instances": [{"attributes": {"id": "ami-ubuntu"}}]
+    },
+    {
+      "module": "module.web",
+      "mode": "managed",
+      "type": "aws_subnet",
+      "name": "this",
+      "instances": [{"attributes": {"id": "subnet-module"}}]
+    }
+  ]
+}`
+
+func TestParser_ParseHCLDir_ReferenceState(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"main.tf": `
+resource "aws_instance" "web" {
+  ami                    = data.aws_ami.ubuntu.id
+  subnet_id              = aws_subnet.app.id
+  availability_zone      = aws_subnet.app.availability_zone
+  key_name               = aws_key_pair.deploy["prod"].key_name
+  vpc_security_group_ids = aws_security_group.web[*].id
+}
+
+module "web" {
+  source = "./modules/web"
+}`,
+		"modules/web/main.tf": `
+resource "aws_instance" "this" {
+  ami       = "ami-123"
+  subnet_id = aws_subnet.this.id
+}`,
+	})
+	statePath := filepath.Join(t.TempDir(), "terraform.tfstate")
+	if err := os.WriteFile(statePath, []byte(referenceState), 0o644); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+
+	instances, err := NewParser(WithReferenceState(statePath)).ParseHCLDir(tmpDir)
+	if err != nil {
+		t.Fatalf("ParseHCLDir() error = %v", err)
+	}
+
+	web := instances["aws_instance.web"]
+	if web.AMI != "ami-ubuntu" {
+		t.Errorf("AMI = %q, want ami-ubuntu", web.AMI)
+	}
+	if web.SubnetID != "subnet-app" {
+		t.Errorf("SubnetID = %q, want subnet-app", web.SubnetID)
+	}
+	if web.AvailabilityZone != "us-east-1b" {
+		t.Errorf("AvailabilityZone = %q, want us-east-1b", web.AvailabilityZone)
+	}
+	if web.KeyName != "deploy-prod" {
+		t.Errorf("KeyName = %q, want deploy-prod", web.KeyName)
+	}
+	if want := []string{"sg-0", "sg-1"}; !reflect.DeepEqual(web.SecurityGroups, want) {
+		t.Errorf("SecurityGroups = %v, want %v", web.SecurityGroups, want)
+	}
+
+	module := instances["module.web.aws_instance.this"]
+	if module == nil {
+		t.Fatal("module instance not found")
+	}
+	if module.SubnetID != "subnet-module" {
+		t.Errorf("module SubnetID = %q, want subnet-module", module.SubnetID)
+	}
+}
+
+func TestParser_ParseHCL_WithoutReferenceState(t *testing.T) {
+	hcl := `
+resource "aws_instance" "web" {
+  ami       = "ami-123"
+  subnet_id = aws_subnet.app.id
+}`
+
+	instances, err := NewParser().ParseHCL([]byte(hcl), "main.tf")
+	if err != nil {
+		t.Fatalf("ParseHCL() error = %v", err)
+	}
+	web := instances["aws_instance.web"]
+	if web.AMI != "ami-123" {
+		t.Errorf("AMI = %q, want ami-123", web.AMI)
+	}
+	if web.SubnetID != "" {
+		t.Errorf("SubnetID = %q, want it unresolved", web.SubnetID)
+	}
+}
+
+func TestParser_ReferenceState_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		missing bool
+	}{
+		{name: "missing file", missing: true},
+		{name: "invalid JSON", content: "{not json"},
+		{name: "invalid index key", content: `{"resources": [{"mode": "managed", "type": "aws_subnet", "name": "a",
+			"instances": [{"index_key": true, "attributes": {}}]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statePath := filepath.Join(t.TempDir(), "terraform.tfstate")
+			if !tt.missing {
+				if err := os.WriteFile(statePath, []byte(tt.content), 0o644); err != nil {
+					t.Fatalf("failed to write state: %v", err)
+				}
+			}
+
+			_, err := NewParser(WithReferenceState(statePath)).
+				ParseHCL([]byte(`resource "aws_instance" "web" {}`), "main.tf")
+			if err == nil {
+				t.Error("ParseHCL() expected error for invalid reference state")
+			}
+		})
+	}
+}
+
+const showReferenceState = `{
+  "format_version": "1.0",
+  "values": {
+    "root_module": {
+      "resources": [
+        {"address": "aws_subnet.app", "mode": "managed", "type": "aws_subnet", "name": "app",
+          "values": {"id": "subnet-app"}},
+        {"address": "aws_security_group.web[0]", "mode": "managed", "type": "aws_security_group",
+          "name": "web", "index": 0, "values": {"id": "sg-0"}},
+        {"address": "aws_security_group.web[1]", "mode": "managed", "type": "aws_security_group",
+          "name": "web", "index": 1, "values": {"id": "sg-1"}}
+      ],
+      "child_modules": [
+        {
+          "address": "module.web",
+          "resources": [
+            {"address": "module.web.aws_subnet.this", "mode": "managed", "type": "aws_subnet",
+              "name": "this", "values": {"id": "subnet-module"}}
+          ]
+        }
+      ]
+    }
+  }
+}`
+
+func TestParser_ReferenceState_Formats(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"show.json":               showReferenceState,
+		"plan.json":               `{"format_version": "1.2", "prior_state": ` + showReferenceState + `}`,
+		"local/terraform.tfstate": referenceState,
+	})
+	hcl := `
+resource "aws_instance" "web" {
+  subnet_id              = aws_subnet.app.id
+  vpc_security_group_ids = aws_security_group.web[*].id
+}`
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "show -json state", path: "show.json"},
+		{name: "show -json plan", path: "plan.json"},
+		{name: "directory with state", path: "local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser(WithReferenceState(filepath.Join(tmpDir, tt.path)))
+			instances, err := parser.ParseHCL([]byte(hcl), "main.tf")
+			if err != nil {
+				t.Fatalf("ParseHCL() error = %v", err)
+			}
+			web := instances["aws_instance.web"]
+			if web.SubnetID != "subnet-app" {
+				t.Errorf("SubnetID = %q, want subnet-app", web.SubnetID)
+			}
+			if want := []string{"sg-0", "sg-1"}; !reflect.DeepEqual(web.SecurityGroups, want) {
+				t.Errorf("SecurityGroups = %v, want %v", web.SecurityGroups, want)
+			}
+		})
+	}
+}
+
+func TestParser_ReferenceState_Unsupported(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"main.tf":        `resource "aws_subnet" "app" {}`,
+		"module/a.tf":    `resource "aws_subnet" "app" {}`,
+		"envs/a.tfstate": referenceState,
+		"envs/b.tfstate": referenceState,
+	})
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "remote state", path: "s3://bucket/terraform.tfstate"},
+		{name: "configuration file", path: filepath.Join(tmpDir, "main.tf")},
+		{name: "module directory", path: filepath.Join(tmpDir, "module")},
+		{name: "several states", path: filepath.Join(tmpDir, "envs/*.tfstate")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser(WithReferenceState(tt.path)).
+				ParseHCL([]byte(`resource "aws_instance" "web" {}`), "main.tf")
+			if err == nil {
+				t.Errorf("ParseHCL() expected error for reference state %s", tt.path)
+			}
+		})
+	}
+}
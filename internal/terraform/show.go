@@ -0,0 +1,208 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/solomon-os/go-test/internal/logger"
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+// PlanSource selects which part of a saved plan's JSON representation is
+// used as the desired state.
+type PlanSource string
+
+const (
+	// PlanSourcePlanned uses planned_values, the state the plan will produce
+	// once applied. This is the default.
+	PlanSourcePlanned PlanSource = "planned"
+
+	// PlanSourcePrior uses prior_state, the refreshed state the plan was
+	// computed from.
+	PlanSourcePrior PlanSource = "prior"
+
+	// PlanSourceDrift uses the resources listed in resource_drift, with the
+	// values Terraform recorded before refreshing. Comparing them with AWS
+	// reproduces Terraform's own refresh-only drift view.
+	PlanSourceDrift PlanSource = "drift"
+)
+
+// ParsePlanSource returns the PlanSource named s: planned, prior or drift.
+// An empty s selects PlanSourcePlanned.
+func ParsePlanSource(s string) (PlanSource, error) {
+	switch source := PlanSource(s); source {
+	case "":
+		return PlanSourcePlanned, nil
+	case PlanSourcePlanned, PlanSourcePrior, PlanSourceDrift:
+		return source, nil
+	}
+	return "", fmt.Errorf("unknown plan source %q: expected planned, prior or drift", s)
+}
+
+// WithPlanSource selects the part of a saved plan used as the desired state
+// when parsing `terraform show -json` output of a plan. It has no effect on
+// state files.
+func WithPlanSource(source PlanSource) ParserOption {
+	return func(p *Parser) {
+		p.planSource = source
+	}
+}
+
+// ShowOutput is the JSON document produced by `terraform show -json` for
+// either a state (values) or a saved plan (planned_values, prior_state,
+// resource_drift).
+type ShowOutput struct {
+	FormatVersion string       `json:"format_version"`
+	Values        *ShowValues  `json:"values"`
+	PlannedValues *ShowValues  `json:"planned_values"`
+	PriorState    *ShowState   `json:"prior_state"`
+	ResourceDrift []ShowChange `json:"resource_drift"`
+}
+
+// ShowState is a state embedded in plan output.
+type ShowState struct {
+	Values *ShowValues `json:"values"`
+}
+
+// ShowValues holds the resources of a state or plan, grouped by module.
+type ShowValues struct {
+	RootModule ShowModule `json:"root_module"`
+}
+
+// ShowModule is a module in `terraform show -json` output.
+type ShowModule struct {
+	Address      string         `json:"address"`
+	Resources    []ShowResource `json:"resources"`
+	ChildModules []ShowModule   `json:"child_modules"`
+}
+
+// ShowResource is a resource instance in `terraform show -json` output.
+// Values has the same shape as the attributes of a state resource instance.
+type ShowResource struct {
+	Address string          `json:"address"`
+	Mode    string          `json:"mode"`
+	Type    string          `json:"type"`
+	Name    string          `json:"name"`
+	Index   json.RawMessage `json:"index,omitempty"`
+	Values  json.RawMessage `json:"values"`
+}
+
+// ShowChange is an entry of resource_drift or resource_changes.
+type ShowChange struct {
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+	Type    string `json:"type"`
+	Change  struct {
+		Actions []string        `json:"actions"`
+		Before  json.RawMessage `json:"before"`
+		After   json.RawMessage `json:"after"`
+	} `json:"change"`
+}
+
+// ParseShowJSON parses the output of `terraform show -json`. For a state it
+// reads values; for a saved plan it reads the part selected with
+// WithPlanSource. Instances without an ID yet, such as those a plan will
+// create, are keyed by their address.
+func (p *Parser) ParseShowJSON(data []byte) (map[string]*models.EC2Instance, error) {
+	logger.Debug("parsing terraform show JSON", "bytes", len(data))
+	var out ShowOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		logger.Error("failed to parse show JSON", "error", err)
+		return nil, fmt.Errorf("failed to parse show JSON: %w", err)
+	}
+
+	instances := make(map[string]*models.EC2Instance)
+	var err error
+	switch {
+	case out.Values != nil:
+		err = p.collectShowModule(&out.Values.RootModule, instances)
+	case out.PlannedValues != nil || out.PriorState != nil || out.ResourceDrift != nil:
+		err = p.collectPlan(&out, instances)
+	}
+	if err != nil {
+		logger.Error("failed to parse show JSON", "error", err)
+		return nil, err
+	}
+
+	logger.Info("parsed terraform show output", "instance_count", len(instances))
+	return instances, nil
+}
+
+// collectPlan adds the instances of the selected part of a plan.
+func (p *Parser) collectPlan(out *ShowOutput, instances map[string]*models.EC2Instance) error {
+	source := p.planSource
+	if source == "" {
+		source = PlanSourcePlanned
+	}
+	logger.Debug("reading saved plan", "source", source)
+
+	switch source {
+	case PlanSourcePlanned:
+		if out.PlannedValues == nil {
+			return fmt.Errorf("plan has no planned_values")
+		}
+		return p.collectShowModule(&out.PlannedValues.RootModule, instances)
+	case PlanSourcePrior:
+		if out.PriorState == nil || out.PriorState.Values == nil {
+			return fmt.Errorf("plan has no prior_state")
+		}
+		return p.collectShowModule(&out.PriorState.Values.RootModule, instances)
+	case PlanSourceDrift:
+		return p.collectDrift(out.ResourceDrift, instances)
+	default:
+		return fmt.Errorf("unknown plan source %q", source)
+	}
+}
+
+// collectShowModule adds the managed aws_instance resources of mod and its
+// child modules.
+func (p *Parser) collectShowModule(mod *ShowModule, instances map[string]*models.EC2Instance) error {
+	for _, res := range mod.Resources {
+		if res.Type != "aws_instance" || res.Mode == "data" {
+			continue
+		}
+		if err := p.addShowInstance(res.Address, res.Values, instances); err != nil {
+			return err
+		}
+	}
+	for i := range mod.ChildModules {
+		if err := p.collectShowModule(&mod.ChildModules[i], instances); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectDrift adds the aws_instance resources Terraform found changed
+// outside of Terraform, using their values from before the refresh.
+func (p *Parser) collectDrift(changes []ShowChange, instances map[string]*models.EC2Instance) error {
+	for _, c := range changes {
+		if c.Type != "aws_instance" || c.Mode == "data" {
+			continue
+		}
+		if err := p.addShowInstance(c.Address, c.Change.Before, instances); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Parser) addShowInstance(
+	address string,
+	values json.RawMessage,
+	instances map[string]*models.EC2Instance,
+) error {
+	if len(values) == 0 || string(values) == "null" {
+		return nil
+	}
+	inst, err := p.parseEC2Attributes(values)
+	if err != nil {
+		return fmt.Errorf("failed to parse EC2 attributes for %s: %w", address, err)
+	}
+	inst.Address = address
+	if inst.InstanceID == "" {
+		inst.InstanceID = address
+	}
+	instances[inst.InstanceID] = inst
+	return nil
+}
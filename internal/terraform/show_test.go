@@ -0,0 +1,224 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const showStateJSON = `{
+  "format_version": "1.0",
+  "terraform_version": "1.7.0",
+  "values": {
+    "root_module": {
+      "resources": [
+        {
+          "address": "aws_instance.bastion",
+          "mode": "managed",
+          "type": "aws_instance",
+          "name": "bastion",
+          "values": {"id": "i-bastion", "instance_type": "t3.micro"}
+        },
+        {
+          "address": "data.aws_instance.lookup",
+          "mode": "data",
+          "type": "aws_instance",
+          "name": "lookup",
+          "values": {"id": "i-data"}
+        }
+      ],
+      "child_modules": [
+        {
+          "address": "module.web",
+          "resources": [
+            {
+              "address": "module.web.aws_instance.this[0]",
+              "mode": "managed",
+              "type": "aws_instance",
+              "name": "this",
+              "index": 0,
+              "values": {"id": "i-web0", "instance_type": "t3.large", "tags": {"Name": "web"}}
+            }
+          ]
+        }
+      ]
+    }
+  }
+}`
+
+const showPlanJSON = `{
+  "format_version": "1.2",
+  "terraform_version": "1.7.0",
+  "planned_values": {
+    "root_module": {
+      "resources": [
+        {
+          "address": "aws_instance.web",
+          "mode": "managed",
+          "type": "aws_instance",
+          "name": "web",
+          "values": {"id": "i-web", "instance_type": "t3.large"}
+        },
+        {
+          "address": "aws_instance.new",
+          "mode": "managed",
+          "type": "aws_instance",
+          "name": "new",
+          "values": {"instance_type": "t3.nano"}
+        }
+      ]
+    }
+  },
+  "prior_state": {
+    "format_version": "1.0",
+    "values": {
+      "root_module": {
+        "resources": [
+          {
+            "address": "aws_instance.web",
+            "mode": "managed",
+            "type": "aws_instance",
+            "name": "web",
+            "values": {"id": "i-web", "instance_type": "t3.medium"}
+          }
+        ]
+      }
+    }
+  },
+  "resource_drift": [
+    {
+      "address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "change": {
+        "actions": ["update"],
+        "before": {"id": "i-web", "instance_type": "t3.small"},
+        "after": {"id": "i-web", "instance_type": "t3.medium"}
+      }
+    }
+  ]
+}`
+
+func TestParser_ParseShowJSON_State(t *testing.T) {
+	p := NewParser()
+	instances, err := p.ParseShowJSON([]byte(showStateJSON))
+	if err != nil {
+		t.Fatalf("ParseShowJSON() error = %v", err)
+	}
+
+	if len(instances) != 2 {
+		t.Fatalf("expected 2 instances, got %d", len(instances))
+	}
+
+	web := instances["i-web0"]
+	if web == nil {
+		t.Fatal("instance i-web0 from child module not found")
+	}
+	if web.Address != "module.web.aws_instance.this[0]" {
+		t.Errorf("Address = %s, want module.web.aws_instance.this[0]", web.Address)
+	}
+	if web.InstanceType != "t3.large" {
+		t.Errorf("InstanceType = %s, want t3.large", web.InstanceType)
+	}
+	if web.Tags["Name"] != "web" {
+		t.Errorf("Tags[Name] = %s, want web", web.Tags["Name"])
+	}
+	if _, ok := instances["i-data"]; ok {
+		t.Error("data source instance should be excluded")
+	}
+}
+
+func TestParser_ParseShowJSON_Plan(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    PlanSource
+		wantCount int
+		wantType  string
+		wantErr   bool
+	}{
+		{name: "default is planned values", source: "", wantCount: 2, wantType: "t3.large"},
+		{name: "planned values", source: PlanSourcePlanned, wantCount: 2, wantType: "t3.large"},
+		{name: "prior state", source: PlanSourcePrior, wantCount: 1, wantType: "t3.medium"},
+		{name: "resource drift", source: PlanSourceDrift, wantCount: 1, wantType: "t3.small"},
+		{name: "unknown source", source: "bogus", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(WithPlanSource(tt.source))
+			instances, err := p.ParseShowJSON([]byte(showPlanJSON))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseShowJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(instances) != tt.wantCount {
+				t.Errorf("got %d instances, want %d", len(instances), tt.wantCount)
+			}
+			if got := instances["i-web"].InstanceType; got != tt.wantType {
+				t.Errorf("InstanceType = %s, want %s", got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestParsePlanSource(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    PlanSource
+		wantErr bool
+	}{
+		{in: "", want: PlanSourcePlanned},
+		{in: "planned", want: PlanSourcePlanned},
+		{in: "prior", want: PlanSourcePrior},
+		{in: "drift", want: PlanSourceDrift},
+		{in: "after", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePlanSource(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePlanSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePlanSource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ParseShowJSON_PendingCreate(t *testing.T) {
+	p := NewParser()
+	instances, err := p.ParseShowJSON([]byte(showPlanJSON))
+	if err != nil {
+		t.Fatalf("ParseShowJSON() error = %v", err)
+	}
+
+	inst, ok := instances["aws_instance.new"]
+	if !ok {
+		t.Fatal("instance without ID should be keyed by address")
+	}
+	if inst.InstanceType != "t3.nano" {
+		t.Errorf("InstanceType = %s, want t3.nano", inst.InstanceType)
+	}
+}
+
+func TestParser_ParseFile_ShowJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(showStateJSON), 0o644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	p := NewParser()
+	instances, err := p.ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	if _, ok := instances["i-bastion"]; !ok {
+		t.Error("show JSON should be detected from a .json file")
+	}
+}
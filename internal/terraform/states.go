@@ -0,0 +1,236 @@
+package terraform
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/solomon-os/go-test/internal/logger"
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+const (
+	// workspaceStateDir is the directory the local backend keeps the state
+	// of non-default workspaces in, as <dir>/<workspace>/terraform.tfstate.
+	workspaceStateDir = "terraform.tfstate.d"
+
+	// defaultStateFile is the local backend's state of the default
+	// workspace.
+	defaultStateFile = "terraform.tfstate"
+
+	// defaultWorkspace is the name of Terraform's default workspace.
+	defaultWorkspace = "default"
+)
+
+// ResolveStatePaths expands state paths into the states to read. Each path
+// may be a glob, a file, or a directory. A directory containing
+// terraform.tfstate.d is expanded into the state of every workspace,
+// including terraform.tfstate for the default workspace; a directory
+// containing only terraform.tfstate is resolved to that state; any other
+// directory is kept as a module directory. Paths matched more than once are
+// returned once, in the order first matched.
+func ResolveStatePaths(paths []string) ([]models.StateRef, error) {
+	return resolvePaths(paths, discoverStates)
+}
+
+// ResolveConfigPaths expands HCL configuration paths, which may be globs,
+// files or module directories. Unlike ResolveStatePaths, directories are
+// always kept as module directories, even if they hold a state.
+func ResolveConfigPaths(paths []string) ([]models.StateRef, error) {
+	return resolvePaths(paths, func(string) ([]models.StateRef, error) { return nil, nil })
+}
+
+// resolvePaths expands the globs of paths, and each match with discover,
+// which returns nil to keep the match itself.
+func resolvePaths(
+	paths []string,
+	discover func(path string) ([]models.StateRef, error),
+) ([]models.StateRef, error) {
+	var refs []models.StateRef
+	seen := make(map[string]bool)
+	add := func(ref models.StateRef) {
+		key := filepath.Clean(ref.StateFile)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		refs = append(refs, ref)
+	}
+
+	for _, pattern := range paths {
+		matches, err := expandStatePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range matches {
+			found, err := discover(path)
+			if err != nil {
+				return nil, err
+			}
+			if found == nil {
+				add(models.StateRef{StateFile: path, Workspace: workspaceOf(path)})
+				continue
+			}
+			for _, ref := range found {
+				add(ref)
+			}
+		}
+	}
+
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("no Terraform state paths given")
+	}
+	logger.Debug("resolved state paths", "count", len(refs))
+	return refs, nil
+}
+
+// expandStatePattern returns the paths matching a glob, or the path itself
+// if it has no glob metacharacters.
+func expandStatePattern(pattern string) ([]string, error) {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state path pattern %q: %w", pattern, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no Terraform state matches %q", pattern)
+	}
+	return matches, nil
+}
+
+// discoverStates returns the states of the directory path: those of its
+// workspaces, or its terraform.tfstate alone. It returns nil if path is not
+// a directory holding either.
+func discoverStates(path string) ([]models.StateRef, error) {
+	workspaces, err := discoverWorkspaces(path)
+	if workspaces != nil || err != nil {
+		return workspaces, err
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+	state := filepath.Join(path, defaultStateFile)
+	if info, err := os.Stat(state); err != nil || info.IsDir() {
+		return nil, nil
+	}
+	return []models.StateRef{{StateFile: state}}, nil
+}
+
+// discoverWorkspaces returns the workspace states of dir, or nil if path is
+// not a directory with a terraform.tfstate.d directory.
+func discoverWorkspaces(path string) ([]models.StateRef, error) {
+	wsDir := filepath.Join(path, workspaceStateDir)
+	if info, err := os.Stat(wsDir); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(wsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspaces: %w", err)
+	}
+
+	refs := make([]models.StateRef, 0, len(entries)+1)
+	if _, err := os.Stat(filepath.Join(path, defaultStateFile)); err == nil {
+		refs = append(refs, models.StateRef{
+			StateFile: filepath.Join(path, defaultStateFile),
+			Workspace: defaultWorkspace,
+		})
+	}
+	for _, entry := range entries {
+		state := filepath.Join(wsDir, entry.Name(), defaultStateFile)
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(state); err != nil {
+			logger.Debug("skipping workspace without state", "workspace", entry.Name())
+			continue
+		}
+		refs = append(refs, models.StateRef{StateFile: state, Workspace: entry.Name()})
+	}
+
+	logger.Debug("discovered workspaces", "dir", path, "count", len(refs))
+	return refs, nil
+}
+
+// workspaceOf returns the workspace of a state file inside
+// terraform.tfstate.d, or an empty string.
+func workspaceOf(path string) string {
+	dir := filepath.Dir(path)
+	if filepath.Base(filepath.Dir(dir)) != workspaceStateDir {
+		return ""
+	}
+	return filepath.Base(dir)
+}
+
+// ParseStates parses each state with parser and merges the instances with
+// MergeStates.
+func ParseStates(
+	parser StateParser,
+	refs []models.StateRef,
+) (map[string]*models.EC2Instance, []models.StateConflict, error) {
+	states := make([]map[string]*models.EC2Instance, 0, len(refs))
+	for _, ref := range refs {
+		instances, err := parser.ParseFile(ref.StateFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", ref.StateFile, err)
+		}
+		states = append(states, instances)
+	}
+
+	merged, conflicts := MergeStates(refs, states)
+	return merged, conflicts, nil
+}
+
+// MergeStates merges the instances read from each state in refs, with
+// states[i] read from refs[i], recording on each the state and workspace it
+// was read from. An instance ID found in more than one state is kept from
+// the first and reported as a conflict. Instances without an instance ID,
+// such as HCL resources not yet correlated, are keyed by state and address,
+// so that same-named resources of different configurations are all kept.
+func MergeStates(
+	refs []models.StateRef,
+	states []map[string]*models.EC2Instance,
+) (map[string]*models.EC2Instance, []models.StateConflict) {
+	merged := make(map[string]*models.EC2Instance)
+	found := make(map[string][]models.StateRef)
+
+	for i, ref := range refs {
+		for key, inst := range states[i] {
+			if inst.IDKnown {
+				found[key] = append(found[key], ref)
+			} else {
+				key = ref.StateFile + ":" + cmp.Or(inst.Address, key)
+			}
+			if _, ok := merged[key]; ok {
+				continue
+			}
+			inst.StateFile = ref.StateFile
+			inst.Workspace = ref.Workspace
+			merged[key] = inst
+		}
+	}
+
+	var conflicts []models.StateConflict
+	for id, states := range found {
+		if len(states) < 2 {
+			continue
+		}
+		logger.Warn("instance found in several states", "instance_id", id, "states", len(states))
+		conflicts = append(conflicts, models.StateConflict{InstanceID: id, States: states})
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].InstanceID < conflicts[j].InstanceID
+	})
+
+	logger.Info("merged Terraform states",
+		"states", len(refs),
+		"instance_count", len(merged),
+		"conflicts", len(conflicts),
+	)
+	return merged, conflicts
+}
@@ -0,0 +1,189 @@
+package terraform
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/solomon-os/go-test/internal/models"
+)
+
+func stateWithInstance(id, instanceType string) string {
+	return `{"version": 4, "resources": [{"mode": "managed", "type": "aws_instance", "name": "web",
+		"instances": [{"attributes": {"id": "` + id + `", "instance_type": "` + instanceType + `"}}]}]}`
+}
+
+func TestResolveStatePaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"app/terraform.tfstate":                          stateWithInstance("i-default", "t3.micro"),
+		"app/terraform.tfstate.d/prod/terraform.tfstate": stateWithInstance("i-prod", "t3.large"),
+		"app/terraform.tfstate.d/dev/terraform.tfstate":  stateWithInstance("i-dev", "t3.small"),
+		"app/terraform.tfstate.d/empty/.keep":            "",
+		"envs/prod.tfstate":                              stateWithInstance("i-1", "t3.micro"),
+		"envs/staging.tfstate":                           stateWithInstance("i-2", "t3.micro"),
+		"module/main.tf":                                 `resource "aws_instance" "web" {}`,
+		"local/main.tf":                                  `resource "aws_instance" "web" {}`,
+		"local/terraform.tfstate":                        stateWithInstance("i-local", "t3.micro"),
+	})
+	path := func(p string) string { return filepath.Join(tmpDir, p) }
+
+	tests := []struct {
+		name    string
+		paths   []string
+		want    []models.StateRef
+		wantErr bool
+	}{
+		{
+			name:  "workspace directory",
+			paths: []string{path("app")},
+			want: []models.StateRef{
+				{StateFile: path("app/terraform.tfstate"), Workspace: "default"},
+				{StateFile: path("app/terraform.tfstate.d/dev/terraform.tfstate"), Workspace: "dev"},
+				{StateFile: path("app/terraform.tfstate.d/prod/terraform.tfstate"), Workspace: "prod"},
+			},
+		},
+		{
+			name:  "glob and repeated path",
+			paths: []string{path("envs/*.tfstate"), path("envs/prod.tfstate")},
+			want: []models.StateRef{
+				{StateFile: path("envs/prod.tfstate")},
+				{StateFile: path("envs/staging.tfstate")},
+			},
+		},
+		{
+			name:  "glob over workspace states",
+			paths: []string{path("app/terraform.tfstate.d/*/terraform.tfstate")},
+			want: []models.StateRef{
+				{StateFile: path("app/terraform.tfstate.d/dev/terraform.tfstate"), Workspace: "dev"},
+				{StateFile: path("app/terraform.tfstate.d/prod/terraform.tfstate"), Workspace: "prod"},
+			},
+		},
+		{
+			name:  "module directory",
+			paths: []string{path("module")},
+			want:  []models.StateRef{{StateFile: path("module")}},
+		},
+		{
+			name:  "directory with default workspace state only",
+			paths: []string{path("local")},
+			want:  []models.StateRef{{StateFile: path("local/terraform.tfstate")}},
+		},
+		{
+			name:    "glob without matches",
+			paths:   []string{path("missing/*.tfstate")},
+			wantErr: true,
+		},
+		{
+			name:    "no paths",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveStatePaths(tt.paths)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveStatePaths() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ResolveStatePaths() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ResolveStatePaths()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResolveConfigPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"local/main.tf":           `resource "aws_instance" "web" {}`,
+		"local/terraform.tfstate": stateWithInstance("i-local", "t3.micro"),
+	})
+	dir := filepath.Join(tmpDir, "local")
+
+	got, err := ResolveConfigPaths([]string{dir})
+	if err != nil {
+		t.Fatalf("ResolveConfigPaths() error = %v", err)
+	}
+	if len(got) != 1 || got[0].StateFile != dir {
+		t.Errorf("ResolveConfigPaths() = %v, want the module directory %s", got, dir)
+	}
+}
+
+func TestParseStates(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"terraform.tfstate":                          stateWithInstance("i-shared", "t3.micro"),
+		"terraform.tfstate.d/prod/terraform.tfstate": stateWithInstance("i-prod", "t3.large"),
+		"terraform.tfstate.d/qa/terraform.tfstate":   stateWithInstance("i-shared", "t3.small"),
+	})
+
+	refs, err := ResolveStatePaths([]string{tmpDir})
+	if err != nil {
+		t.Fatalf("ResolveStatePaths() error = %v", err)
+	}
+
+	instances, conflicts, err := ParseStates(NewParser(), refs)
+	if err != nil {
+		t.Fatalf("ParseStates() error = %v", err)
+	}
+
+	if len(instances) != 2 {
+		t.Fatalf("expected 2 instances, got %v", keys(instances))
+	}
+
+	prod := instances["i-prod"]
+	if prod.Workspace != "prod" || prod.StateFile != filepath.Join(tmpDir, "terraform.tfstate.d/prod/terraform.tfstate") {
+		t.Errorf("i-prod read from %s (%s)", prod.StateFile, prod.Workspace)
+	}
+
+	shared := instances["i-shared"]
+	if shared.Workspace != "default" || shared.InstanceType != "t3.micro" {
+		t.Errorf("i-shared should be kept from the default workspace, got %s (%s)", shared.Workspace, shared.InstanceType)
+	}
+
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %v", conflicts)
+	}
+	if conflicts[0].InstanceID != "i-shared" || len(conflicts[0].States) != 2 {
+		t.Errorf("unexpected conflict %+v", conflicts[0])
+	}
+	if conflicts[0].States[1].Workspace != "qa" {
+		t.Errorf("second conflicting state = %+v, want workspace qa", conflicts[0].States[1])
+	}
+
+	t.Run("same-named resources without instance IDs", func(t *testing.T) {
+		dir := t.TempDir()
+		resource := `resource "aws_instance" "web" { instance_type = "t3.micro" }`
+		writeFiles(t, dir, map[string]string{"app/main.tf": resource, "batch/main.tf": resource})
+		refs := []models.StateRef{
+			{StateFile: filepath.Join(dir, "app/main.tf")},
+			{StateFile: filepath.Join(dir, "batch/main.tf")},
+		}
+
+		instances, conflicts, err := ParseStates(NewParser(), refs)
+		if err != nil {
+			t.Fatalf("ParseStates() error = %v", err)
+		}
+		if len(instances) != 2 || len(conflicts) != 0 {
+			t.Errorf("got instances %v and conflicts %v, want both resources kept without conflict",
+				keys(instances), conflicts)
+		}
+		for _, ref := range refs {
+			if inst := instances[ref.StateFile+":aws_instance.web"]; inst == nil || inst.StateFile != ref.StateFile {
+				t.Errorf("aws_instance.web of %s = %+v", ref.StateFile, inst)
+			}
+		}
+	})
+
+	t.Run("parse error names the state", func(t *testing.T) {
+		_, _, err := ParseStates(NewParser(), []models.StateRef{{StateFile: filepath.Join(tmpDir, "missing.tfstate")}})
+		if err == nil {
+			t.Error("ParseStates() expected error for missing state")
+		}
+	})
+}